@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/bench"
+)
+
+// runBench implements `injective bench` and returns the process exit code:
+// 0 when the test ran, 1 when it couldn't and 2 on bad flags.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage: injective bench [flags]\n\n"+
+			"All streams come from this host, so start the target with MAX_STREAMS_PER_IP=0 RATE_LIMIT_RPS=0\n"+
+			"or it refuses all but 10 of them with 429 Too Many Requests.\n\n")
+		fs.PrintDefaults()
+	}
+
+	var cfg bench.Config
+	fs.StringVar(&cfg.URL, "url", "http://localhost:8080/stream", "stream endpoint")
+	fs.StringVar(&cfg.Token, "token", os.Getenv("INJECTIVE_TOKEN"), "bearer token (defaults to $INJECTIVE_TOKEN)")
+	fs.IntVar(&cfg.Clients, "clients", 100, "number of concurrent streams")
+	fs.DurationVar(&cfg.Duration, "duration", 30*time.Second, "how long to hold streams open after ramp-up")
+	fs.DurationVar(&cfg.Ramp, "ramp", 5*time.Second, "spread connections over this period")
+	fs.Float64Var(&cfg.SlowFraction, "slow-fraction", 0, "share of clients that read slowly (0 to 1)")
+	fs.IntVar(&cfg.SlowRate, "slow-rate", 64, "bytes per second read by slow clients")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return 2
+	}
+	if cfg.Clients <= 0 || cfg.Duration <= 0 || cfg.Ramp < 0 || cfg.SlowRate <= 0 || cfg.SlowFraction < 0 || cfg.SlowFraction > 1 {
+		fmt.Fprintln(os.Stderr, "--clients, --duration and --slow-rate must be positive, --ramp not negative and --slow-fraction between 0 and 1")
+		return 2
+	}
+
+	// Ctrl+C ends the test early but still prints what was measured.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "opening %d streams to %s over %v, holding for %v...\n", cfg.Clients, cfg.URL, cfg.Ramp, cfg.Duration)
+	report, err := bench.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "injective bench: %v\n", err)
+		return 1
+	}
+	report.WriteTo(os.Stdout)
+	return 0
+}
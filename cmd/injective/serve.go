@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/server"
+	"github.com/matheusdutrademoura/injective/internal/tlsutil"
+)
+
+// runServe runs the SSE server until interrupted.
+func runServe() {
+	injectiveServer := server.NewServer()
+
+	// runCtx stops fetching and fan-out once shutdown begins.
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+	go func() {
+		if err := injectiveServer.Run(runCtx); err != nil {
+			log.Fatalf("price pipeline error: %v", err)
+		}
+	}()
+
+	// Create the HTTP server with a timeout-aware configuration.
+	httpServer := &http.Server{
+		Addr:         ":8080",
+		Handler:      injectiveServer.Handler(),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	// TLS is optional; when enabled the certificate is reloaded from disk on renewal
+	// and HTTP/2 is negotiated so browsers can multiplex many streams over one connection.
+	scheme := "http"
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		tlsConfig, err := tlsutil.NewServerConfig(tlsutil.Config{
+			CertFile:          certFile,
+			KeyFile:           os.Getenv("TLS_KEY_FILE"),
+			ClientCAFile:      os.Getenv("TLS_CLIENT_CA_FILE"),
+			RequireClientCert: os.Getenv("TLS_CLIENT_AUTH") == "require",
+		})
+		if err != nil {
+			log.Fatalf("TLS configuration error: %v", err)
+		}
+
+		httpServer.TLSConfig = tlsConfig
+		httpServer.Protocols = new(http.Protocols)
+		httpServer.Protocols.SetHTTP1(true)
+		httpServer.Protocols.SetHTTP2(true)
+		scheme = "https"
+	}
+
+	log.Printf("Frontend available at %s://localhost:8080/", scheme)
+	log.Printf("SSE stream available at %s://localhost:8080/stream", scheme)
+
+	// Start server in a goroutine so we can shut it down gracefully later.
+	go func() {
+		var err error
+		if httpServer.TLSConfig != nil {
+			// Certificates come from TLSConfig.GetCertificate, so no file paths are passed here.
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Listen for interrupts and SIGTERM (sent by Docker and Kubernetes) to perform graceful shutdown.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	<-stop // wait for interrupt
+
+	log.Println("Shutting down server...")
+	stopRun()
+
+	// Give active connections time to finish.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Say goodbye to stream clients first: http.Server.Shutdown doesn't touch streaming
+	// responses and would otherwise wait for them until the deadline.
+	if err := injectiveServer.Shutdown(ctx); err != nil {
+		log.Printf("Timed out draining stream clients: %v", err)
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	log.Println("Server exited gracefully")
+}
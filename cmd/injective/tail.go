@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/matheusdutrademoura/injective/internal/tail"
+)
+
+// runTail implements `injective tail` and returns the process exit code:
+// 0 on success or interrupt, 1 when streaming fails and 2 on bad flags.
+func runTail(args []string) int {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: injective tail [flags]")
+		fs.PrintDefaults()
+	}
+
+	var cfg tail.Config
+	var instruments string
+	var noColor bool
+	fs.StringVar(&cfg.URL, "url", "http://localhost:8080/stream", "stream endpoint")
+	fs.StringVar(&cfg.Token, "token", os.Getenv("INJECTIVE_TOKEN"), "bearer token (defaults to $INJECTIVE_TOKEN)")
+	fs.StringVar(&instruments, "instruments", "", "comma-separated instruments to show (all when empty)")
+	fs.DurationVar(&cfg.Since, "since", 0, "replay this much history first, e.g. 10m")
+	fs.StringVar(&cfg.Format, "format", tail.FormatTable, "output format: table, json or csv")
+	fs.IntVar(&cfg.Count, "count", 0, "exit after this many updates (0 streams forever)")
+	fs.IntVar(&cfg.MaxFailures, "max-failures", 5, "exit after this many connection attempts in a row fail (0 retries forever)")
+	fs.BoolVar(&noColor, "no-color", false, "disable colored output")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return 2
+	}
+	switch cfg.Format {
+	case tail.FormatTable, tail.FormatJSON, tail.FormatCSV:
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (want table, json or csv)\n", cfg.Format)
+		return 2
+	}
+	if cfg.Count < 0 || cfg.Since < 0 || cfg.MaxFailures < 0 {
+		fmt.Fprintln(os.Stderr, "--count, --since and --max-failures must not be negative")
+		return 2
+	}
+	if instruments != "" {
+		cfg.Instruments = strings.Split(instruments, ",")
+	}
+	cfg.Color = !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := tail.Run(ctx, cfg, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "injective tail: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,461 @@
+// Package alerts evaluates user-defined price alert rules against the stream and notifies
+// their webhooks, retrying failed deliveries from a persisted queue.
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/expr"
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/spread"
+)
+
+// ErrNotFound is returned for rules and dead letters that don't exist or belong to someone else.
+var ErrNotFound = errors.New("not found")
+
+// ErrWebhookNotAllowed is returned for rules whose webhook host is outside Config.WebhookHosts.
+var ErrWebhookNotAllowed = errors.New("webhook host not allowed")
+
+// Config tunes the alert manager. Zero values take the defaults noted.
+type Config struct {
+	Path        string        // File persisting rules and deliveries; empty keeps them in memory
+	MaxAttempts int           // Delivery attempts before a notification is dead-lettered (default 8)
+	MinBackoff  time.Duration // Delay before the first retry, doubling per attempt (default 1s)
+	MaxBackoff  time.Duration // Retry delay cap (default 5m)
+	Timeout     time.Duration // Per-attempt webhook timeout (default 10s)
+	MaxQueue    int           // Pending deliveries kept before new ones are dead-lettered (default 10000)
+
+	// WebhookHosts limits the hosts webhooks may point at; "*.example.com" matches any subdomain.
+	// Empty allows any host.
+	WebhookHosts []string
+	// AllowPrivate lets webhooks reach loopback, private and link-local addresses. Without it the
+	// default client refuses to connect to them, so rules can't be used to probe internal services.
+	AllowPrivate bool
+	Client       *http.Client // Defaults to a client enforcing AllowPrivate
+
+	// Env supplies expression rules with indicator readings and price history, and Watch starts
+	// maintaining the indicators they use, returning a function that stops it. Without them
+	// indicators and changes over a window are never known, so conditions using them don't hold.
+	Env   expr.Env
+	Watch func([]indicators.Spec) (release func())
+}
+
+// Manager holds the rules, evaluates them against each update and delivers notifications.
+// It is safe for concurrent use.
+type Manager struct {
+	cfg   Config
+	state state
+	mutex sync.Mutex
+
+	watch    map[string]*watch    // Evaluation state by rule ID
+	programs map[string]*program  // Compiled expression rules by ID
+	lastSeen map[string]time.Time // Latest update time by instrument, for staleness
+	started  time.Time
+	inflight map[string]bool // Deliveries being attempted
+	wake     chan struct{}
+}
+
+// state is everything persisted.
+type state struct {
+	Rules       []Rule     `json:"rules"`
+	Queue       []Delivery `json:"queue"`
+	DeadLetters []Delivery `json:"dead_letters"`
+}
+
+// watch is a rule's evaluation state. It lives in memory and restarts empty.
+type watch struct {
+	last    float64 // Previous price, for crossings
+	seen    bool
+	samples []sample // Prices within the window, for moves
+	fired   bool     // Staleness already reported
+	holds   bool     // Expression or spread condition held at the previous update
+	armed   bool     // Condition evaluated at least once
+}
+
+// program is an expression rule's compiled condition and the release of its indicators.
+type program struct {
+	*expr.Program
+	release func()
+}
+
+type sample struct {
+	at    time.Time
+	price float64
+}
+
+// New returns a manager, loading rules and pending deliveries from cfg.Path when it exists.
+func New(cfg Config) (*Manager, error) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = 10000
+	}
+	if cfg.Client == nil {
+		cfg.Client = webhookClient(cfg.AllowPrivate)
+	}
+	if cfg.Env == nil {
+		cfg.Env = unknownEnv{}
+	}
+	if cfg.Watch == nil {
+		cfg.Watch = func([]indicators.Spec) func() { return func() {} }
+	}
+
+	m := &Manager{
+		cfg:      cfg,
+		watch:    make(map[string]*watch),
+		programs: make(map[string]*program),
+		lastSeen: make(map[string]time.Time),
+		started:  time.Now(),
+		inflight: make(map[string]bool),
+		wake:     make(chan struct{}, 1),
+	}
+	if err := m.load(); err != nil {
+		return nil, fmt.Errorf("loading alerts: %w", err)
+	}
+	for _, r := range m.state.Rules {
+		if err := m.compile(r); err != nil {
+			return nil, fmt.Errorf("loading alert %s: %w", r.ID, err)
+		}
+	}
+	return m, nil
+}
+
+// Rules returns the owner's rules, oldest first.
+func (m *Manager) Rules(owner string) []Rule {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var rules []Rule
+	for _, r := range m.state.Rules {
+		if r.Owner == owner {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// Rule returns one of the owner's rules.
+func (m *Manager) Rule(owner, id string) (Rule, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if i := m.find(owner, id); i >= 0 {
+		return m.state.Rules[i], nil
+	}
+	return Rule{}, ErrNotFound
+}
+
+// Create adds a validated rule for owner, assigning its ID and, unless given, its secret.
+func (m *Manager) Create(owner string, r Rule) (Rule, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.checkWebhook(r.Webhook); err != nil {
+		return Rule{}, err
+	}
+	r.ID, r.Owner, r.CreatedAt = randomID(8), owner, time.Now().UTC()
+	if r.Secret == "" {
+		r.Secret = randomID(32)
+	}
+	if err := m.compile(r); err != nil {
+		return Rule{}, err
+	}
+	m.state.Rules = append(m.state.Rules, r)
+	return r, m.save()
+}
+
+// Update replaces one of the owner's rules with a validated definition, keeping its ID, creation time
+// and, unless a new one is given, its secret. Its evaluation starts over.
+func (m *Manager) Update(owner, id string, r Rule) (Rule, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	i := m.find(owner, id)
+	if i < 0 {
+		return Rule{}, ErrNotFound
+	}
+	if err := m.checkWebhook(r.Webhook); err != nil {
+		return Rule{}, err
+	}
+	old := m.state.Rules[i]
+	r.ID, r.Owner, r.CreatedAt = old.ID, old.Owner, old.CreatedAt
+	if r.Secret == "" {
+		r.Secret = old.Secret
+	}
+	m.forget(id)
+	if err := m.compile(r); err != nil {
+		return Rule{}, err
+	}
+	m.state.Rules[i] = r
+	return r, m.save()
+}
+
+// Delete removes one of the owner's rules. Its pending deliveries are dropped when their turn comes.
+func (m *Manager) Delete(owner, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	i := m.find(owner, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	m.state.Rules = slices.Delete(m.state.Rules, i, i+1)
+	m.forget(id)
+	return m.save()
+}
+
+// Reload replaces the rules and pending deliveries with those in cfg.Path, as saved by whichever
+// instance last managed them. Evaluation starts over.
+func (m *Manager) Reload() error {
+	if m.cfg.Path == "" {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	current := m.state
+	m.state = state{}
+	if err := m.load(); err != nil {
+		m.state = current
+		return fmt.Errorf("loading alerts: %w", err)
+	}
+	for _, r := range current.Rules {
+		m.forget(r.ID)
+	}
+	for _, r := range m.state.Rules {
+		if err := m.compile(r); err != nil {
+			return fmt.Errorf("loading alert %s: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// checkWebhook returns ErrWebhookNotAllowed unless the webhook's host is in cfg.WebhookHosts.
+func (m *Manager) checkWebhook(webhook string) error {
+	if len(m.cfg.WebhookHosts) == 0 {
+		return nil
+	}
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range m.cfg.WebhookHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrWebhookNotAllowed, host)
+}
+
+// compile prepares an expression rule's condition and starts maintaining its indicators.
+func (m *Manager) compile(r Rule) error {
+	if r.Kind != Expression {
+		return nil
+	}
+	p, err := expr.Compile(r.Expr)
+	if err != nil {
+		return err
+	}
+	m.programs[r.ID] = &program{p, m.cfg.Watch(p.Indicators())}
+	return nil
+}
+
+// forget drops a rule's evaluation state and compiled condition.
+func (m *Manager) forget(id string) {
+	delete(m.watch, id)
+	if p := m.programs[id]; p != nil {
+		p.release()
+		delete(m.programs, id)
+	}
+}
+
+func (m *Manager) find(owner, id string) int {
+	return slices.IndexFunc(m.state.Rules, func(r Rule) bool { return r.ID == id && r.Owner == owner })
+}
+
+// Evaluate checks every rule on the update's instrument and queues notifications for those that fire.
+func (m *Manager) Evaluate(u models.PriceUpdate) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if u.Timestamp.After(m.lastSeen[u.Instrument]) {
+		m.lastSeen[u.Instrument] = u.Timestamp
+	}
+	queued := false
+	for _, r := range m.state.Rules {
+		if r.Instrument != u.Instrument {
+			continue
+		}
+		w := m.watchFor(r.ID)
+		msg, from, ok := w.update(r, u)
+		if p := m.programs[r.ID]; p != nil {
+			msg, ok = w.becomes(r, u, p.Eval(u, m.cfg.Env))
+		}
+		if ok {
+			ev := Event{Instrument: u.Instrument, Price: u.Price, From: from, Seq: u.Seq, Message: msg, TriggeredAt: u.Timestamp}
+			if s, ok := spread.Of(u); r.Kind == Spread && ok {
+				ev.Spread = &s
+			}
+			m.enqueue(r, ev)
+			queued = true
+		}
+	}
+	if queued {
+		m.persist()
+	}
+}
+
+// checkStale fires stale rules whose instrument has had no update for their window.
+// Before the first update the window counts from the later of startup and the rule's creation.
+func (m *Manager) checkStale(now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	queued := false
+	for _, r := range m.state.Rules {
+		if r.Kind != Stale {
+			continue
+		}
+		w := m.watchFor(r.ID)
+		last := m.lastSeen[r.Instrument]
+		since := latest(last, m.started, r.CreatedAt)
+		if w.fired || now.Sub(since) < time.Duration(r.Window) {
+			continue
+		}
+		w.fired = true
+		msg := fmt.Sprintf("no %s price for %v", r.Instrument, now.Sub(since).Round(time.Second))
+		ev := Event{Instrument: r.Instrument, Message: msg, TriggeredAt: now.UTC()}
+		if !last.IsZero() {
+			ev.LastUpdate = last
+		}
+		m.enqueue(r, ev)
+		queued = true
+	}
+	if queued {
+		m.persist()
+	}
+}
+
+func (m *Manager) watchFor(id string) *watch {
+	w := m.watch[id]
+	if w == nil {
+		w = &watch{}
+		m.watch[id] = w
+	}
+	return w
+}
+
+// update feeds a price to the rule's state and reports whether the rule fired, with a description
+// and the reference price it moved from.
+func (w *watch) update(r Rule, u models.PriceUpdate) (string, float64, bool) {
+	prev, seen := w.last, w.seen
+	w.last, w.seen = u.Price, true
+	// Any price means the feed is alive again, re-arming staleness.
+	w.fired = false
+
+	switch r.Kind {
+	case CrossAbove:
+		if seen && prev < r.Level && u.Price >= r.Level {
+			return fmt.Sprintf("%s crossed above %g at %g", r.Instrument, r.Level, u.Price), prev, true
+		}
+	case CrossBelow:
+		if seen && prev > r.Level && u.Price <= r.Level {
+			return fmt.Sprintf("%s crossed below %g at %g", r.Instrument, r.Level, u.Price), prev, true
+		}
+	case Move:
+		cutoff := u.Timestamp.Add(-time.Duration(r.Window))
+		w.samples = slices.DeleteFunc(w.samples, func(s sample) bool { return s.at.Before(cutoff) })
+		// Report the largest move from any price still within the window.
+		var from, pct float64
+		for _, s := range w.samples {
+			if p := (u.Price - s.price) / s.price * 100; math.Abs(p) > math.Abs(pct) {
+				from, pct = s.price, p
+			}
+		}
+		if math.Abs(pct) >= r.Percent {
+			// Start a fresh window so one move is reported once.
+			w.samples = []sample{{u.Timestamp, u.Price}}
+			return fmt.Sprintf("%s moved %+.2f%% from %g to %g within %v", r.Instrument, pct, from, u.Price, time.Duration(r.Window)), from, true
+		}
+		w.samples = append(w.samples, sample{u.Timestamp, u.Price})
+	case Spread:
+		// Like crossings, the spread must widen past the level while watched; updates from a single
+		// provider have no spread and count as below it.
+		s, ok := spread.Of(u)
+		wide := ok && s.SpreadBps >= r.Level
+		fired := wide && !w.holds && w.armed
+		w.holds, w.armed = wide, true
+		if fired {
+			return fmt.Sprintf("%s providers %.1f bps apart: %s at %g, %s at %g", r.Instrument, s.SpreadBps,
+				s.High, s.Providers[0].Price, s.Low, s.Providers[len(s.Providers)-1].Price), 0, true
+		}
+	}
+	return "", 0, false
+}
+
+// becomes reports whether an expression rule fired: its condition holds now but didn't at the
+// previous update. The first evaluation only arms the rule, as with crossings, so a restart doesn't
+// repeat notifications for conditions that already held.
+func (w *watch) becomes(r Rule, u models.PriceUpdate, holds bool) (string, bool) {
+	fired := holds && !w.holds && w.armed
+	w.holds, w.armed = holds, true
+	if !fired {
+		return "", false
+	}
+	return fmt.Sprintf("%s: %s at %g", r.Instrument, r.Expr, u.Price), true
+}
+
+// unknownEnv is the Env used when none is configured; nothing beyond the update is known.
+type unknownEnv struct{}
+
+func (unknownEnv) Indicator(string, indicators.Spec) (indicators.Value, bool) {
+	return indicators.Value{}, false
+}
+
+func (unknownEnv) PriceAt(string, time.Time) (float64, bool) { return 0, false }
+
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, c := range times {
+		if c.After(t) {
+			t = c
+		}
+	}
+	return t
+}
+
+// randomID returns n random bytes, hex encoded.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// persist saves state, logging failures; notifications stay queued in memory either way.
+func (m *Manager) persist() {
+	if err := m.save(); err != nil {
+		log.Printf("[!] saving alerts: %v", err)
+	}
+}
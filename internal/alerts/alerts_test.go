@@ -0,0 +1,238 @@
+package alerts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+var start = time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)
+
+func tick(at time.Duration, price float64) models.PriceUpdate {
+	return models.PriceUpdate{Timestamp: start.Add(at), Price: price, Instrument: "BTC-USD"}
+}
+
+// newManager returns a manager whose webhooks may reach test receivers on loopback.
+func newManager(t *testing.T, cfg Config) *Manager {
+	t.Helper()
+	cfg.AllowPrivate = true
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func mustCreate(t *testing.T, m *Manager, owner string, r Rule) Rule {
+	t.Helper()
+	if r.Webhook == "" {
+		r.Webhook = "http://127.0.0.1:1/hook"
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := m.Create(owner, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// queued returns the events waiting in the queue.
+func queued(m *Manager) []Event {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var events []Event
+	for _, d := range m.state.Queue {
+		var ev Event
+		json.Unmarshal(d.Payload, &ev)
+		events = append(events, ev)
+	}
+	return events
+}
+
+// TestValidate tests that incomplete rules are rejected.
+func TestValidate(t *testing.T) {
+	for _, r := range []Rule{
+		{Kind: CrossAbove, Level: 1, Webhook: "http://x"},
+		{Instrument: "btc-usd", Kind: CrossAbove, Webhook: "http://x"},
+		{Instrument: "btc-usd", Kind: Move, Percent: 5, Webhook: "http://x"},
+		{Instrument: "btc-usd", Kind: Stale, Webhook: "http://x"},
+		{Instrument: "btc-usd", Kind: "sideways", Webhook: "http://x"},
+		{Instrument: "btc-usd", Kind: CrossBelow, Level: 1, Webhook: "ftp://x"},
+	} {
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+
+	var r Rule
+	if err := json.Unmarshal([]byte(`{"instrument":"btc-usd","kind":"move","percent":3,"window":"15m","webhook":"https://example.com/hook"}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Validate(); err != nil || r.Instrument != "BTC-USD" || time.Duration(r.Window) != 15*time.Minute {
+		t.Errorf("unexpected rule %+v, %v", r, err)
+	}
+}
+
+// TestCrossing tests that level rules fire once per crossing, in their direction only.
+func TestCrossing(t *testing.T) {
+	m := newManager(t, Config{})
+	above := mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: CrossAbove, Level: 100})
+	mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: CrossBelow, Level: 90})
+	mustCreate(t, m, "", Rule{Instrument: "ETH-USD", Kind: CrossAbove, Level: 1})
+
+	for i, p := range []float64{95, 101, 102, 99, 100, 89} {
+		m.Evaluate(tick(time.Duration(i)*time.Second, p))
+	}
+
+	events := queued(m)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 alerts, got %+v", events)
+	}
+	if e := events[0]; e.RuleID != above.ID || e.Kind != CrossAbove || e.Price != 101 || e.From != 95 {
+		t.Errorf("unexpected first alert %+v", e)
+	}
+	if e := events[1]; e.Kind != CrossAbove || e.Price != 100 {
+		t.Errorf("expected a second crossing at 100, got %+v", e)
+	}
+	if e := events[2]; e.Kind != CrossBelow || e.Price != 89 || !strings.Contains(e.Message, "below 90") {
+		t.Errorf("unexpected crossing below %+v", e)
+	}
+}
+
+// TestMove tests percent moves within the window, reported once each.
+func TestMove(t *testing.T) {
+	m := newManager(t, Config{})
+	mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: Move, Percent: 5, Window: Duration(time.Minute)})
+
+	m.Evaluate(tick(0, 100))
+	m.Evaluate(tick(2*time.Minute, 104)) // 4% in two minutes, and 100 has left the window
+	m.Evaluate(tick(150*time.Second, 106))
+	if n := len(queued(m)); n != 0 {
+		t.Fatalf("expected no alert for moves outside the window, got %d", n)
+	}
+	m.Evaluate(tick(170*time.Second, 98.5)) // Down 7.1% from 106 within the minute
+	m.Evaluate(tick(175*time.Second, 98))
+
+	events := queued(m)
+	if len(events) != 1 || events[0].From != 106 || events[0].Price != 98.5 {
+		t.Errorf("expected one alert from 106 to 98.5, got %+v", events)
+	}
+}
+
+// TestStale tests that silence fires once and a new price re-arms the rule.
+func TestStale(t *testing.T) {
+	m := newManager(t, Config{})
+	mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: Stale, Window: Duration(time.Minute)})
+	now := time.Now()
+
+	m.checkStale(now.Add(30 * time.Second))
+	if n := len(queued(m)); n != 0 {
+		t.Fatalf("expected no alert within the window, got %d", n)
+	}
+	m.checkStale(now.Add(2 * time.Minute))
+	m.checkStale(now.Add(3 * time.Minute))
+	if n := len(queued(m)); n != 1 {
+		t.Fatalf("expected one stale alert, got %d", n)
+	}
+
+	m.Evaluate(models.PriceUpdate{Timestamp: now.Add(3 * time.Minute), Price: 100, Instrument: "BTC-USD"})
+	m.checkStale(now.Add(3*time.Minute + 30*time.Second))
+	m.checkStale(now.Add(5 * time.Minute))
+	events := queued(m)
+	if len(events) != 2 || events[1].LastUpdate.IsZero() {
+		t.Errorf("expected a second alert after the feed went quiet again, got %+v", events)
+	}
+}
+
+// TestOwnership tests that rules are only visible to and editable by their owner.
+func TestOwnership(t *testing.T) {
+	m := newManager(t, Config{})
+	r := mustCreate(t, m, "alice", Rule{Instrument: "BTC-USD", Kind: CrossAbove, Level: 100})
+	if r.Secret == "" || r.ID == "" {
+		t.Fatalf("expected an ID and generated secret, got %+v", r)
+	}
+
+	if len(m.Rules("bob")) != 0 {
+		t.Error("expected bob to see no rules")
+	}
+	if _, err := m.Rule("bob", r.ID); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for bob, got %v", err)
+	}
+	if err := m.Delete("bob", r.ID); err != ErrNotFound {
+		t.Errorf("expected bob's delete to fail, got %v", err)
+	}
+
+	updated, err := m.Update("alice", r.ID, Rule{Instrument: "BTC-USD", Kind: CrossBelow, Level: 90, Webhook: r.Webhook})
+	if err != nil || updated.Kind != CrossBelow || updated.Secret != r.Secret || !updated.CreatedAt.Equal(r.CreatedAt) {
+		t.Errorf("unexpected update %+v, %v", updated, err)
+	}
+	if err := m.Delete("alice", r.ID); err != nil || len(m.Rules("alice")) != 0 {
+		t.Errorf("expected alice's rule deleted, got %v", err)
+	}
+}
+
+// TestExpression tests that expression rules fire when their condition becomes true and keep
+// the indicators they use maintained while they exist.
+func TestExpression(t *testing.T) {
+	var watching []string
+	m := newManager(t, Config{
+		Watch: func(specs []indicators.Spec) func() {
+			watching = append(watching, specs[0].String())
+			return func() { watching = watching[1:] }
+		},
+	})
+	r := mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: Expression, Expr: "price > 100 or price > sma(3)"})
+	if len(watching) != 1 || watching[0] != "sma:3" {
+		t.Fatalf("expected sma:3 to be watched, got %v", watching)
+	}
+
+	// The first evaluation only arms the rule, even though the condition already holds.
+	for i, p := range []float64{101, 99, 101, 102, 98, 103} {
+		m.Evaluate(tick(time.Duration(i)*time.Second, p))
+	}
+	events := queued(m)
+	if len(events) != 2 || events[0].Price != 101 || events[1].Price != 103 || !strings.Contains(events[0].Message, r.Expr) {
+		t.Errorf("expected alerts at 101 and 103, got %+v", events)
+	}
+
+	if err := m.Delete("", r.ID); err != nil || len(watching) != 0 {
+		t.Errorf("expected the indicator released with the rule, still watching %v", watching)
+	}
+
+	bad := Rule{Instrument: "BTC-USD", Kind: Expression, Expr: "price >", Webhook: "http://x"}
+	if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "column 8") {
+		t.Errorf("expected a positioned error, got %v", err)
+	}
+}
+
+// TestSpread tests that spread rules fire when providers move apart past the level, once per widening.
+func TestSpread(t *testing.T) {
+	m := newManager(t, Config{})
+	mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: Spread, Level: 50})
+
+	merged := func(at time.Duration, a, b float64) models.PriceUpdate {
+		u := tick(at, (a+b)/2)
+		u.Providers = map[string]float64{"a": a, "b": b}
+		return u
+	}
+	m.Evaluate(merged(0, 100, 101)) // Arms the rule, even though already wide
+	m.Evaluate(merged(1, 100, 100.1))
+	m.Evaluate(merged(2, 100, 100.6)) // Widens: fires
+	m.Evaluate(merged(3, 100, 100.7)) // Still wide
+	m.Evaluate(tick(4, 100))          // A single provider has no spread
+	m.Evaluate(merged(5, 99, 100))    // Wide again: fires
+
+	events := queued(m)
+	if len(events) != 2 || events[0].Spread == nil || events[0].Spread.High != "b" || events[1].Price != 99.5 {
+		t.Fatalf("expected two spread alerts with their providers, got %+v", events)
+	}
+	if !strings.Contains(events[0].Message, "59.8 bps apart: b at 100.6, a at 100") {
+		t.Errorf("unexpected message %q", events[0].Message)
+	}
+}
@@ -0,0 +1,97 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/expr"
+)
+
+// Kind is what a rule watches for.
+type Kind string
+
+const (
+	CrossAbove Kind = "cross_above" // Price moves from below Level to at or above it
+	CrossBelow Kind = "cross_below" // Price moves from above Level to at or below it
+	Move       Kind = "move"        // Price moves Percent or more, either way, within Window
+	Stale      Kind = "stale"       // No price for the instrument for Window
+	Spread     Kind = "spread"      // Providers' prices move Level basis points or more apart
+	Expression Kind = "expression"  // Expr becomes true, e.g. "price > ema(50) and change(1h) < -3%"
+)
+
+// Rule is an alert definition. Rules are owned by the token subject that created them.
+type Rule struct {
+	ID         string    `json:"id"`
+	Owner      string    `json:"owner,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Instrument string    `json:"instrument"`
+	Kind       Kind      `json:"kind"`
+	Level      float64   `json:"level,omitempty"`   // cross_above, cross_below; basis points for spread
+	Percent    float64   `json:"percent,omitempty"` // move
+	Window     Duration  `json:"window,omitzero"`   // move, stale
+	Expr       string    `json:"expr,omitempty"`    // expression
+	Webhook    string    `json:"webhook"`
+	Secret     string    `json:"secret,omitempty"` // HMAC key for webhook signatures, generated when empty
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate checks that the rule is complete for its kind. It normalises the instrument to upper case.
+func (r *Rule) Validate() error {
+	r.Instrument = strings.ToUpper(strings.TrimSpace(r.Instrument))
+	if r.Instrument == "" {
+		return errors.New("instrument is required")
+	}
+	switch r.Kind {
+	case CrossAbove, CrossBelow, Spread:
+		if r.Level <= 0 || math.IsInf(r.Level, 0) {
+			return fmt.Errorf("%s needs a positive level", r.Kind)
+		}
+	case Move:
+		if r.Percent <= 0 || math.IsInf(r.Percent, 0) {
+			return errors.New("move needs a positive percent")
+		}
+		if r.Window <= 0 {
+			return errors.New("move needs a positive window, e.g. 15m")
+		}
+	case Stale:
+		if r.Window <= 0 {
+			return errors.New("stale needs a positive window, e.g. 1m")
+		}
+	case Expression:
+		if _, err := expr.Compile(r.Expr); err != nil {
+			return fmt.Errorf("invalid expr: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q (expected cross_above, cross_below, move, stale, spread or expression)", r.Kind)
+	}
+	u, err := url.Parse(r.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook %q: expected an http or https URL", r.Webhook)
+	}
+	return nil
+}
+
+// Duration is a time.Duration written in JSON as a string such as "15m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("durations are strings such as \"15m\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
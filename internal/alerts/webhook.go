@@ -0,0 +1,345 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/spread"
+)
+
+const staleCheckInterval = time.Second
+
+// Event is the JSON body posted to a rule's webhook.
+type Event struct {
+	ID          string           `json:"id"` // Delivery ID, the same across retries so receivers can deduplicate
+	RuleID      string           `json:"rule_id"`
+	RuleName    string           `json:"rule_name,omitempty"`
+	Kind        Kind             `json:"kind"`
+	Instrument  string           `json:"instrument"`
+	Price       float64          `json:"price,omitempty"`
+	From        float64          `json:"from,omitempty"` // Previous or reference price
+	Seq         uint64           `json:"seq,omitempty"`
+	LastUpdate  time.Time        `json:"last_update,omitzero"` // Stale alerts: when the last price arrived
+	Spread      *spread.Snapshot `json:"spread,omitempty"`     // Spread alerts: each provider's price
+	Message     string           `json:"message"`
+	TriggeredAt time.Time        `json:"triggered_at"`
+}
+
+// Delivery is a notification on its way to a webhook, or dead-lettered after running out of attempts.
+type Delivery struct {
+	ID          string          `json:"id"`
+	RuleID      string          `json:"rule_id"`
+	Owner       string          `json:"owner,omitempty"`
+	URL         string          `json:"url"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt,omitzero"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Signature returns the X-Injective-Signature header for a webhook body: "sha256=" followed by the
+// hex HMAC-SHA256, keyed with the rule's secret, of the X-Injective-Timestamp value, a dot and the body.
+// Receivers should recompute it and reject stale timestamps to prevent replays.
+func Signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueue queues a notification of r firing. Callers must hold the mutex and persist afterwards.
+func (m *Manager) enqueue(r Rule, ev Event) {
+	ev.ID, ev.RuleID, ev.RuleName, ev.Kind = randomID(8), r.ID, r.Name, r.Kind
+	payload, _ := json.Marshal(ev)
+	d := Delivery{ID: ev.ID, RuleID: r.ID, Owner: r.Owner, URL: r.Webhook, Payload: payload, CreatedAt: time.Now().UTC()}
+
+	if len(m.state.Queue) >= m.cfg.MaxQueue {
+		d.LastError = "delivery queue full"
+		m.deadLetter(d)
+		return
+	}
+	m.state.Queue = append(m.state.Queue, d)
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DeadLetters returns the owner's deliveries that ran out of attempts, oldest first.
+func (m *Manager) DeadLetters(owner string) []Delivery {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var dead []Delivery
+	for _, d := range m.state.DeadLetters {
+		if d.Owner == owner {
+			dead = append(dead, d)
+		}
+	}
+	return dead
+}
+
+// Retry moves one of the owner's dead letters back to the queue with a fresh set of attempts.
+func (m *Manager) Retry(owner, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	i := slices.IndexFunc(m.state.DeadLetters, func(d Delivery) bool { return d.ID == id && d.Owner == owner })
+	if i < 0 {
+		return ErrNotFound
+	}
+	d := m.state.DeadLetters[i]
+	m.state.DeadLetters = slices.Delete(m.state.DeadLetters, i, i+1)
+	d.Attempts, d.NextAttempt, d.LastError = 0, time.Time{}, ""
+	m.state.Queue = append(m.state.Queue, d)
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return m.save()
+}
+
+// Run delivers queued notifications and checks stale rules until ctx is cancelled.
+// Deliveries interrupted by cancellation stay queued and resume on the next start.
+func (m *Manager) Run(ctx context.Context) {
+	stale := time.NewTicker(staleCheckInterval)
+	defer stale.Stop()
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-stale.C:
+			m.checkStale(now)
+			continue
+		case <-m.wake:
+		case <-retry.C:
+		}
+
+		if next := m.dispatch(ctx); !next.IsZero() {
+			retry.Reset(time.Until(next))
+		}
+	}
+}
+
+// dispatch starts an attempt for every due delivery and returns when the next one falls due, if any.
+func (m *Manager) dispatch(ctx context.Context) time.Time {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	var next time.Time
+	for _, d := range m.state.Queue {
+		switch {
+		case m.inflight[d.ID]:
+		case !d.NextAttempt.After(now):
+			r, ok := m.rule(d.RuleID)
+			if !ok {
+				continue // Dropped by the next save, below
+			}
+			m.inflight[d.ID] = true
+			go m.attempt(ctx, d, r.Secret)
+		case next.IsZero() || d.NextAttempt.Before(next):
+			next = d.NextAttempt
+		}
+	}
+
+	// Deliveries of deleted rules have nobody to sign for them.
+	n := len(m.state.Queue)
+	m.state.Queue = slices.DeleteFunc(m.state.Queue, func(d Delivery) bool {
+		_, ok := m.rule(d.RuleID)
+		return !ok && !m.inflight[d.ID]
+	})
+	if len(m.state.Queue) != n {
+		m.persist()
+	}
+	return next
+}
+
+func (m *Manager) rule(id string) (Rule, bool) {
+	i := slices.IndexFunc(m.state.Rules, func(r Rule) bool { return r.ID == id })
+	if i < 0 {
+		return Rule{}, false
+	}
+	return m.state.Rules[i], true
+}
+
+// attempt posts a delivery once and records the outcome.
+func (m *Manager) attempt(ctx context.Context, d Delivery, secret string) {
+	err := m.post(ctx, d, secret)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.inflight, d.ID)
+	if ctx.Err() != nil {
+		return
+	}
+
+	i := slices.IndexFunc(m.state.Queue, func(q Delivery) bool { return q.ID == d.ID })
+	if i < 0 {
+		return
+	}
+	if err == nil {
+		m.state.Queue = slices.Delete(m.state.Queue, i, i+1)
+		m.persist()
+		return
+	}
+
+	q := &m.state.Queue[i]
+	q.Attempts++
+	q.LastError = err.Error()
+	if q.Attempts >= m.cfg.MaxAttempts {
+		log.Printf("[!] alert %s to %s dead-lettered after %d attempts: %v", q.ID, q.URL, q.Attempts, err)
+		m.deadLetter(*q)
+		m.state.Queue = slices.Delete(m.state.Queue, i, i+1)
+	} else {
+		q.NextAttempt = time.Now().Add(m.backoff(q.Attempts))
+	}
+	m.persist()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// post sends the signed payload. Any 2xx response counts as delivered.
+func (m *Manager) post(ctx context.Context, d Delivery, secret string) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "injective-alerts")
+	req.Header.Set("X-Injective-Delivery", d.ID)
+	req.Header.Set("X-Injective-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Injective-Signature", Signature(secret, ts, d.Payload))
+
+	resp, err := m.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookClient returns the default client for webhooks. Unless allowPrivate, it refuses to connect to
+// loopback, private, link-local and unspecified addresses, checked after DNS resolution and on every
+// redirect, so a webhook can't reach internal services whatever its URL says.
+func webhookClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{}
+	}
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if ip = ip.Unmap(); ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+				return fmt.Errorf("webhook address %s is not public", ip)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // The check applies to the webhook's own address, not a proxy's
+	return &http.Client{Transport: transport}
+}
+
+// backoff returns the delay before the next attempt: MinBackoff doubling per failed attempt, capped at
+// MaxBackoff, with jitter so a recovering receiver isn't hit by every retry at once.
+func (m *Manager) backoff(attempts int) time.Duration {
+	d := m.cfg.MaxBackoff
+	if attempts < 32 {
+		d = min(m.cfg.MinBackoff<<(attempts-1), m.cfg.MaxBackoff)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+const maxDeadLetters = 1000
+
+// deadLetter keeps d for inspection, dropping the oldest beyond maxDeadLetters. Callers must hold the mutex.
+func (m *Manager) deadLetter(d Delivery) {
+	d.NextAttempt = time.Time{}
+	m.state.DeadLetters = append(m.state.DeadLetters, d)
+	if n := len(m.state.DeadLetters); n > maxDeadLetters {
+		m.state.DeadLetters = slices.Delete(m.state.DeadLetters, 0, n-maxDeadLetters)
+	}
+}
+
+func (m *Manager) load() error {
+	if m.cfg.Path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(m.cfg.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, &m.state)
+}
+
+// save writes the state file atomically, so a crash leaves either the old or the new state.
+// Callers must hold the mutex.
+func (m *Manager) save() error {
+	if m.cfg.Path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.cfg.Path), filepath.Base(m.cfg.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), m.cfg.Path)
+}
@@ -0,0 +1,216 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint that verifies signatures and answers with the given statuses in turn,
+// then 200.
+type receiver struct {
+	*httptest.Server
+	t        *testing.T
+	secret   string
+	mutex    sync.Mutex
+	statuses []int
+	events   []Event
+	attempts int
+	received chan Event
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rc := &receiver{t: t, statuses: statuses, received: make(chan Event, 16)}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Injective-Timestamp"), 10, 64)
+
+		rc.mutex.Lock()
+		defer rc.mutex.Unlock()
+		rc.attempts++
+		if got := r.Header.Get("X-Injective-Signature"); got != Signature(rc.secret, ts, body) {
+			t.Errorf("bad signature %q", got)
+		}
+		if len(rc.statuses) > 0 {
+			status := rc.statuses[0]
+			rc.statuses = rc.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		var ev Event
+		json.Unmarshal(body, &ev)
+		if ev.ID != r.Header.Get("X-Injective-Delivery") {
+			t.Errorf("expected delivery header %s to match the event", ev.ID)
+		}
+		rc.events = append(rc.events, ev)
+		rc.received <- ev
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) wait(t *testing.T) Event {
+	t.Helper()
+	select {
+	case ev := <-rc.received:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a webhook")
+		return Event{}
+	}
+}
+
+func run(t *testing.T, m *Manager) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// TestWebhookDelivery tests that a firing rule is posted, signed, and retried until the receiver accepts it.
+func TestWebhookDelivery(t *testing.T) {
+	rc := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	m := newManager(t, Config{MinBackoff: 10 * time.Millisecond})
+	r := mustCreate(t, m, "", Rule{Name: "btc 100", Instrument: "BTC-USD", Kind: CrossAbove, Level: 100, Webhook: rc.URL})
+	rc.secret = r.Secret
+	run(t, m)
+
+	m.Evaluate(tick(0, 99))
+	m.Evaluate(tick(time.Second, 100))
+
+	ev := rc.wait(t)
+	if ev.RuleID != r.ID || ev.RuleName != "btc 100" || ev.Price != 100 {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if rc.attempts != 3 {
+		t.Errorf("expected delivery on the third attempt, got %d attempts", rc.attempts)
+	}
+	waitFor(t, func() bool { return len(queued(m)) == 0 })
+}
+
+// TestDeadLetters tests that deliveries run out of attempts into the dead-letter list and can be retried.
+func TestDeadLetters(t *testing.T) {
+	rc := newReceiver(t, 500, 500, 500)
+	m := newManager(t, Config{MaxAttempts: 3, MinBackoff: 5 * time.Millisecond})
+	r := mustCreate(t, m, "alice", Rule{Instrument: "BTC-USD", Kind: CrossBelow, Level: 100, Webhook: rc.URL})
+	rc.secret = r.Secret
+	run(t, m)
+
+	m.Evaluate(tick(0, 101))
+	m.Evaluate(tick(time.Second, 99))
+
+	waitFor(t, func() bool { return len(m.DeadLetters("alice")) == 1 })
+	dead := m.DeadLetters("alice")[0]
+	if dead.Attempts != 3 || dead.LastError == "" || len(queued(m)) != 0 {
+		t.Errorf("unexpected dead letter %+v", dead)
+	}
+	if err := m.Retry("bob", dead.ID); err != ErrNotFound {
+		t.Errorf("expected bob's retry to fail, got %v", err)
+	}
+
+	if err := m.Retry("alice", dead.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ev := rc.wait(t); ev.ID != dead.ID {
+		t.Errorf("expected the retried delivery %s, got %s", dead.ID, ev.ID)
+	}
+	if len(m.DeadLetters("alice")) != 0 {
+		t.Error("expected the dead letter to be gone after a successful retry")
+	}
+}
+
+// TestPersistence tests that rules and undelivered notifications survive a restart.
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	rc := newReceiver(t)
+
+	m := newManager(t, Config{Path: path})
+	r := mustCreate(t, m, "", Rule{Instrument: "BTC-USD", Kind: CrossAbove, Level: 100, Webhook: rc.URL})
+	rc.secret = r.Secret
+	m.Evaluate(tick(0, 99))
+	m.Evaluate(tick(time.Second, 101)) // Queued, but never sent: this manager doesn't run
+
+	restarted := newManager(t, Config{Path: path})
+	if rules := restarted.Rules(""); len(rules) != 1 || rules[0].Secret != r.Secret {
+		t.Fatalf("expected the rule to be reloaded, got %+v", rules)
+	}
+	run(t, restarted)
+	if ev := rc.wait(t); ev.RuleID != r.ID || ev.Price != 101 {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+// TestReload tests that a manager picks up rules and deliveries saved by another sharing its file.
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	m := newManager(t, Config{Path: path})
+	other := newManager(t, Config{Path: path})
+	r := mustCreate(t, other, "", Rule{Instrument: "BTC-USD", Kind: Expression, Expr: "price > 100"})
+
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	m.Evaluate(tick(0, 99))
+	m.Evaluate(tick(time.Second, 101))
+	if events := queued(m); len(events) != 1 || events[0].RuleID != r.ID {
+		t.Errorf("expected the other manager's rule to fire, got %+v", events)
+	}
+}
+
+// TestWebhookRestrictions tests the webhook host allowlist, and that the default client refuses private addresses.
+func TestWebhookRestrictions(t *testing.T) {
+	m := newManager(t, Config{WebhookHosts: []string{"hooks.example.com", "*.example.org"}})
+	for webhook, allowed := range map[string]bool{
+		"https://hooks.example.com/btc":  true,
+		"https://a.b.example.org/btc":    true,
+		"https://example.org/btc":        false,
+		"https://hooks.example.com.evil": false,
+		"http://169.254.169.254/latest":  false,
+	} {
+		_, err := m.Create("", Rule{Instrument: "BTC-USD", Kind: Stale, Window: Duration(time.Minute), Webhook: webhook})
+		if allowed != (err == nil) || (!allowed && !errors.Is(err, ErrWebhookNotAllowed)) {
+			t.Errorf("%s: expected allowed=%v, got %v", webhook, allowed, err)
+		}
+	}
+
+	rc := newReceiver(t)
+	private, err := New(Config{MaxAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := mustCreate(t, private, "", Rule{Instrument: "BTC-USD", Kind: CrossAbove, Level: 100, Webhook: rc.URL})
+	rc.secret = r.Secret
+	run(t, private)
+	private.Evaluate(tick(0, 99))
+	private.Evaluate(tick(time.Second, 101))
+	waitFor(t, func() bool { return len(private.DeadLetters("")) == 1 })
+	if dead := private.DeadLetters("")[0]; !strings.Contains(dead.LastError, "not public") || rc.attempts != 0 {
+		t.Errorf("expected the loopback receiver to be refused, got %+v after %d attempts", dead, rc.attempts)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
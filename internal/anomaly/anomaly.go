@@ -0,0 +1,204 @@
+// Package anomaly screens incoming prices for bad ticks, such as a sudden 50% drop followed by a recovery,
+// before they are published.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Mode is what happens to a suspicious price.
+type Mode string
+
+const (
+	Quarantine Mode = "quarantine" // Withheld from clients
+	Flag       Mode = "flag"       // Published with an anomaly field
+	Pass       Mode = "pass"       // Published unchanged; only reported for review
+)
+
+// ParseMode parses a mode name.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case Quarantine, Flag, Pass:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown mode %q (expected quarantine, flag or pass)", s)
+}
+
+// Config tunes the detector.
+type Config struct {
+	Mode       Mode
+	Window     int     // Log returns kept per instrument for the z-score (default 60)
+	ZScore     float64 // Returns further than this many standard deviations from the window's mean are suspicious (default 6)
+	MaxJump    float64 // Moves larger than this percentage from the last normal price are suspicious (default 10)
+	MinJump    float64 // Moves smaller than this percentage never are, however quiet the window (default 0.5)
+	MinSamples int     // Returns needed before z-scores are trusted (default 20)
+	Confirm    int     // Suspicious prices in a row accepted as a genuine new level (default 3)
+}
+
+// Detector screens each instrument's prices against its recent accepted ones. Suspicious prices don't
+// become the reference for the next, so a bad tick and the recovery after it are judged against the
+// price before: only the bad tick stands out. It is not safe for concurrent use.
+type Detector struct {
+	cfg    Config
+	series map[string]*series
+}
+
+// series is an instrument's recent accepted prices.
+type series struct {
+	last    float64   // Last accepted price, zero before the first
+	returns []float64 // Ring of accepted log returns
+	next    int       // Ring position of the next return
+	strikes int       // Suspicious prices in a row, each within MaxJump of the one before
+	pending float64   // Last suspicious price
+}
+
+func NewDetector(cfg Config) *Detector {
+	if cfg.Mode == "" {
+		cfg.Mode = Flag
+	}
+	if cfg.Window <= 1 {
+		cfg.Window = 60
+	}
+	if cfg.ZScore <= 0 {
+		cfg.ZScore = 6
+	}
+	if cfg.MaxJump <= 0 {
+		cfg.MaxJump = 10
+	}
+	if cfg.MinJump <= 0 {
+		cfg.MinJump = 0.5
+	}
+	if cfg.MinSamples <= 1 {
+		cfg.MinSamples = 20
+	}
+	cfg.MinSamples = min(cfg.MinSamples, cfg.Window)
+	if cfg.Confirm <= 0 {
+		cfg.Confirm = 3
+	}
+	return &Detector{cfg: cfg, series: make(map[string]*series)}
+}
+
+// Report records a suspicious price for review.
+type Report struct {
+	ID         uint64    `json:"id"` // Assigned by the Log
+	Instrument string    `json:"instrument"`
+	Price      float64   `json:"price"`
+	Timestamp  time.Time `json:"timestamp"`
+	Sources    []string  `json:"sources,omitempty"`
+	Action     Action    `json:"action"`
+	models.Anomaly
+}
+
+// Action is what was done with a suspicious price.
+type Action string
+
+const (
+	Quarantined Action = "quarantined"
+	Flagged     Action = "flagged"
+	Passed      Action = "passed"
+	Confirmed   Action = "confirmed" // Suspicious, but persisted for Confirm prices and was accepted as the new level
+)
+
+// Screen checks an update. It reports whether the update should be published, and describes it
+// when it was suspicious. In Flag mode suspicious updates are marked with their anomaly. Invalid
+// prices (zero, negative, NaN or infinite) are withheld in every mode: no client can use them.
+func (d *Detector) Screen(u *models.PriceUpdate) (bool, *Report) {
+	s := d.series[u.Instrument]
+	if s == nil {
+		s = &series{returns: make([]float64, 0, d.cfg.Window)}
+		d.series[u.Instrument] = s
+	}
+
+	a, suspicious := d.check(s, u.Price)
+	if !suspicious {
+		s.accept(u.Price, d.cfg.Window)
+		return true, nil
+	}
+
+	r := &Report{Instrument: u.Instrument, Price: u.Price, Timestamp: u.Timestamp, Sources: u.Sources, Anomaly: a}
+	if s.strikes > 0 && math.Abs(u.Price-s.pending) <= s.pending*d.cfg.MaxJump/100 {
+		s.strikes++
+	} else {
+		s.strikes = 1
+	}
+	s.pending = u.Price
+	// A price level persisting away from the old one is a genuine move, such as a crash, rather than a bad
+	// tick. The window restarts from it, as the returns before describe a market that no longer applies.
+	if a.Reasons[0] != "invalid" && s.strikes >= d.cfg.Confirm {
+		s.returns, s.next, s.last = s.returns[:0], 0, 0
+		s.accept(u.Price, d.cfg.Window)
+		r.Action = Confirmed
+		return true, r
+	}
+
+	switch {
+	case d.cfg.Mode == Quarantine, a.Reasons[0] == "invalid":
+		r.Action = Quarantined
+		return false, r
+	case d.cfg.Mode == Flag:
+		r.Action = Flagged
+		u.Anomaly = &r.Anomaly
+	default:
+		r.Action = Passed
+	}
+	return true, r
+}
+
+// check compares a price with the series, reporting whether it is suspicious and why.
+func (d *Detector) check(s *series, price float64) (models.Anomaly, bool) {
+	a := models.Anomaly{Reference: s.last}
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		a.Reasons = []string{"invalid"}
+		return a, true
+	}
+	if s.last == 0 {
+		return a, false
+	}
+
+	a.JumpPct = (price - s.last) / s.last * 100
+	if math.Abs(a.JumpPct) > d.cfg.MaxJump {
+		a.Reasons = append(a.Reasons, "jump")
+	}
+	if len(s.returns) >= d.cfg.MinSamples && math.Abs(a.JumpPct) >= d.cfg.MinJump {
+		mean, sd := meanStdDev(s.returns)
+		if sd > 0 {
+			a.ZScore = (math.Log(price/s.last) - mean) / sd
+			if math.Abs(a.ZScore) > d.cfg.ZScore {
+				a.Reasons = append(a.Reasons, "zscore")
+			}
+		}
+	}
+	return a, len(a.Reasons) > 0
+}
+
+// accept makes price the series' reference, adding its return to the window.
+func (s *series) accept(price float64, window int) {
+	if s.last > 0 {
+		r := math.Log(price / s.last)
+		if len(s.returns) < window {
+			s.returns = append(s.returns, r)
+		} else {
+			s.returns[s.next] = r
+		}
+		s.next = (s.next + 1) % window
+	}
+	s.last, s.strikes = price, 0
+}
+
+// meanStdDev returns the mean and sample standard deviation of values.
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
@@ -0,0 +1,144 @@
+package anomaly
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// screen passes prices through d, returning those published and the reports.
+func screen(d *Detector, prices ...float64) ([]float64, []Report) {
+	var published []float64
+	var reports []Report
+	start := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	for i, p := range prices {
+		u := models.PriceUpdate{Timestamp: start.Add(time.Duration(i) * time.Second), Price: p, Instrument: "BTC-USD"}
+		publish, r := d.Screen(&u)
+		if publish {
+			published = append(published, u.Price)
+		}
+		if r != nil {
+			reports = append(reports, *r)
+		}
+	}
+	return published, reports
+}
+
+// TestQuarantine tests that a bad tick is withheld while the recovery after it passes.
+func TestQuarantine(t *testing.T) {
+	published, reports := screen(NewDetector(Config{Mode: Quarantine}), 100, 101, 50, 100.5, 0, 101)
+	if !slices.Equal(published, []float64{100, 101, 100.5, 101}) {
+		t.Errorf("expected the 50%% drop and the zero withheld, got %v", published)
+	}
+	if len(reports) != 2 || reports[0].Action != Quarantined || reports[0].Reference != 101 || !slices.Equal(reports[0].Reasons, []string{"jump"}) {
+		t.Fatalf("expected the drop reported against 101, got %+v", reports)
+	}
+	if math.Abs(reports[0].JumpPct+50.495) > 0.001 || reports[1].Reasons[0] != "invalid" {
+		t.Errorf("unexpected reports %+v", reports)
+	}
+}
+
+// TestFlag tests that flagged ticks are published with their anomaly, and pass mode only reports them.
+func TestFlag(t *testing.T) {
+	d := NewDetector(Config{Mode: Flag})
+	u := models.PriceUpdate{Price: 100, Instrument: "BTC-USD"}
+	d.Screen(&u)
+	u.Price = 150
+	if publish, r := d.Screen(&u); !publish || r.Action != Flagged || u.Anomaly == nil || u.Anomaly.JumpPct != 50 {
+		t.Errorf("expected the jump published with its anomaly, got %+v", u)
+	}
+
+	published, reports := screen(NewDetector(Config{Mode: Pass}), 100, 150)
+	if len(published) != 2 || len(reports) != 1 || reports[0].Action != Passed {
+		t.Errorf("expected the jump passed through and reported, got %v %+v", published, reports)
+	}
+}
+
+// TestInvalid tests that zero and NaN prices are withheld and reported whatever the mode.
+func TestInvalid(t *testing.T) {
+	for _, mode := range []Mode{Quarantine, Flag, Pass} {
+		published, reports := screen(NewDetector(Config{Mode: mode}), 100, 0, math.NaN(), 100.5)
+		if !slices.Equal(published, []float64{100, 100.5}) {
+			t.Errorf("%s: expected the zero and NaN withheld, got %v", mode, published)
+		}
+		if len(reports) != 2 || reports[0].Action != Quarantined || reports[1].Action != Quarantined || reports[1].Reasons[0] != "invalid" {
+			t.Errorf("%s: expected both reported as quarantined, got %+v", mode, reports)
+		}
+	}
+}
+
+// TestZScore tests that a move far outside the window's usual returns stands out, though within MaxJump.
+func TestZScore(t *testing.T) {
+	d := NewDetector(Config{Mode: Quarantine, MinSamples: 10})
+	prices := []float64{100}
+	for i := range 30 {
+		prices = append(prices, prices[len(prices)-1]*(1+0.001*float64(i%3-1))) // Moves of 0.1%
+	}
+	last := prices[len(prices)-1]
+	published, reports := screen(d, append(prices, last*1.05, last*1.002)...)
+	if len(published) != len(prices)+1 || len(reports) != 1 || !slices.Equal(reports[0].Reasons, []string{"zscore"}) || reports[0].ZScore < 6 {
+		t.Errorf("expected only the 5%% move withheld for its z-score, got %+v", reports)
+	}
+
+	// Moves below MinJump never stand out, however quiet the market.
+	quiet := []float64{100}
+	for i := range 30 {
+		quiet = append(quiet, 100+0.0001*float64(i%2))
+	}
+	if _, reports := screen(NewDetector(Config{MinSamples: 10}), append(quiet, 100.3)...); len(reports) != 0 {
+		t.Errorf("expected a 0.3%% move not to be suspicious, got %+v", reports)
+	}
+}
+
+// TestConfirm tests that a level persisting for Confirm prices is accepted as a genuine move.
+func TestConfirm(t *testing.T) {
+	published, reports := screen(NewDetector(Config{Mode: Quarantine}), 100, 70, 200, 69, 70, 71, 70.5)
+	if !slices.Equal(published, []float64{100, 71, 70.5}) {
+		t.Errorf("expected the new level accepted on the third price near it, got %v", published)
+	}
+	if len(reports) != 5 || reports[4].Action != Confirmed {
+		t.Errorf("expected the accepted price reported as confirmed, got %+v", reports)
+	}
+}
+
+// TestLog tests that reports are kept newest first up to the limit and counted for metrics.
+func TestLog(t *testing.T) {
+	l := NewLog(2)
+	for i, instrument := range []string{"BTC-USD", "ETH-USD", "BTC-USD"} {
+		l.Add(Report{Instrument: instrument, Price: float64(i), Action: Quarantined})
+	}
+	all := func(string) bool { return true }
+	recent := l.Recent(10, all)
+	if len(recent) != 2 || recent[0].ID != 3 || recent[1].ID != 2 {
+		t.Errorf("expected reports 3 and 2, got %+v", recent)
+	}
+	if btc := l.Recent(10, func(i string) bool { return i == "BTC-USD" }); len(btc) != 1 || btc[0].Price != 2 {
+		t.Errorf("expected only the latest BTC-USD report to be kept, got %+v", btc)
+	}
+
+	var out strings.Builder
+	l.WriteMetrics(&out, all)
+	if !strings.Contains(out.String(), `injective_anomalies_total{instrument="BTC-USD",action="quarantined"} 2`) {
+		t.Errorf("expected every report counted, got:\n%s", out.String())
+	}
+}
+
+func TestLogKeepsNone(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		l := NewLog(size)
+		l.Add(Report{Instrument: "BTC-USD", Action: Flagged})
+		all := func(string) bool { return true }
+		if recent := l.Recent(10, all); len(recent) != 0 {
+			t.Errorf("size %d: expected no reports kept, got %+v", size, recent)
+		}
+		var out strings.Builder
+		l.WriteMetrics(&out, all)
+		if !strings.Contains(out.String(), `injective_anomalies_total{instrument="BTC-USD",action="flagged"} 1`) {
+			t.Errorf("size %d: expected the report counted, got:\n%s", size, out.String())
+		}
+	}
+}
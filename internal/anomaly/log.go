@@ -0,0 +1,68 @@
+package anomaly
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Log keeps the most recent reports for review and counts every report by instrument and action.
+// It is safe for concurrent use.
+type Log struct {
+	mutex   sync.Mutex
+	reports []Report // Oldest first, at most max
+	max     int
+	seq     uint64
+	counts  map[[2]string]uint64 // By instrument and action
+}
+
+// NewLog keeps up to size reports. With size zero or less it keeps none, only counting them for metrics.
+func NewLog(size int) *Log {
+	return &Log{max: max(size, 0), counts: make(map[[2]string]uint64)}
+}
+
+// Add records a report, numbering it.
+func (l *Log) Add(r Report) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.seq++
+	r.ID = l.seq
+	l.counts[[2]string{r.Instrument, string(r.Action)}]++
+	if l.max == 0 {
+		return
+	}
+	if len(l.reports) == l.max {
+		l.reports = slices.Delete(l.reports, 0, 1)
+	}
+	l.reports = append(l.reports, r)
+}
+
+// Recent returns up to limit of the latest reports, newest first, for the instruments allow accepts.
+func (l *Log) Recent(limit int, allow func(instrument string) bool) []Report {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var reports []Report
+	for i := len(l.reports) - 1; i >= 0 && len(reports) < limit; i-- {
+		if allow(l.reports[i].Instrument) {
+			reports = append(reports, l.reports[i])
+		}
+	}
+	return reports
+}
+
+// WriteMetrics writes the report counts in the Prometheus text format, for the instruments allow accepts.
+func (l *Log) WriteMetrics(w io.Writer, allow func(instrument string) bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	fmt.Fprintf(w, "# HELP injective_anomalies_total Suspicious prices by what was done with them.\n# TYPE injective_anomalies_total counter\n")
+	keys := slices.SortedFunc(maps.Keys(l.counts), func(a, b [2]string) int {
+		return slices.Compare(a[:], b[:])
+	})
+	for _, key := range keys {
+		if allow(key[0]) {
+			fmt.Fprintf(w, "injective_anomalies_total{instrument=%q,action=%q} %d\n", key[0], key[1], l.counts[key])
+		}
+	}
+}
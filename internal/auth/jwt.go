@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedToken = errors.New("auth: malformed token")
+	ErrAlgorithm      = errors.New("auth: unsupported signing algorithm")
+	ErrSignature      = errors.New("auth: invalid signature")
+	ErrMissingExpiry  = errors.New("auth: token has no expiry")
+	ErrExpired        = errors.New("auth: token expired")
+	ErrNotYetValid    = errors.New("auth: token not valid yet")
+)
+
+// Claims holds the subset of JWT claims the stream cares about.
+// Instruments restricts which instruments the bearer may receive; an empty list means no restriction.
+type Claims struct {
+	Subject     string   `json:"sub,omitempty"`
+	ExpiresAt   int64    `json:"exp,omitempty"`
+	NotBefore   int64    `json:"nbf,omitempty"`
+	IssuedAt    int64    `json:"iat,omitempty"`
+	Instruments []string `json:"instruments,omitempty"`
+}
+
+// Expiry returns the exp claim as a time.Time.
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0).UTC()
+}
+
+// Allows reports whether the claims grant access to the given instrument.
+// Matching is case-insensitive and "*" grants every instrument.
+func (c *Claims) Allows(instrument string) bool {
+	if len(c.Instruments) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.Instruments, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, instrument)
+	})
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Validator verifies HS256-signed JWTs against a shared secret.
+type Validator struct {
+	secret []byte
+	leeway time.Duration
+	now    func() time.Time
+}
+
+// NewValidator creates a Validator for the given secret.
+// A small leeway absorbs clock skew between the token issuer and this server.
+func NewValidator(secret []byte) *Validator {
+	return &Validator{
+		secret: secret,
+		leeway: 5 * time.Second,
+		now:    time.Now,
+	}
+}
+
+// Deadline returns when tokens with these claims stop being accepted: their expiry plus the leeway.
+func (v *Validator) Deadline(c *Claims) time.Time {
+	return c.Expiry().Add(v.leeway)
+}
+
+// Validate parses the token, checks its signature in constant time and enforces exp/nbf.
+// Only HS256 is accepted; in particular "none" is rejected so unsigned tokens can never pass.
+func (v *Validator) Validate(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if h.Alg != "HS256" {
+		return nil, ErrAlgorithm
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !hmac.Equal(sig, sign(v.secret, parts[0]+"."+parts[1])) {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == 0 {
+		return nil, ErrMissingExpiry
+	}
+	if now.After(v.Deadline(&claims)) {
+		return nil, ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, ErrNotYetValid
+	}
+
+	return &claims, nil
+}
+
+// Sign produces an HS256 token for the given claims.
+// The server only validates tokens, so this is mostly useful for tests and tooling.
+func Sign(claims Claims, secret []byte) (string, error) {
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(secret, signingInput)), nil
+}
+
+func sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+func mustSign(t *testing.T, claims Claims, secret []byte) string {
+	t.Helper()
+	token, err := Sign(claims, secret)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+// TestValidate checks signature, algorithm and time-based claim handling.
+func TestValidate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	valid := Claims{Subject: "dashboard", ExpiresAt: now.Add(time.Minute).Unix()}
+
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	validParts := strings.Split(mustSign(t, valid, testSecret), ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", mustSign(t, valid, testSecret), nil},
+		{"wrong secret", mustSign(t, valid, []byte("other")), ErrSignature},
+		{"alg none", noneHeader + "." + validParts[1] + ".", ErrAlgorithm},
+		{"tampered payload", validParts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":9999999999}`)) + "." + validParts[2], ErrSignature},
+		{"two segments", validParts[0] + "." + validParts[1], ErrMalformedToken},
+		{"garbage", "not-a-token", ErrMalformedToken},
+		{"expired", mustSign(t, Claims{ExpiresAt: now.Add(-time.Minute).Unix()}, testSecret), ErrExpired},
+		{"expired within leeway", mustSign(t, Claims{ExpiresAt: now.Add(-2 * time.Second).Unix()}, testSecret), nil},
+		{"missing exp", mustSign(t, Claims{Subject: "forever"}, testSecret), ErrMissingExpiry},
+		{"not yet valid", mustSign(t, Claims{ExpiresAt: now.Add(time.Hour).Unix(), NotBefore: now.Add(time.Minute).Unix()}, testSecret), ErrNotYetValid},
+	}
+
+	v := NewValidator(testSecret)
+	v.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Validate(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestClaimsAllows checks instrument restrictions carried in the token.
+func TestClaimsAllows(t *testing.T) {
+	tests := []struct {
+		name        string
+		instruments []string
+		instrument  string
+		want        bool
+	}{
+		{"no restriction", nil, "BTC-USD", true},
+		{"listed", []string{"BTC-USD", "ETH-USD"}, "ETH-USD", true},
+		{"case insensitive", []string{"btc-usd"}, "BTC-USD", true},
+		{"not listed", []string{"ETH-USD"}, "BTC-USD", false},
+		{"wildcard", []string{"*"}, "SOL-USD", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Claims{Instruments: tt.instruments}
+			if got := c.Allows(tt.instrument); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.instrument, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMiddleware checks that tokens are read from the header or query string and claims reach the handler.
+func TestMiddleware(t *testing.T) {
+	token := mustSign(t, Claims{Subject: "ui", ExpiresAt: time.Now().Add(time.Minute).Unix()}, testSecret)
+
+	tests := []struct {
+		name       string
+		header     string
+		query      string
+		wantStatus int
+	}{
+		{"header", "Bearer " + token, "", http.StatusOK},
+		{"query param", "", "?access_token=" + token, http.StatusOK},
+		{"missing", "", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + token, "", http.StatusUnauthorized},
+		{"invalid", "Bearer " + token + "x", "", http.StatusUnauthorized},
+	}
+
+	v := NewValidator(testSecret)
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c := ClaimsFrom(r.Context()); c == nil || c.Subject != "ui" {
+			t.Errorf("expected claims in context, got %+v", c)
+		}
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/stream"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package bench load-tests an injective server by holding many concurrent SSE streams open.
+//
+// Each simulated client records how long its stream took to connect, the delivery latency of every
+// update (receive time minus the update's timestamp, so the bench and server clocks should agree),
+// updates it missed according to sequence gaps, and whether the server disconnected it early.
+// A fraction of clients read deliberately slowly through a small socket buffer, which exercises the
+// server's slow-client eviction.
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes a load test. URL and Clients are required.
+type Config struct {
+	URL      string        // Stream endpoint, e.g. http://localhost:8080/stream
+	Token    string        // Bearer token, when the server requires one
+	Clients  int           // Number of concurrent streams
+	Duration time.Duration // How long streams are held open once the ramp-up is over
+	Ramp     time.Duration // Connections are spread evenly over this period (0 opens them all at once)
+
+	SlowFraction float64 // Share of clients that read slowly, between 0 and 1
+	SlowRate     int     // Bytes per second a slow client reads (default 64)
+}
+
+// slowReadBuffer is the socket receive buffer of slow clients, kept small so the backlog
+// reaches the server quickly instead of piling up in the kernel.
+const slowReadBuffer = 4096
+
+// Run opens cfg.Clients streams, holds them for cfg.Duration and returns the aggregated results.
+// Cancelling ctx ends the test early; the report then covers what was measured so far.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return nil, fmt.Errorf("bench: invalid URL: %w", err)
+	}
+	if cfg.Clients <= 0 {
+		return nil, errors.New("bench: clients must be positive")
+	}
+	if cfg.SlowFraction < 0 || cfg.SlowFraction > 1 {
+		return nil, errors.New("bench: slow fraction must be between 0 and 1")
+	}
+	if cfg.SlowRate <= 0 {
+		cfg.SlowRate = 64
+	}
+
+	fast := &http.Client{Transport: newTransport(0)}
+	slow := &http.Client{Transport: newTransport(slowReadBuffer)}
+	defer fast.CloseIdleConnections()
+	defer slow.CloseIdleConnections()
+
+	started := time.Now()
+	ctx, cancel := context.WithDeadline(ctx, started.Add(cfg.Ramp+cfg.Duration))
+	defer cancel()
+
+	slowClients := int(float64(cfg.Clients) * cfg.SlowFraction)
+	results := make([]result, cfg.Clients)
+	var wg sync.WaitGroup
+	for i := range cfg.Clients {
+		// Slow clients are spread evenly through the ramp rather than all connecting last.
+		isSlow := (i+1)*slowClients/cfg.Clients > i*slowClients/cfg.Clients
+		if cfg.Ramp > 0 && i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(started.Add(cfg.Ramp * time.Duration(i) / time.Duration(cfg.Clients)))):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(r *result) {
+			defer wg.Done()
+			r.slow = isSlow
+			httpClient := fast
+			if isSlow {
+				httpClient = slow
+			}
+			r.run(ctx, httpClient, cfg)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return newReport(cfg, results, time.Since(started)), nil
+}
+
+// newTransport returns a transport that dials one connection per stream, like separate browsers would.
+// A positive readBuffer shrinks each connection's socket receive buffer.
+func newTransport(readBuffer int) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err == nil && readBuffer > 0 {
+				if tcp, ok := conn.(*net.TCPConn); ok {
+					tcp.SetReadBuffer(readBuffer)
+				}
+			}
+			return conn, err
+		},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		DisableCompression:    true,
+	}
+}
+
+// result is what a single simulated client observed.
+type result struct {
+	slow       bool
+	connected  bool
+	connect    time.Duration   // Time until the response headers arrived
+	failure    string          // Why the stream couldn't be opened
+	latencies  []time.Duration // Delivery latency of each update
+	missed     int64           // Updates skipped according to sequence gaps
+	disconnect string          // Why the server ended the stream early, if it did
+}
+
+// update holds the fields of a price update the bench needs.
+type update struct {
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (r *result) run(ctx context.Context, httpClient *http.Client, cfg Config) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		r.failure = err.Error()
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.failure = connectFailure(err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		r.failure = resp.Status
+		return
+	}
+	r.connected, r.connect = true, time.Since(start)
+
+	var body io.Reader = resp.Body
+	if r.slow {
+		body = &throttledReader{r: resp.Body, rate: cfg.SlowRate}
+	}
+
+	err = r.read(body)
+	if ctx.Err() != nil {
+		return // The test ended; this isn't a disconnect
+	}
+	switch {
+	case err == nil:
+		r.disconnect = "closed by server"
+	default:
+		r.disconnect = connectFailure(err)
+	}
+}
+
+// read consumes the stream, recording each update, until it ends. A clean end returns nil.
+func (r *result) read(body io.Reader) error {
+	var event, data string
+	var lastSeq uint64
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = value
+			}
+			continue
+		}
+
+		if (event == "" || event == "message") && data != "" {
+			received := time.Now()
+			var u update
+			if err := json.Unmarshal([]byte(data), &u); err != nil {
+				return fmt.Errorf("decoding update: %w", err)
+			}
+			r.latencies = append(r.latencies, received.Sub(u.Timestamp))
+			if lastSeq > 0 && u.Seq > lastSeq+1 {
+				r.missed += int64(u.Seq - lastSeq - 1)
+			}
+			if u.Seq > 0 {
+				lastSeq = u.Seq
+			}
+		}
+		if event == "shutdown" {
+			return errors.New("server shutdown")
+		}
+		event, data = "", ""
+	}
+	return scanner.Err()
+}
+
+// connectFailure shortens network errors so equal causes are grouped in the report.
+func connectFailure(err error) string {
+	var netErr *net.OpError
+	if errors.As(err, &netErr) && netErr.Err != nil {
+		return netErr.Op + ": " + netErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// throttledReader reads at most rate bytes per second, in small chunks.
+type throttledReader struct {
+	r    io.Reader
+	rate int
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	chunk := max(t.rate/10, 1)
+	if len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	time.Sleep(time.Duration(n) * time.Second / time.Duration(t.rate))
+	return n, err
+}
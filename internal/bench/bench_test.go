@@ -0,0 +1,158 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/server"
+)
+
+// startServer runs a real injective server fed by a mock CoinDesk API that ticks every 20ms.
+func startServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var price atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Data": {"BTC-USD": {"VALUE": %d}}}`, 60000+price.Add(1))
+	}))
+	t.Cleanup(api.Close)
+
+	t.Setenv("COINDESK_API_KEY", "dummy")
+	t.Setenv("COINDESK_API_URL", api.URL+"?api_key=%s")
+	t.Setenv("UPDATE_INTERVAL", "20ms")
+	t.Setenv("RATE_LIMIT_RPS", "0")
+
+	srv := server.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Run(ctx)
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// TestRun tests that concurrent streams are opened, measured and split into regular and slow clients.
+func TestRun(t *testing.T) {
+	t.Setenv("MAX_STREAMS_PER_IP", "0")
+	ts := startServer(t)
+
+	rep, err := Run(context.Background(), Config{
+		URL:          ts.URL + "/stream",
+		Clients:      20,
+		Duration:     500 * time.Millisecond,
+		Ramp:         100 * time.Millisecond,
+		SlowFraction: 0.25,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if rep.Connected != 20 || len(rep.Failures) != 0 {
+		t.Errorf("expected all 20 clients to connect, got %d (failures %v)", rep.Connected, rep.Failures)
+	}
+	if rep.SlowClients != 5 {
+		t.Errorf("expected 5 slow clients, got %d", rep.SlowClients)
+	}
+	if rep.Connect.Count != 20 {
+		t.Errorf("expected 20 connect samples, got %d", rep.Connect.Count)
+	}
+	if rep.Delivery.Count == 0 || rep.Updates < int64(rep.Delivery.Count) {
+		t.Errorf("expected delivery samples, got %+v (updates %d)", rep.Delivery, rep.Updates)
+	}
+	if rep.Delivery.P50 <= 0 || rep.Delivery.P50 > time.Second {
+		t.Errorf("implausible median delivery latency %v", rep.Delivery.P50)
+	}
+
+	var out bytes.Buffer
+	rep.WriteTo(&out)
+	for _, want := range []string{"20 connected", "slow clients:", "p99"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestRunReportsRejections tests that streams refused by the server are counted by cause.
+func TestRunReportsRejections(t *testing.T) {
+	t.Setenv("MAX_STREAMS_PER_IP", "3")
+	ts := startServer(t)
+
+	rep, err := Run(context.Background(), Config{URL: ts.URL + "/stream", Clients: 5, Duration: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.Connected != 3 || rep.Failures["429 Too Many Requests"] != 2 || rep.RateLimited() != 2 {
+		t.Errorf("expected 3 connected and 2 rejected, got %d connected, failures %v", rep.Connected, rep.Failures)
+	}
+
+	// Only when most streams are refused does the report point at the server's per-IP cap.
+	var out strings.Builder
+	rep.WriteTo(&out)
+	if strings.Contains(out.String(), "MAX_STREAMS_PER_IP") {
+		t.Errorf("expected no rate limit note, got:\n%s", out.String())
+	}
+	rep.Failures["429 Too Many Requests"] = 4
+	out.Reset()
+	rep.WriteTo(&out)
+	if !strings.Contains(out.String(), "MAX_STREAMS_PER_IP=0") {
+		t.Errorf("expected a rate limit note, got:\n%s", out.String())
+	}
+}
+
+// TestRunCountsDisconnects tests that streams the server ends early are reported as disconnects.
+func TestRunCountsDisconnects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"seq\":1,\"timestamp\":%q}\n\n", time.Now().Format(time.RFC3339Nano))
+		fmt.Fprintf(w, "data: {\"seq\":4,\"timestamp\":%q}\n\n", time.Now().Format(time.RFC3339Nano))
+	}))
+	defer ts.Close()
+
+	rep, err := Run(context.Background(), Config{URL: ts.URL, Clients: 2, Duration: time.Second})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.Disconnects["closed by server"] != 2 {
+		t.Errorf("expected 2 disconnects, got %v", rep.Disconnects)
+	}
+	if rep.Missed != 4 {
+		t.Errorf("expected 4 missed updates (2 per client), got %d", rep.Missed)
+	}
+}
+
+// TestPercentiles tests nearest-rank percentiles.
+func TestPercentiles(t *testing.T) {
+	var d []time.Duration
+	for i := 100; i >= 1; i-- {
+		d = append(d, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(d)
+	want := Percentiles{Count: 100, Min: time.Millisecond, P50: 51 * time.Millisecond, P90: 91 * time.Millisecond, P99: 100 * time.Millisecond, Max: 100 * time.Millisecond}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+	if (percentiles(nil) != Percentiles{}) {
+		t.Error("expected zero percentiles for no samples")
+	}
+}
+
+// TestRunValidatesConfig tests that invalid configurations are rejected up front.
+func TestRunValidatesConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{URL: "not a url", Clients: 1},
+		{URL: "http://localhost/stream"},
+		{URL: "http://localhost/stream", Clients: 1, SlowFraction: 1.5},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
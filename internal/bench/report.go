@@ -0,0 +1,161 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report aggregates the results of a load test.
+type Report struct {
+	Clients     int           // Streams attempted
+	SlowClients int           // Of which deliberately slow
+	Connected   int           // Streams the server accepted
+	Elapsed     time.Duration // Wall time including ramp-up
+
+	Failures    map[string]int // Streams that couldn't be opened, by cause (e.g. "429 Too Many Requests")
+	Disconnects map[string]int // Streams ended before the test did, by cause
+
+	Updates int64 // Updates received across all streams
+	Missed  int64 // Updates skipped according to sequence gaps
+
+	Connect      Percentiles // Time to response headers
+	Delivery     Percentiles // Update latency for regular clients
+	SlowDelivery Percentiles // Update latency for slow clients
+}
+
+// Percentiles summarises a latency distribution.
+type Percentiles struct {
+	Count                   int
+	Min, P50, P90, P99, Max time.Duration
+}
+
+func newReport(cfg Config, results []result, elapsed time.Duration) *Report {
+	rep := &Report{
+		Clients:     cfg.Clients,
+		Elapsed:     elapsed,
+		Failures:    make(map[string]int),
+		Disconnects: make(map[string]int),
+	}
+
+	var connect, delivery, slowDelivery []time.Duration
+	for _, r := range results {
+		if r.slow {
+			rep.SlowClients++
+		}
+		if r.failure != "" {
+			rep.Failures[r.failure]++
+		}
+		if !r.connected {
+			continue
+		}
+		rep.Connected++
+		connect = append(connect, r.connect)
+		if r.disconnect != "" {
+			rep.Disconnects[r.disconnect]++
+		}
+		rep.Updates += int64(len(r.latencies))
+		rep.Missed += r.missed
+		if r.slow {
+			slowDelivery = append(slowDelivery, r.latencies...)
+		} else {
+			delivery = append(delivery, r.latencies...)
+		}
+	}
+
+	rep.Connect = percentiles(connect)
+	rep.Delivery = percentiles(delivery)
+	rep.SlowDelivery = percentiles(slowDelivery)
+	return rep
+}
+
+// percentiles sorts d in place and summarises it using the nearest-rank method.
+func percentiles(d []time.Duration) Percentiles {
+	if len(d) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(d)
+	rank := func(p float64) time.Duration {
+		return d[min(int(p*float64(len(d))), len(d)-1)]
+	}
+	return Percentiles{
+		Count: len(d),
+		Min:   d[0],
+		P50:   rank(0.50),
+		P90:   rank(0.90),
+		P99:   rank(0.99),
+		Max:   d[len(d)-1],
+	}
+}
+
+// WriteTo prints a human-readable summary of the report.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	fmt.Fprintf(cw, "clients:      %d (%d slow), %d connected, %d failed in %v\n",
+		r.Clients, r.SlowClients, r.Connected, r.Clients-r.Connected, r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(cw, "updates:      %d received, %d missed\n", r.Updates, r.Missed)
+	fmt.Fprintf(cw, "connect:      %s\n", r.Connect)
+	fmt.Fprintf(cw, "delivery:     %s\n", r.Delivery)
+	if r.SlowClients > 0 {
+		fmt.Fprintf(cw, "slow clients: %s\n", r.SlowDelivery)
+	}
+	writeCounts(cw, "failures", r.Failures)
+	writeCounts(cw, "disconnects", r.Disconnects)
+	if r.RateLimited()*2 > r.Clients {
+		fmt.Fprintf(cw, "note: most streams were refused with 429; a server with default settings accepts %d streams\n", defaultStreamsPerIP)
+		fmt.Fprintf(cw, "      per IP, so start the target with MAX_STREAMS_PER_IP=0 RATE_LIMIT_RPS=0 to bench from one host\n")
+	}
+	return cw.n, cw.err
+}
+
+// defaultStreamsPerIP is the server's default MAX_STREAMS_PER_IP.
+const defaultStreamsPerIP = 10
+
+// RateLimited returns how many streams the server refused with 429 Too Many Requests.
+func (r *Report) RateLimited() int {
+	n := 0
+	for cause, count := range r.Failures {
+		if strings.HasPrefix(cause, "429 ") {
+			n += count
+		}
+	}
+	return n
+}
+
+func (p Percentiles) String() string {
+	if p.Count == 0 {
+		return "no samples"
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(10 * time.Microsecond) }
+	return fmt.Sprintf("min %v  p50 %v  p90 %v  p99 %v  max %v  (n=%d)",
+		round(p.Min), round(p.P50), round(p.P90), round(p.P99), round(p.Max), p.Count)
+}
+
+func writeCounts(w io.Writer, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, cause := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(w, "  %6d  %s\n", counts[cause], cause)
+	}
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,24 @@
+package bus
+
+import (
+	"context"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Bus decouples producing price updates from fanning them out to clients.
+//
+// The instance that fetches prices publishes to the bus, and every instance (including the publisher)
+// subscribes and delivers what it receives to its local clients. With an in-memory bus this is a single
+// process; with a shared broker it lets one fetcher feed many SSE servers.
+type Bus interface {
+	// Publish sends an update to all current subscribers.
+	Publish(ctx context.Context, update models.PriceUpdate) error
+
+	// Subscribe returns a channel of updates published from now on.
+	// The channel is closed when ctx is cancelled or the bus is closed.
+	Subscribe(ctx context.Context) (<-chan models.PriceUpdate, error)
+
+	// Close releases the bus's resources and ends all subscriptions.
+	Close() error
+}
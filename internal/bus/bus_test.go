@@ -0,0 +1,138 @@
+package bus
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/resp/resptest"
+)
+
+func expectUpdate(t *testing.T, ch <-chan models.PriceUpdate, wantSeq uint64) {
+	t.Helper()
+	select {
+	case u, ok := <-ch:
+		if !ok {
+			t.Fatalf("subscription closed while waiting for seq %d", wantSeq)
+		}
+		if u.Seq != wantSeq {
+			t.Fatalf("expected seq %d, got %d", wantSeq, u.Seq)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for seq %d", wantSeq)
+	}
+}
+
+func expectClosed(t *testing.T, ch <-chan models.PriceUpdate) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected subscription to be closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+// testBus exercises the Bus contract shared by all implementations.
+func testBus(t *testing.T, b Bus) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub1, err := b.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	sub2Ctx, cancelSub2 := context.WithCancel(ctx)
+	sub2, err := b.Subscribe(sub2Ctx)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	update := models.PriceUpdate{Seq: 1, Timestamp: time.Now().UTC(), Price: 42000.5, Instrument: "BTC-USD"}
+	if err := b.Publish(ctx, update); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	expectUpdate(t, sub1, 1)
+	expectUpdate(t, sub2, 1)
+
+	// Cancelling one subscription doesn't affect the other.
+	cancelSub2()
+	expectClosed(t, sub2)
+
+	update.Seq = 2
+	if err := b.Publish(ctx, update); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	expectUpdate(t, sub1, 2)
+
+	// Close ends every subscription and rejects further use.
+	b.Close()
+	expectClosed(t, sub1)
+	if err := b.Publish(ctx, update); err == nil {
+		t.Error("expected publish on closed bus to fail")
+	}
+}
+
+// TestMemoryBus tests the in-memory implementation.
+func TestMemoryBus(t *testing.T) {
+	testBus(t, NewMemoryBus())
+}
+
+// TestRedisBus tests the Redis implementation against an in-process fake,
+// or a real server when REDIS_TEST_ADDR is set (e.g. REDIS_TEST_ADDR=localhost:6379).
+func TestRedisBus(t *testing.T) {
+	cfg := RedisConfig{Channel: "injective-test-" + time.Now().Format("150405.000")}
+	if addr := os.Getenv("REDIS_TEST_ADDR"); addr != "" {
+		cfg.Addr = addr
+	} else {
+		fake := resptest.NewServer(t, "s3cret")
+		cfg.Addr, cfg.Password = fake.Addr(), "s3cret"
+	}
+
+	testBus(t, NewRedisBus(cfg))
+}
+
+// TestRedisBusAuthFailure tests that a wrong password surfaces from Subscribe.
+func TestRedisBusAuthFailure(t *testing.T) {
+	fake := resptest.NewServer(t, "s3cret")
+	b := NewRedisBus(RedisConfig{Addr: fake.Addr(), Password: "wrong", Channel: "prices"})
+	defer b.Close()
+
+	if _, err := b.Subscribe(context.Background()); err == nil {
+		t.Fatal("expected authentication error")
+	}
+}
+
+// TestRedisBusResubscribes tests that a subscription survives the broker dropping the connection.
+func TestRedisBusResubscribes(t *testing.T) {
+	fake := resptest.NewServer(t, "")
+	b := NewRedisBus(RedisConfig{Addr: fake.Addr(), Channel: "prices"})
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := b.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	fake.DropConnections()
+
+	// Wait for the subscriber to come back before publishing, since Pub/Sub doesn't buffer.
+	deadline := time.Now().Add(3 * time.Second)
+	for fake.SubscriberCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscriber did not reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := b.Publish(ctx, models.PriceUpdate{Seq: 7}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	expectUpdate(t, sub, 7)
+}
@@ -0,0 +1,85 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// subscriberBuffer is how many updates a subscriber may lag behind before updates are dropped for it.
+const subscriberBuffer = 64
+
+var ErrClosed = errors.New("bus: closed")
+
+// MemoryBus is an in-process Bus for single-instance deployments and tests.
+type MemoryBus struct {
+	subscribers map[chan models.PriceUpdate]struct{}
+	closed      bool
+	mutex       sync.Mutex
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{subscribers: make(map[chan models.PriceUpdate]struct{})}
+}
+
+// Publish delivers the update to every subscriber without blocking.
+// A subscriber whose buffer is full misses the update rather than stalling the publisher.
+func (b *MemoryBus) Publish(_ context.Context, update models.PriceUpdate) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	for ch := range b.subscribers {
+		select {
+		case ch <- update:
+		default:
+			log.Printf("[!] bus subscriber lagging, dropped update %d", update.Seq)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context) (<-chan models.PriceUpdate, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+	ch := make(chan models.PriceUpdate, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(ch)
+	}()
+
+	return ch, nil
+}
+
+func (b *MemoryBus) unsubscribe(ch chan models.PriceUpdate) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
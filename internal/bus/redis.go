@@ -0,0 +1,190 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/resp"
+)
+
+const (
+	redisCommandTimeout = 3 * time.Second
+	redisMinBackoff     = 100 * time.Millisecond
+	redisMaxBackoff     = 10 * time.Second
+)
+
+// RedisConfig points the bus at a Redis server and channel.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	Channel  string
+}
+
+// RedisBus is a Bus over Redis Pub/Sub, so one fetcher can feed SSE servers on many hosts.
+//
+// Updates are sent as JSON. Redis Pub/Sub is fire-and-forget: subscribers that are disconnected
+// while an update is published miss it, which is acceptable for a live price feed where the next tick supersedes it.
+// Subscriptions reconnect with exponential backoff until their context is cancelled.
+type RedisBus struct {
+	cfg RedisConfig
+
+	pub      *resp.Conn // Shared publishing connection, dialled lazily
+	pubMutex sync.Mutex
+
+	ctx    context.Context // Cancelled by Close to end subscriptions
+	cancel context.CancelFunc
+}
+
+func NewRedisBus(cfg RedisConfig) *RedisBus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisBus{cfg: cfg, ctx: ctx, cancel: cancel}
+}
+
+// Publish sends the update with PUBLISH, redialling once if the shared connection has gone stale.
+func (b *RedisBus) Publish(ctx context.Context, update models.PriceUpdate) error {
+	if b.ctx.Err() != nil {
+		return ErrClosed
+	}
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	b.pubMutex.Lock()
+	defer b.pubMutex.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if b.pub == nil {
+			if b.pub, err = resp.Dial(ctx, b.cfg.Addr, b.cfg.Password); err != nil {
+				return err
+			}
+		}
+		if _, err = b.pub.Do(redisCommandTimeout, "PUBLISH", b.cfg.Channel, string(payload)); err == nil {
+			return nil
+		}
+		var replyErr resp.Error
+		if errors.As(err, &replyErr) {
+			return err
+		}
+		b.pub.Close()
+		b.pub = nil
+	}
+	return err
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context) (<-chan models.PriceUpdate, error) {
+	if b.ctx.Err() != nil {
+		return nil, ErrClosed
+	}
+
+	// Subscribe synchronously once so configuration errors surface to the caller
+	// and updates published right after Subscribe returns are not missed.
+	conn, err := b.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan models.PriceUpdate, subscriberBuffer)
+	go b.receive(ctx, conn, ch)
+	return ch, nil
+}
+
+func (b *RedisBus) subscribe(ctx context.Context) (*resp.Conn, error) {
+	conn, err := resp.Dial(ctx, b.cfg.Addr, b.cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Do(redisCommandTimeout, "SUBSCRIBE", b.cfg.Channel); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// receive reads messages until ctx or the bus is done, resubscribing after connection failures.
+func (b *RedisBus) receive(ctx context.Context, conn *resp.Conn, ch chan<- models.PriceUpdate) {
+	defer close(ch)
+
+	// done ends the subscription when either the caller or Close says so.
+	done, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(b.ctx, cancel)
+	defer stop()
+
+	backoff := redisMinBackoff
+	for {
+		// Closing the connection is what unblocks a pending read when we are told to stop.
+		closeOnDone := context.AfterFunc(done, func() { conn.Close() })
+		err := b.readMessages(conn, ch, done)
+		closeOnDone()
+		conn.Close()
+
+		if done.Err() != nil {
+			return
+		}
+		log.Printf("[!] redis subscription lost: %v", err)
+
+		for {
+			select {
+			case <-done.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, redisMaxBackoff)
+
+			if conn, err = b.subscribe(done); err == nil {
+				log.Printf("redis subscription restored")
+				backoff = redisMinBackoff
+				break
+			}
+			log.Printf("[!] redis resubscribe failed: %v", err)
+		}
+	}
+}
+
+func (b *RedisBus) readMessages(conn *resp.Conn, ch chan<- models.PriceUpdate, done context.Context) error {
+	for {
+		reply, err := conn.Read()
+		if err != nil {
+			return err
+		}
+
+		// Pub/Sub pushes arrive as ["message", channel, payload].
+		msg, ok := reply.([]any)
+		if !ok || len(msg) != 3 || msg[0] != "message" {
+			continue
+		}
+		payload, _ := msg[2].(string)
+
+		var update models.PriceUpdate
+		if err := json.Unmarshal([]byte(payload), &update); err != nil {
+			log.Printf("[!] ignoring malformed bus message: %v", err)
+			continue
+		}
+
+		select {
+		case ch <- update:
+		case <-done.Done():
+			return done.Err()
+		default:
+			log.Printf("[!] bus subscriber lagging, dropped update %d", update.Seq)
+		}
+	}
+}
+
+func (b *RedisBus) Close() error {
+	b.cancel()
+
+	b.pubMutex.Lock()
+	defer b.pubMutex.Unlock()
+	if b.pub != nil {
+		b.pub.Close()
+		b.pub = nil
+	}
+	return nil
+}
@@ -1,10 +1,14 @@
 package client
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/matheusdutrademoura/injective/internal/models"
 )
@@ -13,6 +17,16 @@ import (
 type Client struct {
 	ID   string
 	Chan chan models.PriceUpdate
+
+	// Interval, when positive, limits the client to one update per instrument per Interval.
+	// Updates arriving in between are conflated: only the latest is delivered when the period ends.
+	// It must be set before the client is registered.
+	Interval time.Duration
+
+	// Throttling state, guarded by the manager's mutex.
+	lastSent map[string]time.Time
+	pending  map[string]models.PriceUpdate
+	flush    Timer
 }
 
 // NewClientWithBuffer creates a client with a buffered channel of the specified size.
@@ -21,9 +35,14 @@ func NewClientWithBuffer(buffer int) *Client {
 	return &Client{Chan: make(chan models.PriceUpdate, buffer)}
 }
 
+// ErrShuttingDown is returned by Register once Shutdown has been called.
+var ErrShuttingDown = errors.New("client manager is shutting down")
+
 // ClientManager manages concurrent access to the map of connected clients.
 type ClientManager struct {
 	clients map[*Client]bool
+	closing bool
+	clock   Clock
 	mutex   sync.Mutex
 }
 
@@ -31,47 +50,59 @@ type ClientManager struct {
 var clientCounter int64
 
 func NewClientManager() *ClientManager {
-	return &ClientManager{clients: make(map[*Client]bool)}
+	return &ClientManager{clients: make(map[*Client]bool), clock: realClock{}}
 }
 
 // Register adds a new client to the manager, assigning it a unique ID.
 // Uses atomic operations to safely generate IDs in concurrent environment.
-func (cm *ClientManager) Register(c *Client) {
+// Once the manager is shutting down new clients are refused with ErrShuttingDown.
+func (cm *ClientManager) Register(c *Client) error {
 	c.ID = fmt.Sprintf("client-%d", atomic.AddInt64(&clientCounter, 1))
 	cm.mutex.Lock()
+	if cm.closing {
+		cm.mutex.Unlock()
+		return ErrShuttingDown
+	}
+	if c.Interval > 0 {
+		c.lastSent = make(map[string]time.Time)
+		c.pending = make(map[string]models.PriceUpdate)
+	}
 	cm.clients[c] = true
 	cm.mutex.Unlock()
 	log.Printf("--> registered [%s]", c.ID)
+	return nil
 }
 
 // Unregister removes the client and closes its channel to signal disconnection.
 // Closing the channel allows goroutines receiving from it to exit gracefully.
+// It is safe to call more than once: the channel is only closed by the call that removes the client.
 func (cm *ClientManager) Unregister(c *Client) {
 	cm.mutex.Lock()
-	_, exists := cm.clients[c]
-	if exists {
-		delete(cm.clients, c)
-		log.Printf("<-- unregistered [%s]", c.ID)
+	defer cm.mutex.Unlock()
+
+	if _, exists := cm.clients[c]; !exists {
+		return
 	}
-	cm.mutex.Unlock()
+	delete(cm.clients, c)
+	c.stopFlush()
 	close(c.Chan)
+	log.Printf("<-- unregistered [%s]", c.ID)
 }
 
 // Broadcast sends the price update to all registered clients.
 // It uses non-blocking sends to avoid blocking the entire system on slow or stuck clients.
 // Clients whose channel buffer is full are considered slow and are removed to maintain overall system health.
 // Slow clients are collected while holding the lock and removed after releasing the lock to avoid deadlocks.
+// Throttled clients (see Client.Interval) receive the update now or, conflated, when their period ends.
 func (cm *ClientManager) Broadcast(update models.PriceUpdate) {
 	cm.mutex.Lock()
 	var slowClients []*Client
 
 	for client := range cm.clients {
-		select {
-		case client.Chan <- update:
-			// Successfully sent update
-		default:
-			// Client channel full; mark client for removal
-			log.Printf("[!] dropping client [%s]", client.ID)
+		if client.Interval > 0 && cm.conflate(client, update) {
+			continue
+		}
+		if !cm.send(client, update) {
 			slowClients = append(slowClients, client)
 		}
 	}
@@ -82,3 +113,118 @@ func (cm *ClientManager) Broadcast(update models.PriceUpdate) {
 		cm.Unregister(c)
 	}
 }
+
+// send delivers an update without blocking, reporting false if the client's channel is full.
+// It must be called with the mutex held.
+func (cm *ClientManager) send(c *Client, update models.PriceUpdate) bool {
+	select {
+	case c.Chan <- update:
+		if c.Interval > 0 {
+			c.lastSent[update.Instrument] = cm.clock.Now()
+		}
+		return true
+	default:
+		// Client channel full; the caller removes the client
+		log.Printf("[!] dropping client [%s]", c.ID)
+		return false
+	}
+}
+
+// conflate holds back an update for a throttled client whose current period hasn't ended,
+// replacing any update already held for the instrument. It reports whether the update was held.
+// It must be called with the mutex held.
+func (cm *ClientManager) conflate(c *Client, update models.PriceUpdate) bool {
+	_, held := c.pending[update.Instrument]
+	last, sent := c.lastSent[update.Instrument]
+	if !held && (!sent || cm.clock.Now().Sub(last) >= c.Interval) {
+		return false
+	}
+	c.pending[update.Instrument] = update
+	if c.flush == nil {
+		c.flush = cm.clock.AfterFunc(last.Add(c.Interval).Sub(cm.clock.Now()), func() { cm.flush(c) })
+	}
+	return true
+}
+
+// flush delivers a throttled client's held updates whose period has ended and re-arms
+// the timer for the rest. It runs on the clock's timer goroutine.
+func (cm *ClientManager) flush(c *Client) {
+	cm.mutex.Lock()
+	if !cm.clients[c] {
+		cm.mutex.Unlock()
+		return // unregistered while the timer was firing
+	}
+	c.flush = nil
+
+	now := cm.clock.Now()
+	var next time.Time
+	slow := false
+	for _, update := range heldInOrder(c.pending) {
+		due := c.lastSent[update.Instrument].Add(c.Interval)
+		if due.After(now) {
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+			continue
+		}
+		delete(c.pending, update.Instrument)
+		if !cm.send(c, update) {
+			slow = true
+			break
+		}
+	}
+	if !slow && !next.IsZero() {
+		c.flush = cm.clock.AfterFunc(next.Sub(now), func() { cm.flush(c) })
+	}
+	cm.mutex.Unlock()
+
+	if slow {
+		cm.Unregister(c)
+	}
+}
+
+// heldInOrder returns held updates in publication order.
+func heldInOrder(pending map[string]models.PriceUpdate) []models.PriceUpdate {
+	updates := make([]models.PriceUpdate, 0, len(pending))
+	for _, u := range pending {
+		updates = append(updates, u)
+	}
+	slices.SortFunc(updates, func(a, b models.PriceUpdate) int { return cmp.Compare(a.Seq, b.Seq) })
+	return updates
+}
+
+func (c *Client) stopFlush() {
+	if c.flush != nil {
+		c.flush.Stop()
+		c.flush = nil
+	}
+}
+
+// Shutdown stops accepting new clients and closes every registered client's channel.
+// Receivers observe the closed channel and can check Closing to tell a shutdown apart from being dropped.
+func (cm *ClientManager) Shutdown() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.closing = true
+	for c := range cm.clients {
+		delete(cm.clients, c)
+		c.stopFlush()
+		close(c.Chan)
+	}
+	log.Printf("closed all client channels for shutdown")
+}
+
+// Closing reports whether Shutdown has been called.
+func (cm *ClientManager) Closing() bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return cm.closing
+}
+
+// Count returns the number of registered clients.
+func (cm *ClientManager) Count() int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	return len(cm.clients)
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -162,3 +164,201 @@ func TestClientManagerRace(t *testing.T) {
 	time.Sleep(500 * time.Millisecond)
 	close(stop)
 }
+
+// TestClientManager_Shutdown tests that Shutdown closes all client channels and refuses new clients.
+func TestClientManager_Shutdown(t *testing.T) {
+	cm := NewClientManager()
+
+	c1 := NewClientWithBuffer(1)
+	c2 := NewClientWithBuffer(1)
+	cm.Register(c1)
+	cm.Register(c2)
+
+	cm.Shutdown()
+
+	for _, c := range []*Client{c1, c2} {
+		if _, ok := <-c.Chan; ok {
+			t.Errorf("expected channel of [%s] to be closed", c.ID)
+		}
+	}
+	if !cm.Closing() {
+		t.Error("expected manager to report closing")
+	}
+	if cm.Count() != 0 {
+		t.Errorf("expected no registered clients, got %d", cm.Count())
+	}
+	if err := cm.Register(NewClientWithBuffer(1)); err != ErrShuttingDown {
+		t.Errorf("expected ErrShuttingDown, got %v", err)
+	}
+
+	// Handlers still unregister on their way out; that must not double-close.
+	cm.Unregister(c1)
+}
+
+// fakeClock is a Clock whose time only moves when the test advances it.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves time forward and runs the timers that came due, in order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []*fakeTimer
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		switch {
+		case t.stopped:
+		case !t.at.After(c.now):
+			t.stopped = true
+			due = append(due, t)
+		default:
+			remaining = append(remaining, t)
+		}
+	}
+	c.timers = remaining
+	c.mu.Unlock()
+
+	slices.SortFunc(due, func(a, b *fakeTimer) int { return a.at.Compare(b.at) })
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// received drains whatever is waiting on the client's channel.
+func received(c *Client) []float64 {
+	var prices []float64
+	for {
+		select {
+		case u := <-c.Chan:
+			prices = append(prices, u.Price)
+		default:
+			return prices
+		}
+	}
+}
+
+// TestBroadcast_Throttled verifies that throttled clients get at most one update per period, the latest one.
+func TestBroadcast_Throttled(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cm := NewClientManager()
+	cm.clock = clock
+
+	throttled := NewClientWithBuffer(4)
+	throttled.Interval = 30 * time.Second
+	cm.Register(throttled)
+	live := NewClientWithBuffer(16)
+	cm.Register(live)
+
+	seq := uint64(0)
+	tick := func(price float64) {
+		seq++
+		cm.Broadcast(models.PriceUpdate{Seq: seq, Instrument: "BTC-USD", Price: price})
+	}
+
+	tick(100) // first update goes straight through
+	if got := received(throttled); !slices.Equal(got, []float64{100}) {
+		t.Fatalf("expected first update immediately, got %v", got)
+	}
+
+	clock.Advance(5 * time.Second)
+	tick(101)
+	clock.Advance(5 * time.Second)
+	tick(102)
+	if got := received(throttled); len(got) != 0 {
+		t.Fatalf("expected updates to be held within the period, got %v", got)
+	}
+
+	clock.Advance(20 * time.Second) // period ends at 30s
+	if got := received(throttled); !slices.Equal(got, []float64{102}) {
+		t.Fatalf("expected only the latest update at the end of the period, got %v", got)
+	}
+
+	// Nothing happens in the next period, so the following update is delivered at once.
+	clock.Advance(45 * time.Second)
+	tick(103)
+	if got := received(throttled); !slices.Equal(got, []float64{103}) {
+		t.Fatalf("expected an idle client to get the update immediately, got %v", got)
+	}
+
+	if got := received(live); len(got) != 4 {
+		t.Errorf("expected the unthrottled client to get all 4 updates, got %v", got)
+	}
+}
+
+// TestBroadcast_ThrottledPerInstrument verifies that instruments are conflated independently.
+func TestBroadcast_ThrottledPerInstrument(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cm := NewClientManager()
+	cm.clock = clock
+
+	c := NewClientWithBuffer(4)
+	c.Interval = time.Second
+	cm.Register(c)
+
+	cm.Broadcast(models.PriceUpdate{Seq: 1, Instrument: "BTC-USD", Price: 1})
+	clock.Advance(500 * time.Millisecond)
+	cm.Broadcast(models.PriceUpdate{Seq: 2, Instrument: "ETH-USD", Price: 2}) // other instrument, not held
+	cm.Broadcast(models.PriceUpdate{Seq: 3, Instrument: "BTC-USD", Price: 3})
+	cm.Broadcast(models.PriceUpdate{Seq: 4, Instrument: "ETH-USD", Price: 4})
+	if got := received(c); !slices.Equal(got, []float64{1, 2}) {
+		t.Fatalf("expected the first update of each instrument, got %v", got)
+	}
+
+	clock.Advance(500 * time.Millisecond) // BTC's period ends
+	if got := received(c); !slices.Equal(got, []float64{3}) {
+		t.Fatalf("expected the held BTC update, got %v", got)
+	}
+	clock.Advance(500 * time.Millisecond) // ETH's period ends
+	if got := received(c); !slices.Equal(got, []float64{4}) {
+		t.Fatalf("expected the held ETH update, got %v", got)
+	}
+}
+
+// TestUnregister_StopsThrottleTimer verifies that a pending flush doesn't touch an unregistered client.
+func TestUnregister_StopsThrottleTimer(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cm := NewClientManager()
+	cm.clock = clock
+
+	c := NewClientWithBuffer(1)
+	c.Interval = time.Second
+	cm.Register(c)
+	cm.Broadcast(models.PriceUpdate{Seq: 1, Instrument: "BTC-USD", Price: 1})
+	<-c.Chan
+	cm.Broadcast(models.PriceUpdate{Seq: 2, Instrument: "BTC-USD", Price: 2})
+
+	cm.Unregister(c)
+	clock.Advance(time.Second) // would panic sending on the closed channel
+	if _, ok := <-c.Chan; ok {
+		t.Error("expected no update after unregistering")
+	}
+}
@@ -0,0 +1,158 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy describes which cross-origin requests a route accepts.
+//
+// AllowedOrigins entries are matched against the Origin header and may be:
+//   - an exact origin, e.g. "https://app.example.com"
+//   - a wildcard subdomain, e.g. "https://*.example.com" (matches any subdomain, not the apex)
+//   - "*" to allow any origin
+//
+// With an empty AllowedOrigins list no cross-origin request is allowed; same-origin requests are unaffected.
+type Policy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration // How long browsers may cache a preflight response
+}
+
+// Validate rejects policies that would let any site make credentialed requests: "*" with AllowCredentials.
+func (p Policy) Validate() error {
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return errors.New(`credentials cannot be allowed for any origin ("*"); list the origins instead`)
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether the policy accepts the given Origin header value.
+func (p Policy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) || matchWildcard(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler applies the policy to next.
+// Preflight requests are answered directly and never reach next; denied preflights get 403.
+// Simple requests from denied origins are passed through without CORS headers so the browser blocks the response.
+func (p Policy) Handler(next http.Handler) http.Handler {
+	methods := strings.Join(p.AllowedMethods, ", ")
+	headers := strings.Join(p.AllowedHeaders, ", ")
+	exposed := strings.Join(p.ExposedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// Responses differ by Origin, so shared caches must key on it.
+		w.Header().Add("Vary", "Origin")
+
+		if !p.AllowsOrigin(origin) {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p.setAllowOrigin(w, origin)
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			if !slices.Contains(p.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+				http.Error(w, "method not allowed", http.StatusForbidden)
+				return
+			}
+			if !p.allowsHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+				http.Error(w, "header not allowed", http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if p.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if exposed != "" {
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setAllowOrigin echoes the origin back, except for a "*" policy where the literal wildcard suffices.
+// Browsers reject "*" on credentialed requests, which is the point: credentials are never allowed for
+// any origin, even if a policy that fails Validate asks for them.
+func (p Policy) setAllowOrigin(w http.ResponseWriter, origin string) {
+	if slices.Contains(p.AllowedOrigins, "*") {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p Policy) allowsHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, h) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWildcard matches "scheme://*.domain[:port]" patterns against an origin.
+func matchWildcard(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	prefix := scheme + "://"
+	if !strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) {
+		return false
+	}
+	sub := origin[len(prefix):]
+	suffix := "." + host
+	return len(sub) > len(suffix) && strings.EqualFold(sub[len(sub)-len(suffix):], suffix)
+}
+
+// ParseOrigins splits a comma-separated origin list, dropping blanks and trailing slashes.
+func ParseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
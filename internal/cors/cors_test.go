@@ -0,0 +1,167 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAllowsOrigin tests exact, wildcard-subdomain and any-origin matching.
+func TestAllowsOrigin(t *testing.T) {
+	p := Policy{AllowedOrigins: ParseOrigins("https://app.example.com, https://*.partner.io/, http://localhost:3000")}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://dash.partner.io", true},
+		{"https://a.b.partner.io", true},
+		{"https://partner.io", false},
+		{"https://notpartner.io", false},
+		{"http://dash.partner.io", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"", false},
+		{"null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := p.AllowsOrigin(tt.origin); got != tt.want {
+				t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+
+	if !(Policy{AllowedOrigins: []string{"*"}}).AllowsOrigin("https://anything.test") {
+		t.Error("expected * to allow any origin")
+	}
+}
+
+// TestHandler tests response headers for allowed and denied origins, simple and preflight requests.
+func TestHandler(t *testing.T) {
+	p := Policy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowedHeaders:   []string{"Authorization", "Last-Event-ID"},
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	var reached bool
+	h := p.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	tests := []struct {
+		name          string
+		method        string
+		origin        string
+		reqMethod     string
+		reqHeaders    string
+		wantStatus    int
+		wantOrigin    string
+		wantCreds     string
+		wantReached   bool
+		wantMaxAge    string
+		wantExposeHdr string
+	}{
+		{
+			name: "allowed simple request", method: http.MethodGet, origin: "https://app.example.com",
+			wantStatus: http.StatusOK, wantOrigin: "https://app.example.com", wantCreds: "true", wantReached: true, wantExposeHdr: "Retry-After",
+		},
+		{
+			name: "allowed wildcard subdomain", method: http.MethodGet, origin: "https://beta.example.org",
+			wantStatus: http.StatusOK, wantOrigin: "https://beta.example.org", wantCreds: "true", wantReached: true, wantExposeHdr: "Retry-After",
+		},
+		{
+			name: "denied simple request", method: http.MethodGet, origin: "https://evil.test",
+			wantStatus: http.StatusOK, wantReached: true,
+		},
+		{
+			name: "same-origin request", method: http.MethodGet,
+			wantStatus: http.StatusOK, wantReached: true,
+		},
+		{
+			name: "allowed preflight", method: http.MethodOptions, origin: "https://app.example.com", reqMethod: http.MethodGet, reqHeaders: "authorization, last-event-id",
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com", wantCreds: "true", wantMaxAge: "600",
+		},
+		{
+			name: "preflight from denied origin", method: http.MethodOptions, origin: "https://evil.test", reqMethod: http.MethodGet,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "preflight with disallowed method", method: http.MethodOptions, origin: "https://app.example.com", reqMethod: http.MethodDelete,
+			wantStatus: http.StatusForbidden, wantOrigin: "https://app.example.com", wantCreds: "true",
+		},
+		{
+			name: "preflight with disallowed header", method: http.MethodOptions, origin: "https://app.example.com", reqMethod: http.MethodGet, reqHeaders: "X-Custom",
+			wantStatus: http.StatusForbidden, wantOrigin: "https://app.example.com", wantCreds: "true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			req := httptest.NewRequest(tt.method, "/stream", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.reqMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.reqMethod)
+			}
+			if tt.reqHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.reqHeaders)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("expected Allow-Origin %q, got %q", tt.wantOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+				t.Errorf("expected Allow-Credentials %q, got %q", tt.wantCreds, got)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("expected Max-Age %q, got %q", tt.wantMaxAge, got)
+			}
+			if got := w.Header().Get("Access-Control-Expose-Headers"); got != tt.wantExposeHdr {
+				t.Errorf("expected Expose-Headers %q, got %q", tt.wantExposeHdr, got)
+			}
+			if reached != tt.wantReached {
+				t.Errorf("expected next handler reached=%v, got %v", tt.wantReached, reached)
+			}
+		})
+	}
+}
+
+// TestHandlerAnyOrigin tests that a "*" policy answers with the literal wildcard and never allows credentials.
+func TestHandlerAnyOrigin(t *testing.T) {
+	for _, credentials := range []bool{false, true} {
+		p := Policy{AllowedOrigins: []string{"*"}, AllowCredentials: credentials}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://anywhere.test")
+		w := httptest.NewRecorder()
+		p.Handler(http.NotFoundHandler()).ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("credentials %v: expected Allow-Origin *, got %q", credentials, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("credentials %v: expected no Allow-Credentials, got %q", credentials, got)
+		}
+		if err := p.Validate(); (err != nil) != credentials {
+			t.Errorf("credentials %v: unexpected validation result %v", credentials, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/resp/resptest"
+)
+
+// testLease exercises the Lease contract shared by all implementations.
+func testLease(t *testing.T, lease Lease) {
+	ctx := context.Background()
+	ttl := 150 * time.Millisecond
+
+	acquire := func(id string, want bool) {
+		t.Helper()
+		held, err := lease.Acquire(ctx, id, ttl)
+		if err != nil {
+			t.Fatalf("acquire %s: %v", id, err)
+		}
+		if held != want {
+			t.Fatalf("acquire %s: held=%v, want %v", id, held, want)
+		}
+	}
+
+	acquire("a", true)
+	acquire("b", false)
+	acquire("a", true) // renewal
+
+	time.Sleep(ttl + 50*time.Millisecond)
+	acquire("b", true) // a's lease expired
+	acquire("a", false)
+
+	if err := lease.Release(ctx, "a"); err != nil { // releasing someone else's lease is a no-op
+		t.Fatalf("release a: %v", err)
+	}
+	acquire("a", false)
+
+	if err := lease.Release(ctx, "b"); err != nil {
+		t.Fatalf("release b: %v", err)
+	}
+	acquire("a", true)
+}
+
+// TestFileLease tests the shared-file lease.
+func TestFileLease(t *testing.T) {
+	testLease(t, NewFileLease(filepath.Join(t.TempDir(), "leader.lease")))
+}
+
+// TestRedisLease tests the Redis lease against an in-process fake implementing its scripts.
+func TestRedisLease(t *testing.T) {
+	fake := resptest.NewServer(t, "")
+	fake.HandleScript(renewScript, func(db *resptest.DB, keys, args []string) any {
+		if v, ok := db.Get(keys[0]); ok && v == args[0] {
+			ms, _ := time.ParseDuration(args[1] + "ms")
+			db.Expire(keys[0], ms)
+			return int64(1)
+		}
+		return int64(0)
+	})
+	fake.HandleScript(releaseScript, func(db *resptest.DB, keys, args []string) any {
+		if v, ok := db.Get(keys[0]); ok && v == args[0] {
+			db.Del(keys[0])
+			return int64(1)
+		}
+		return int64(0)
+	})
+
+	testLease(t, NewRedisLease(fake.Addr(), "", "injective:leader"))
+}
+
+// flakyLease wraps a Lease and fails every call while broken is set, simulating a lost connection to the store.
+type flakyLease struct {
+	Lease
+	broken atomic.Bool
+}
+
+func (f *flakyLease) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if f.broken.Load() {
+		return false, errors.New("store unreachable")
+	}
+	return f.Lease.Acquire(ctx, id, ttl)
+}
+
+func (f *flakyLease) Release(ctx context.Context, id string) error {
+	if f.broken.Load() {
+		return errors.New("store unreachable")
+	}
+	return f.Lease.Release(ctx, id)
+}
+
+// leadership records when a replica started and stopped leading.
+type leadership struct {
+	started chan time.Time
+	stopped chan time.Time
+}
+
+func newLeadership() *leadership {
+	return &leadership{started: make(chan time.Time, 4), stopped: make(chan time.Time, 4)}
+}
+
+func (l *leadership) lead(ctx context.Context) {
+	l.started <- time.Now()
+	<-ctx.Done()
+	l.stopped <- time.Now()
+}
+
+func mustElector(t *testing.T, lease Lease, id string, ttl time.Duration) *Elector {
+	t.Helper()
+	e, err := NewElector(lease, id, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// start runs the elector in the background and stops it, waiting for it to return, when the test ends.
+func start(t *testing.T, ctx context.Context, e *Elector, lead func(context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx, lead)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, ch <-chan time.Time, within time.Duration, what string) time.Time {
+	t.Helper()
+	select {
+	case at := <-ch:
+		return at
+	case <-time.After(within):
+		t.Fatalf("timed out after %v waiting for %s", within, what)
+		return time.Time{}
+	}
+}
+
+// TestElectorGracefulFailover tests that a follower takes over within one renewal interval when the leader shuts down.
+func TestElectorGracefulFailover(t *testing.T) {
+	ttl := 300 * time.Millisecond
+	lease := NewFileLease(filepath.Join(t.TempDir(), "leader.lease"))
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+
+	a, b := mustElector(t, lease, "a", ttl), mustElector(t, lease, "b", ttl)
+	la, lb := newLeadership(), newLeadership()
+
+	start(t, ctxA, a, la.lead)
+	waitFor(t, la.started, time.Second, "a to lead")
+	start(t, ctxB, b, lb.lead)
+
+	// b must stay a follower while a is healthy.
+	select {
+	case <-lb.started:
+		t.Fatal("b was elected while a held the lease")
+	case <-time.After(2 * ttl):
+	}
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected a to lead, got a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+
+	stopA()
+	stoppedA := waitFor(t, la.stopped, time.Second, "a to step down")
+	startedB := waitFor(t, lb.started, ttl, "b to take over")
+
+	if startedB.Before(stoppedA) {
+		t.Error("b started leading before a stopped")
+	}
+	if gap := startedB.Sub(stoppedA); gap > ttl/3+100*time.Millisecond {
+		t.Errorf("graceful failover took %v, expected about one renewal interval", gap)
+	}
+}
+
+// TestElectorCrashFailover tests failover when the leader loses the lease store:
+// it must step down before its lease expires, and the follower must take over shortly after expiry.
+func TestElectorCrashFailover(t *testing.T) {
+	ttl := 300 * time.Millisecond
+	shared := NewFileLease(filepath.Join(t.TempDir(), "leader.lease"))
+	flaky := &flakyLease{Lease: shared}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := mustElector(t, flaky, "a", ttl), mustElector(t, shared, "b", ttl)
+	la, lb := newLeadership(), newLeadership()
+
+	start(t, ctx, a, la.lead)
+	waitFor(t, la.started, time.Second, "a to lead")
+	start(t, ctx, b, lb.lead)
+
+	brokenAt := time.Now()
+	flaky.broken.Store(true)
+
+	stoppedA := waitFor(t, la.stopped, 2*ttl, "a to step down")
+	startedB := waitFor(t, lb.started, 2*ttl, "b to take over")
+
+	if !stoppedA.Before(startedB) {
+		t.Errorf("leaders overlapped: a stopped at +%v, b started at +%v", stoppedA.Sub(brokenAt), startedB.Sub(brokenAt))
+	}
+	// a renewed at most one interval before the failure, so the lease expires within ttl of it
+	// and b notices within one more interval.
+	if took := startedB.Sub(brokenAt); took > ttl+ttl/3+100*time.Millisecond {
+		t.Errorf("failover took %v, expected at most ttl plus one renewal interval", took)
+	}
+}
+
+// TestElectorLeadReturns tests that a leader whose work stops hands the lease over rather than
+// holding it idle, and campaigns again afterwards.
+func TestElectorLeadReturns(t *testing.T) {
+	ttl := 300 * time.Millisecond
+	lease := NewFileLease(filepath.Join(t.TempDir(), "leader.lease"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := mustElector(t, lease, "a", ttl), mustElector(t, lease, "b", ttl)
+	quit := make(chan time.Time, 1)
+	start(t, ctx, a, func(context.Context) { quit <- time.Now() }) // Gives up at once
+	quitAt := waitFor(t, quit, time.Second, "a to lead")
+
+	lb := newLeadership()
+	start(t, ctx, b, lb.lead)
+	startedB := waitFor(t, lb.started, ttl, "b to take over")
+	if took := startedB.Sub(quitAt); took > ttl/3+100*time.Millisecond {
+		t.Errorf("takeover took %v, expected about one renewal interval", took)
+	}
+	if a.IsLeader() {
+		t.Error("expected a to have stepped down")
+	}
+}
+
+// TestNewElectorTTL tests that TTLs too short to renew are rejected.
+func TestNewElectorTTL(t *testing.T) {
+	lease := NewFileLease(filepath.Join(t.TempDir(), "leader.lease"))
+	for _, ttl := range []time.Duration{0, -time.Second, time.Millisecond} {
+		if _, err := NewElector(lease, "a", ttl); err == nil {
+			t.Errorf("expected ttl %v to be rejected", ttl)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Lease is a time-bound exclusive claim shared between replicas.
+type Lease interface {
+	// Acquire obtains the lease for id, or extends it if id already holds it.
+	// It reports whether id holds the lease afterwards.
+	Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error)
+
+	// Release gives the lease up if id holds it, so a follower can take over without waiting for it to expire.
+	Release(ctx context.Context, id string) error
+}
+
+// Elector campaigns for a Lease and runs a function only while holding it.
+//
+// The lease is renewed every ttl/3. If the lease store reports that another replica holds the lease
+// the elector steps down immediately. If the store is unreachable it keeps leading only while its last
+// renewal is still safely valid (ttl minus one renewal interval, leaving room for clock skew),
+// because no other replica can acquire the lease before it expires.
+type Elector struct {
+	lease      Lease
+	id         string
+	ttl        time.Duration
+	renewEvery time.Duration
+	leader     atomic.Bool
+}
+
+// minTTL keeps renewals far enough apart for a lease store round trip.
+const minTTL = 30 * time.Millisecond
+
+// NewElector returns an elector for id, which must be unique among replicas, holding the lease for ttl at a time.
+func NewElector(lease Lease, id string, ttl time.Duration) (*Elector, error) {
+	if ttl < minTTL {
+		return nil, fmt.Errorf("election ttl %v is too short (at least %v)", ttl, minTTL)
+	}
+	return &Elector{
+		lease:      lease,
+		id:         id,
+		ttl:        ttl,
+		renewEvery: ttl / 3,
+	}, nil
+}
+
+// IsLeader reports whether this replica currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until ctx is cancelled. While this replica leads, lead runs with a context that is
+// cancelled as soon as leadership is lost; Run waits for lead to return before campaigning again,
+// so a replica never runs two leaders' work at once. If lead returns while still leading, the lease
+// is released so that a replica able to do the work can take over, and this one campaigns again
+// from the next renewal. The lease is released when Run returns.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	var (
+		stepDown   context.CancelFunc // Non-nil while leading
+		ended      chan struct{}      // Closed when the current term's lead returns
+		leading    sync.WaitGroup
+		validUntil time.Time
+		resting    bool // Sitting out one renewal after lead returned, so another replica can take over
+		ticker     = time.NewTicker(e.renewEvery)
+	)
+	defer ticker.Stop()
+
+	resign := func(reason string) {
+		if stepDown == nil {
+			return
+		}
+		log.Printf("[%s] stepping down: %s", e.id, reason)
+		stepDown()
+		leading.Wait()
+		stepDown, ended = nil, nil
+		e.leader.Store(false)
+	}
+
+	for {
+		var (
+			held bool
+			err  error
+		)
+		if !resting {
+			held, err = e.lease.Acquire(ctx, e.id, e.ttl)
+		}
+		now := time.Now()
+
+		switch {
+		case ctx.Err() != nil, resting:
+			resting = false
+		case err != nil:
+			log.Printf("[!] [%s] leadership lease unavailable: %v", e.id, err)
+			if stepDown != nil && now.After(validUntil) {
+				resign("lease could not be renewed in time")
+			}
+		case !held:
+			resign("lease held by another replica")
+		default:
+			validUntil = now.Add(e.ttl - e.renewEvery)
+			if stepDown == nil {
+				log.Printf("[%s] elected leader", e.id)
+				var leaderCtx context.Context
+				leaderCtx, stepDown = context.WithCancel(ctx)
+				ended = make(chan struct{})
+				e.leader.Store(true)
+				leading.Add(1)
+				go func(ended chan struct{}) {
+					defer leading.Done()
+					defer close(ended)
+					lead(leaderCtx)
+				}(ended)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := stepDown != nil
+			resign("shutting down")
+			if wasLeader {
+				e.release()
+			}
+			return
+		case <-ended:
+			resign("leader stopped working")
+			e.release()
+			resting = true
+		case <-ticker.C:
+		}
+	}
+}
+
+// release hands the lease back on shutdown, using a fresh context since Run's own is already cancelled.
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.renewEvery)
+	defer cancel()
+	if err := e.lease.Release(ctx, e.id); err != nil {
+		log.Printf("[!] releasing leadership lease: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+// FileLease stores the lease in a file shared by all replicas, e.g. on a common volume.
+// Read-modify-write cycles are serialised with an exclusive advisory lock on the file,
+// and expiry uses the replicas' wall clocks, so their clocks should be roughly in sync.
+type FileLease struct {
+	path string
+}
+
+func NewFileLease(path string) *FileLease {
+	return &FileLease{path: path}
+}
+
+type leaseRecord struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+func (fl *FileLease) Acquire(_ context.Context, id string, ttl time.Duration) (bool, error) {
+	held := false
+	err := fl.update(func(rec *leaseRecord) bool {
+		now := time.Now()
+		if rec.Holder != "" && rec.Holder != id && now.Before(rec.Expires) {
+			return false
+		}
+		*rec = leaseRecord{Holder: id, Expires: now.Add(ttl)}
+		held = true
+		return true
+	})
+	return held, err
+}
+
+func (fl *FileLease) Release(_ context.Context, id string) error {
+	return fl.update(func(rec *leaseRecord) bool {
+		if rec.Holder != id {
+			return false
+		}
+		*rec = leaseRecord{}
+		return true
+	})
+}
+
+// update locks the file, passes its record to fn and writes it back if fn reports a change.
+func (fl *FileLease) update(fn func(rec *leaseRecord) bool) error {
+	f, err := os.OpenFile(fl.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	unlock, err := lockFile(f)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var rec leaseRecord
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(raw) > 0 {
+		// A corrupt file is treated as a free lease rather than blocking election forever.
+		_ = json.Unmarshal(raw, &rec)
+	}
+
+	if !fn(&rec) {
+		return nil
+	}
+
+	raw, err = json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(raw, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile is unavailable without flock; use the Redis lease on these platforms.
+func lockFile(*os.File) (func(), error) {
+	return nil, errors.New("election: file leases require a unix platform")
+}
@@ -0,0 +1,80 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/resp"
+)
+
+const redisLeaseTimeout = 3 * time.Second
+
+// Lua scripts make check-and-modify atomic so a replica can only extend or delete its own lease.
+const (
+	renewScript   = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// RedisLease stores the lease as a Redis key holding the leader's ID.
+// The lease is taken with SET NX PX (SETNX with an expiry), so it disappears by itself if the leader dies.
+type RedisLease struct {
+	addr     string
+	password string
+	key      string
+
+	conn  *resp.Conn
+	mutex sync.Mutex
+}
+
+func NewRedisLease(addr, password, key string) *RedisLease {
+	return &RedisLease{addr: addr, password: password, key: key}
+}
+
+func (rl *RedisLease) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	ms := strconv.FormatInt(ttl.Milliseconds(), 10)
+
+	reply, err := rl.do(ctx, "SET", rl.key, id, "NX", "PX", ms)
+	if err != nil {
+		return false, err
+	}
+	if reply == "OK" {
+		return true, nil
+	}
+
+	// The key exists; extend it if it is ours.
+	reply, err = rl.do(ctx, "EVAL", renewScript, "1", rl.key, id, ms)
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+func (rl *RedisLease) Release(ctx context.Context, id string) error {
+	_, err := rl.do(ctx, "EVAL", releaseScript, "1", rl.key, id)
+	return err
+}
+
+// do runs a command on the shared connection, dialling it on first use or after a transport error.
+func (rl *RedisLease) do(ctx context.Context, args ...string) (any, error) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	if rl.conn == nil {
+		conn, err := resp.Dial(ctx, rl.addr, rl.password)
+		if err != nil {
+			return nil, err
+		}
+		rl.conn = conn
+	}
+
+	reply, err := rl.conn.Do(redisLeaseTimeout, args...)
+	var replyErr resp.Error
+	if err != nil && !errors.As(err, &replyErr) {
+		rl.conn.Close()
+		rl.conn = nil
+	}
+	return reply, err
+}
@@ -0,0 +1,116 @@
+// Package enrich adds change statistics to price updates before they are published.
+package enrich
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Reference windows for the change fields.
+const (
+	hour = time.Hour
+	day  = 24 * time.Hour
+)
+
+// Enricher remembers recent prices per instrument to compute each update's change versus the
+// previous update and versus an hour and a day ago. Older prices are kept at one-minute resolution.
+// It is safe for concurrent use.
+type Enricher struct {
+	instruments map[string]*series
+	mutex       sync.Mutex
+}
+
+// series is an instrument's price history.
+type series struct {
+	last    sample
+	samples []sample // The last price of each minute, oldest first, reaching back just over a day
+}
+
+type sample struct {
+	at    time.Time
+	price float64
+}
+
+func New() *Enricher {
+	return &Enricher{instruments: make(map[string]*series)}
+}
+
+// Enrich fills in the change fields of an update about to be published and remembers its price.
+func (e *Enricher) Enrich(u *models.PriceUpdate) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if s := e.instruments[u.Instrument]; s != nil {
+		if !s.last.at.IsZero() {
+			u.Change = models.NewChange(s.last.price, u.Price)
+		}
+		if from, ok := s.at(u.Timestamp.Add(-hour)); ok {
+			u.Change1h = models.NewChange(from, u.Price)
+		}
+		if from, ok := s.at(u.Timestamp.Add(-day)); ok {
+			u.Change24h = models.NewChange(from, u.Price)
+		}
+	}
+	e.record(*u)
+}
+
+// Observe remembers the price of a published update, e.g. one received from another instance,
+// so changes stay continuous when this instance takes over publishing.
+func (e *Enricher) Observe(u models.PriceUpdate) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.record(u)
+}
+
+// PriceAt returns the instrument's last known price at or before t, at one-minute resolution except
+// for the latest price. It reports false when the history doesn't reach back that far.
+func (e *Enricher) PriceAt(instrument string, t time.Time) (float64, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if s := e.instruments[instrument]; s != nil {
+		return s.at(t)
+	}
+	return 0, false
+}
+
+func (e *Enricher) record(u models.PriceUpdate) {
+	s := e.instruments[u.Instrument]
+	if s == nil {
+		s = &series{}
+		e.instruments[u.Instrument] = s
+	}
+	// Updates we enriched ourselves come back from the bus; anything not newer is already known.
+	if !u.Timestamp.After(s.last.at) {
+		return
+	}
+	s.last = sample{u.Timestamp, u.Price}
+
+	if n := len(s.samples); n > 0 && s.samples[n-1].at.Truncate(time.Minute).Equal(u.Timestamp.Truncate(time.Minute)) {
+		s.samples[n-1] = s.last
+	} else {
+		s.samples = append(s.samples, s.last)
+	}
+
+	// Keep one sample at or before a day ago, so the 24h reference stays available.
+	cutoff := u.Timestamp.Add(-day)
+	drop := 0
+	for drop+1 < len(s.samples) && !s.samples[drop+1].at.After(cutoff) {
+		drop++
+	}
+	s.samples = s.samples[drop:]
+}
+
+// at returns the last known price at or before t. It reports false if the history doesn't reach back that far.
+func (s *series) at(t time.Time) (float64, bool) {
+	var price float64
+	found := false
+	for _, smp := range s.samples {
+		if smp.at.After(t) {
+			break
+		}
+		price, found = smp.price, true
+	}
+	return price, found
+}
@@ -0,0 +1,106 @@
+package enrich
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+var start = time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)
+
+func update(at time.Duration, price float64) *models.PriceUpdate {
+	return &models.PriceUpdate{Timestamp: start.Add(at), Price: price, Instrument: "BTC-USD"}
+}
+
+// TestEnrichChanges tests the change versus the previous update, an hour ago and a day ago.
+func TestEnrichChanges(t *testing.T) {
+	e := New()
+
+	first := update(0, 100)
+	e.Enrich(first)
+	if first.Change != nil || first.Change1h != nil || first.Change24h != nil {
+		t.Fatalf("expected no changes without history, got %+v", first)
+	}
+
+	second := update(5*time.Second, 110)
+	e.Enrich(second)
+	if c := second.Change; c == nil || c.From != 100 || c.Abs != 10 || c.Pct != 10 {
+		t.Errorf("unexpected tick change %+v", c)
+	}
+	if second.Change1h != nil {
+		t.Errorf("expected no 1h change before an hour of history, got %+v", second.Change1h)
+	}
+
+	// Prices every 5 minutes for a day.
+	for at := 5 * time.Minute; at <= day; at += 5 * time.Minute {
+		e.Enrich(update(at, 100+at.Hours()))
+	}
+
+	last := update(day+30*time.Second, 150)
+	e.Enrich(last)
+	if c := last.Change; c == nil || c.From != 124 {
+		t.Errorf("expected change from the previous update at 124, got %+v", c)
+	}
+	if c := last.Change1h; c == nil || c.From != 123 {
+		t.Errorf("expected 1h change from the price at 23:00, got %+v", c)
+	}
+	if c := last.Change24h; c == nil || c.From != 110 || math.Abs(c.Pct-36.3636) > 0.001 {
+		t.Errorf("expected 24h change from the last price at midnight, got %+v", c)
+	}
+}
+
+// TestObserve tests that updates published elsewhere feed the history and duplicates are ignored.
+func TestObserve(t *testing.T) {
+	e := New()
+	e.Observe(*update(0, 100))
+	e.Observe(*update(time.Second, 101))
+
+	ours := update(2*time.Second, 103)
+	e.Enrich(ours)
+	e.Observe(*ours)                    // our own update coming back from the bus
+	e.Observe(*update(time.Second, 90)) // stale
+
+	next := update(3*time.Second, 104)
+	e.Enrich(next)
+	if next.Change == nil || next.Change.From != 103 {
+		t.Errorf("expected change from 103, got %+v", next.Change)
+	}
+}
+
+// TestHistoryIsTrimmed tests that samples older than a day are dropped.
+func TestHistoryIsTrimmed(t *testing.T) {
+	e := New()
+	for at := time.Duration(0); at <= 3*day; at += 30 * time.Second {
+		e.Enrich(update(at, 100))
+	}
+	if n := len(e.instruments["BTC-USD"].samples); n > 24*60+2 {
+		t.Errorf("expected about a day of minute samples, got %d", n)
+	}
+}
+
+// TestPriceAt tests looking prices up by time.
+func TestPriceAt(t *testing.T) {
+	e := New()
+	e.Observe(*update(0, 100))
+	e.Observe(*update(10*time.Minute, 110))
+	e.Observe(*update(10*time.Minute+5*time.Second, 111))
+
+	for _, tt := range []struct {
+		at   time.Duration
+		want float64
+		ok   bool
+	}{
+		{-time.Second, 0, false},
+		{5 * time.Minute, 100, true},
+		{time.Hour, 111, true},
+	} {
+		if got, ok := e.PriceAt("BTC-USD", start.Add(tt.at)); got != tt.want || ok != tt.ok {
+			t.Errorf("PriceAt(+%v) = %v, %v; want %v, %v", tt.at, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := e.PriceAt("ETH-USD", start); ok {
+		t.Error("expected no price for an unknown instrument")
+	}
+}
@@ -0,0 +1,240 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+)
+
+// Type is the type of an expression.
+type Type int
+
+const (
+	invalid Type = iota
+	Bool
+	Number
+	Percent  // Written 3%, returned by change; kept apart from numbers so 3 and 3% can't be mixed up
+	Duration // Written 15m or 1h, only as the argument of change
+	String
+)
+
+func (t Type) String() string {
+	return [...]string{"invalid", "condition", "number", "percentage", "duration", "string"}[t]
+}
+
+// maxChangeWindow is how far back change can look, bounded by the price history kept for it.
+const maxChangeWindow = 24 * time.Hour
+
+// fields are the update fields expressions can refer to by name.
+var fields = map[string]Type{
+	"price":      Number,
+	"bid":        Number,
+	"ask":        Number,
+	"volume":     Number,
+	"spread":     Number, // Basis points between the highest and lowest provider price
+	"instrument": String,
+}
+
+// checker resolves names and types, collecting the indicators the expression needs.
+type checker struct {
+	specs []indicators.Spec
+}
+
+func (c *checker) check(n node) (Type, error) {
+	t, err := c.typeOf(n)
+	n.base().typ = t
+	return t, err
+}
+
+func (c *checker) typeOf(n node) (Type, error) {
+	switch n := n.(type) {
+	case *literal:
+		if n.typ == Duration {
+			return 0, errorf(n.pos, "durations are only allowed as the argument of change, e.g. change(1h)")
+		}
+		return n.typ, nil
+
+	case *name:
+		if t, ok := fields[n.name]; ok {
+			return t, nil
+		}
+		if _, ok := functions[n.name]; ok {
+			return 0, errorf(n.pos, "%s is a function, e.g. %s", n.name, functions[n.name].example)
+		}
+		return 0, errorf(n.pos, "unknown name %q (expected one of price, bid, ask, volume, spread or instrument)", n.name)
+
+	case *unary:
+		t, err := c.check(n.x)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case n.op == "not" && t != Bool:
+			return 0, errorf(n.x.base().pos, "not needs a condition, found a %s", t)
+		case n.op == "-" && t != Number && t != Percent:
+			return 0, errorf(n.x.base().pos, "can't negate a %s", t)
+		}
+		return t, nil
+
+	case *binary:
+		x, err := c.check(n.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := c.check(n.y)
+		if err != nil {
+			return 0, err
+		}
+		return binaryType(n, x, y)
+
+	case *call:
+		return c.call(n)
+	}
+	panic(fmt.Sprintf("expr: unexpected node %T", n))
+}
+
+func binaryType(n *binary, x, y Type) (Type, error) {
+	switch n.op {
+	case "and", "or":
+		if x != Bool {
+			return 0, errorf(n.x.base().pos, "%s needs conditions on both sides, found a %s", n.op, x)
+		}
+		if y != Bool {
+			return 0, errorf(n.y.base().pos, "%s needs conditions on both sides, found a %s", n.op, y)
+		}
+		return Bool, nil
+
+	case "<", "<=", ">", ">=", "==", "!=":
+		if x != y {
+			hint := ""
+			if (x == Number && y == Percent) || (x == Percent && y == Number) {
+				hint = " (change returns a percentage: compare it with 3%, not 3)"
+			}
+			return 0, errorf(n.pos, "can't compare a %s with a %s%s", x, y, hint)
+		}
+		if (x == Bool || x == String) && n.op != "==" && n.op != "!=" {
+			return 0, errorf(n.pos, "%ss can only be compared with == or !=", x)
+		}
+		return Bool, nil
+
+	case "+", "-":
+		if x != y || (x != Number && x != Percent) {
+			return 0, errorf(n.pos, "can't %s a %s and a %s", verb(n.op), x, y)
+		}
+		return x, nil
+
+	case "*", "/":
+		switch {
+		case x == Number && y == Number:
+			return Number, nil
+		case x == Percent && y == Number, x == Number && y == Percent && n.op == "*":
+			return Percent, nil // Scaling a percentage
+		}
+		return 0, errorf(n.pos, "can't %s a %s and a %s", verb(n.op), x, y)
+	}
+	panic("expr: unexpected operator " + n.op)
+}
+
+func verb(op string) string {
+	return map[string]string{"+": "add", "-": "subtract", "*": "multiply", "/": "divide"}[op]
+}
+
+// function describes a built-in function.
+type function struct {
+	example string
+	args    []Type // Argument types; the last ones may be left out down to minArgs
+	minArgs int
+	result  Type
+}
+
+var functions = map[string]function{
+	"sma":       {"sma(20)", []Type{Number}, 1, Number},
+	"ema":       {"ema(50)", []Type{Number}, 1, Number},
+	"rsi":       {"rsi(14)", []Type{Number}, 1, Number},
+	"vwap":      {"vwap(100)", []Type{Number}, 1, Number},
+	"bb_upper":  {"bb_upper(20) or bb_upper(20, 2.5)", []Type{Number, Number}, 1, Number},
+	"bb_middle": {"bb_middle(20)", []Type{Number}, 1, Number},
+	"bb_lower":  {"bb_lower(20) or bb_lower(20, 2.5)", []Type{Number, Number}, 1, Number},
+	"change":    {"change(1h), or change() for the previous update", []Type{Duration}, 0, Percent},
+	"abs":       {"abs(change(1h))", []Type{invalid}, 1, invalid},
+	"min":       {"min(bid, ask)", []Type{invalid, invalid}, 2, invalid},
+	"max":       {"max(bid, ask)", []Type{invalid, invalid}, 2, invalid},
+}
+
+func (c *checker) call(n *call) (Type, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		if _, field := fields[n.name]; field {
+			return 0, errorf(n.pos, "%s is not a function; write it without parentheses", n.name)
+		}
+		return 0, errorf(n.pos, "unknown function %q", n.name)
+	}
+	if len(n.args) < fn.minArgs || len(n.args) > len(fn.args) {
+		return 0, errorf(n.pos, "wrong number of arguments to %s, e.g. %s", n.name, fn.example)
+	}
+
+	// abs, min and max work on numbers or percentages, returning the same type.
+	if fn.result == invalid {
+		var t Type
+		for _, arg := range n.args {
+			at, err := c.check(arg)
+			if err != nil {
+				return 0, err
+			}
+			if at != Number && at != Percent {
+				return 0, errorf(arg.base().pos, "%s needs numbers or percentages, found a %s", n.name, at)
+			}
+			if t != invalid && at != t {
+				return 0, errorf(arg.base().pos, "%s can't mix a %s and a %s", n.name, t, at)
+			}
+			t = at
+		}
+		return t, nil
+	}
+
+	// The other functions take constant arguments, so indicators and windows are known up front.
+	consts := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		lit, ok := arg.(*literal)
+		if !ok || lit.typ != fn.args[i] {
+			return 0, errorf(arg.base().pos, "%s takes a constant %s, e.g. %s", n.name, fn.args[i], fn.example)
+		}
+		consts[i] = lit.num
+	}
+
+	switch n.name {
+	case "change":
+		if len(consts) == 1 {
+			n.window = time.Duration(consts[0])
+			if n.window <= 0 || n.window > maxChangeWindow {
+				return 0, errorf(n.args[0].base().pos, "change looks back at most %v", maxChangeWindow)
+			}
+		}
+		return fn.result, nil
+	case "bb_upper", "bb_middle", "bb_lower":
+		n.band = strings.TrimPrefix(n.name, "bb_")
+		n.spec = indicators.Spec{Kind: "bb", K: 2}
+		if len(consts) == 2 {
+			n.spec.K = consts[1]
+			if n.spec.K <= 0 || math.IsInf(n.spec.K, 0) {
+				return 0, errorf(n.args[1].base().pos, "band width must be positive")
+			}
+		}
+	default:
+		n.spec = indicators.Spec{Kind: n.name}
+	}
+
+	period := consts[0]
+	if period != math.Trunc(period) || period < 1 || period > indicators.MaxPeriod {
+		return 0, errorf(n.args[0].base().pos, "period must be a whole number between 1 and %d", indicators.MaxPeriod)
+	}
+	n.spec.Period = int(period)
+	if !slices.Contains(c.specs, n.spec) {
+		c.specs = append(c.specs, n.spec)
+	}
+	return fn.result, nil
+}
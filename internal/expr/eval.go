@@ -0,0 +1,169 @@
+package expr
+
+import (
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/spread"
+)
+
+// Env supplies what expressions refer to beyond the update itself.
+type Env interface {
+	// Indicator returns the current reading of an indicator, reporting false while it warms up.
+	Indicator(instrument string, spec indicators.Spec) (indicators.Value, bool)
+	// PriceAt returns the instrument's last price at or before t, reporting false beyond the history kept.
+	PriceAt(instrument string, t time.Time) (float64, bool)
+}
+
+// value is the result of evaluating a node; only the field for its type is set.
+type value struct {
+	num float64
+	str string
+	b   bool
+}
+
+type evaluator struct {
+	u   models.PriceUpdate
+	env Env
+}
+
+// eval computes n. It reports false when the value is unknown: a missing field, an indicator still
+// warming up, history that doesn't reach back far enough, or a division by zero.
+func (e *evaluator) eval(n node) (value, bool) {
+	switch n := n.(type) {
+	case *literal:
+		return value{num: n.num, str: n.str, b: n.str == "true"}, true
+
+	case *name:
+		return e.field(n.name)
+
+	case *unary:
+		x, ok := e.eval(n.x)
+		if n.op == "not" {
+			return value{b: !x.b}, ok
+		}
+		return value{num: -x.num}, ok
+
+	case *binary:
+		return e.binary(n)
+
+	case *call:
+		return e.call(n)
+	}
+	panic("expr: unexpected node")
+}
+
+func (e *evaluator) field(name string) (value, bool) {
+	var v float64
+	switch name {
+	case "instrument":
+		return value{str: e.u.Instrument}, true
+	case "price":
+		v = e.u.Price
+	case "bid":
+		v = e.u.Bid
+	case "ask":
+		v = e.u.Ask
+	case "volume":
+		v = e.u.Volume
+	case "spread":
+		// A single provider can't disagree with itself, which isn't a zero spread but an unknown one.
+		sp, ok := spread.Of(e.u)
+		return value{num: sp.SpreadBps}, ok
+	}
+	// Zero means the provider didn't report the field.
+	return value{num: v}, v != 0
+}
+
+func (e *evaluator) binary(n *binary) (value, bool) {
+	// and/or follow three-valued logic: false and unknown is false, true or unknown is true.
+	switch n.op {
+	case "and", "or":
+		want := n.op == "or" // The value that decides the result on its own
+		x, xok := e.eval(n.x)
+		if xok && x.b == want {
+			return value{b: want}, true
+		}
+		y, yok := e.eval(n.y)
+		if yok && y.b == want {
+			return value{b: want}, true
+		}
+		return value{b: !want}, xok && yok
+	}
+
+	x, xok := e.eval(n.x)
+	y, yok := e.eval(n.y)
+	if !xok || !yok {
+		return value{}, false
+	}
+	switch n.op {
+	case "<":
+		return value{b: x.num < y.num}, true
+	case "<=":
+		return value{b: x.num <= y.num}, true
+	case ">":
+		return value{b: x.num > y.num}, true
+	case ">=":
+		return value{b: x.num >= y.num}, true
+	case "==":
+		return value{b: x == y}, true
+	case "!=":
+		return value{b: x != y}, true
+	case "+":
+		return value{num: x.num + y.num}, true
+	case "-":
+		return value{num: x.num - y.num}, true
+	case "*":
+		return value{num: x.num * y.num}, true
+	case "/":
+		if y.num == 0 {
+			return value{}, false
+		}
+		return value{num: x.num / y.num}, true
+	}
+	panic("expr: unexpected operator " + n.op)
+}
+
+func (e *evaluator) call(n *call) (value, bool) {
+	switch n.name {
+	case "abs", "min", "max":
+		x, ok := e.eval(n.args[0])
+		if !ok {
+			return value{}, false
+		}
+		if n.name == "abs" {
+			return value{num: max(x.num, -x.num)}, true
+		}
+		y, ok := e.eval(n.args[1])
+		if !ok {
+			return value{}, false
+		}
+		if n.name == "min" {
+			return value{num: min(x.num, y.num)}, true
+		}
+		return value{num: max(x.num, y.num)}, true
+
+	case "change":
+		if n.window == 0 {
+			if e.u.Change == nil {
+				return value{}, false
+			}
+			return value{num: e.u.Change.Pct}, true
+		}
+		from, ok := e.env.PriceAt(e.u.Instrument, e.u.Timestamp.Add(-n.window))
+		if !ok || from == 0 {
+			return value{}, false
+		}
+		return value{num: (e.u.Price - from) / from * 100}, true
+	}
+
+	v, ok := e.env.Indicator(e.u.Instrument, n.spec)
+	switch n.band {
+	case "upper":
+		return value{num: v.Upper}, ok
+	case "lower":
+		return value{num: v.Lower}, ok
+	}
+	return value{num: v.Value}, ok
+}
@@ -0,0 +1,87 @@
+// Package expr implements the condition language used by alert rules and ?where= stream filters,
+// e.g. `price > ema(50) and change(1h) < -3%`.
+//
+// Expressions combine the update's fields (price, bid, ask, volume, spread, instrument), indicators
+// (sma, ema, rsi, vwap, bb_upper, bb_middle, bb_lower), percentage changes (change(1h), or change()
+// versus the previous update) and abs, min and max, with arithmetic, comparisons and and/or/not.
+// Percentages are a type of their own, written 3%, so they can't be confused with prices.
+// Values that aren't known yet, such as an indicator still warming up, make a condition false
+// unless the rest of it decides the result anyway.
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// MaxLength bounds the length of an expression's source.
+const MaxLength = 1024
+
+// Error is a syntax or type error at a byte offset in the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Show returns the error under the offending line of src, with a caret at the position.
+func (e *Error) Show(src string) string {
+	return fmt.Sprintf("%s\n%s^\n%s", src, strings.Repeat(" ", e.Pos), e.Error())
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Program is a checked condition, ready to evaluate. It is safe for concurrent use.
+type Program struct {
+	src   string
+	root  node
+	specs []indicators.Spec
+}
+
+// Compile parses and type-checks a condition. Errors are *Error.
+func Compile(src string) (*Program, error) {
+	if len(src) > MaxLength {
+		return nil, errorf(MaxLength, "expression longer than %d characters", MaxLength)
+	}
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{}
+	t, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+	if t != Bool {
+		return nil, errorf(start(root), "expression is a %s, not a condition; compare it, e.g. price > 100", t)
+	}
+	return &Program{src: src, root: root, specs: c.specs}, nil
+}
+
+// start returns where n begins in the source, which for binary expressions is left of the operator.
+func start(n node) int {
+	if b, ok := n.(*binary); ok {
+		return start(b.x)
+	}
+	return n.base().pos
+}
+
+func (p *Program) String() string { return p.src }
+
+// Indicators returns the indicators the condition refers to, which env must be able to supply.
+func (p *Program) Indicators() []indicators.Spec { return p.specs }
+
+// Eval reports whether the condition holds for u. Unknown counts as false.
+func (p *Program) Eval(u models.PriceUpdate, env Env) bool {
+	e := &evaluator{u: u, env: env}
+	v, ok := e.eval(p.root)
+	return ok && v.b
+}
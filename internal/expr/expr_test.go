@@ -0,0 +1,150 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+var now = time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+
+// env serves fixed indicator readings and an hour-old price.
+type env struct {
+	readings map[string]indicators.Value
+	hourAgo  float64
+}
+
+func (e env) Indicator(_ string, spec indicators.Spec) (indicators.Value, bool) {
+	v, ok := e.readings[spec.String()]
+	return v, ok
+}
+
+func (e env) PriceAt(_ string, t time.Time) (float64, bool) {
+	if now.Sub(t) > time.Hour || e.hourAgo == 0 {
+		return 0, false
+	}
+	return e.hourAgo, true
+}
+
+// TestEval tests evaluation against an update, indicators and history.
+func TestEval(t *testing.T) {
+	u := models.PriceUpdate{Timestamp: now, Price: 96, Bid: 95.9, Ask: 96.1, Instrument: "BTC-USD", Change: models.NewChange(100, 96)}
+	e := env{
+		readings: map[string]indicators.Value{
+			"ema:50":    {Value: 90},
+			"rsi:14":    {Value: 25},
+			"bb:20:2.5": {Value: 98, Upper: 104, Lower: 97},
+		},
+		hourAgo: 100,
+	}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"price > ema(50) and change(1h) < -3%", true},
+		{"price > ema(50) and change(1h) < -5%", false},
+		{"change() == -4%", true},
+		{"abs(change(1h)) >= 4%", true},
+		{"rsi(14) < 30 or price > 1000", true},
+		{"price < bb_lower(20, 2.5)", true},
+		{"not (price < bb_lower(20, 2.5))", false},
+		{"(ask - bid) / price * 10000 < 25", true},
+		{"max(bid, ask) == ask && instrument == \"BTC-USD\"", true},
+		{"instrument != \"ETH-USD\" and -price < 0", true},
+		{"change(1h) * 2 == -8%", true},
+		// Unknown values: ema(20) is warming up, there's no volume and no history a day back.
+		{"price > ema(20)", false},
+		{"not (price > ema(20))", false},
+		{"price > ema(20) or price > 50", true},
+		{"price > ema(20) and price > 1000", false},
+		{"volume > 0", false},
+		{"spread >= 0", false},
+		{"change(24h) < 0%", false},
+		{"price / 0 > 1", false},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.src, err)
+			continue
+		}
+		if got := p.Eval(u, e); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestEvalSpread tests the spread between providers of a merged update.
+func TestEvalSpread(t *testing.T) {
+	p, err := Compile("spread > 25 and price > 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := models.PriceUpdate{Price: 100, Providers: map[string]float64{"a": 99.8, "b": 100.1}}
+	if !p.Eval(u, env{}) {
+		t.Error("expected a 30 bps spread to exceed 25")
+	}
+	u.Providers["b"] = 100
+	if p.Eval(u, env{}) {
+		t.Error("expected a 20 bps spread not to exceed 25")
+	}
+}
+
+// TestCompileErrors tests that syntax and type errors point at the offending position.
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+	}{
+		{"price > ", 8},
+		{"price >> 1", 7},
+		{"price = 1", 6},
+		{"price > emaa(50)", 8},
+		{"change(1h) < -3", 11},
+		{"price > ema(x)", 12},
+		{"price > ema(0)", 12},
+		{"price > ema(20.5)", 12},
+		{"price + 1", 0},
+		{"1 < price < 2", 10},
+		{"price > 1 and bid", 14},
+		{"change(48h) > 1%", 7},
+		{"price > 1h", 8},
+		{"(price > 1", 10},
+		{"instrument < \"A\"", 11},
+		{"pricey > 1", 0},
+		{"price(1) > 1", 0},
+		{"\"open", 0},
+		{"price > 1 #", 10},
+		{"min(price, 1%) > 0", 11},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Compile(%q): expected an *Error, got %v", tt.src, err)
+			continue
+		}
+		if e.Pos != tt.pos {
+			t.Errorf("Compile(%q): error at %d, want %d:\n%s", tt.src, e.Pos, tt.pos, e.Show(tt.src))
+		}
+	}
+}
+
+// TestIndicators tests that compiled programs list the indicators they need once each.
+func TestIndicators(t *testing.T) {
+	p, err := Compile("ema(50) > sma(20) and price > EMA(50) and bb_upper(20) > bb_lower(20, 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range p.Indicators() {
+		names = append(names, s.String())
+	}
+	if len(names) != 4 || names[0] != "ema:50" || names[1] != "sma:20" || names[2] != "bb:20" || names[3] != "bb:20:3" {
+		t.Errorf("unexpected indicators %v", names)
+	}
+}
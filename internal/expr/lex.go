@@ -0,0 +1,127 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokPercent
+	tokDuration
+	tokString
+	tokIdent
+	tokOp // Operators and punctuation, including the keywords and, or and not
+)
+
+type token struct {
+	kind tokenKind
+	pos  int // Byte offset in the source
+	text string
+	num  float64 // Numbers, percentages and durations (in nanoseconds)
+	str  string  // Unquoted strings
+}
+
+// lex splits src into tokens, ending with tokEOF.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			num := src[start:i]
+			// Letters straight after a number make it a duration, e.g. 15m or 1h30m.
+			if i < len(src) && isLetter(src[i]) {
+				for i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '.') {
+					i++
+				}
+				d, err := time.ParseDuration(src[start:i])
+				if err != nil {
+					return nil, errorf(start, "invalid duration %q (use units such as 30s, 15m or 1h)", src[start:i])
+				}
+				tokens = append(tokens, token{kind: tokDuration, pos: start, text: src[start:i], num: float64(d)})
+				continue
+			}
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return nil, errorf(start, "invalid number %q", num)
+			}
+			if i < len(src) && src[i] == '%' {
+				i++
+				tokens = append(tokens, token{kind: tokPercent, pos: start, text: src[start:i], num: v})
+				continue
+			}
+			tokens = append(tokens, token{kind: tokNumber, pos: start, text: num, num: v})
+
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			word := src[start:i]
+			switch lower := strings.ToLower(word); lower {
+			case "and", "or", "not":
+				tokens = append(tokens, token{kind: tokOp, pos: start, text: lower})
+			default:
+				tokens = append(tokens, token{kind: tokIdent, pos: start, text: lower})
+			}
+
+		case c == '"':
+			start := i
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(src) {
+				return nil, errorf(start, "unterminated string")
+			}
+			i++
+			s, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, errorf(start, "invalid string %s", src[start:i])
+			}
+			tokens = append(tokens, token{kind: tokString, pos: start, text: src[start:i], str: s})
+
+		default:
+			op, spelling := "", ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o.spelling) {
+					op, spelling = o.op, o.spelling
+					break
+				}
+			}
+			if op == "" {
+				if c == '=' {
+					return nil, errorf(i, "unexpected '=' (use == to compare)")
+				}
+				return nil, errorf(i, "unexpected %q", []rune(src[i:])[0])
+			}
+			tokens = append(tokens, token{kind: tokOp, pos: i, text: op})
+			i += len(spelling)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// operators lists symbols by spelling, longest first so "<=" isn't read as "<".
+// The C-style forms of and, or and not are accepted too.
+var operators = []struct{ spelling, op string }{
+	{"&&", "and"}, {"||", "or"}, {"<=", "<="}, {">=", ">="}, {"==", "=="}, {"!=", "!="},
+	{"<", "<"}, {">", ">"}, {"!", "not"}, {"+", "+"}, {"-", "-"}, {"*", "*"}, {"/", "/"},
+	{"(", "("}, {")", ")"}, {",", ","},
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
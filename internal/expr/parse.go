@@ -0,0 +1,229 @@
+package expr
+
+import (
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+)
+
+// Grammar, loosest binding first:
+//
+//	or         = and { "or" and }
+//	and        = not { "and" not }
+//	not        = "not" not | comparison
+//	comparison = sum [ ("<" | "<=" | ">" | ">=" | "==" | "!=") sum ]
+//	sum        = product { ("+" | "-") product }
+//	product    = unary { ("*" | "/") unary }
+//	unary      = "-" unary | primary
+//	primary    = number | percent | duration | string | name | name "(" [ or { "," or } ] ")" | "(" or ")"
+
+// node is an expression in the syntax tree. The checker fills in each node's type.
+type node interface {
+	base() *nodeBase
+}
+
+type nodeBase struct {
+	pos int
+	typ Type
+}
+
+func (n *nodeBase) base() *nodeBase { return n }
+
+type (
+	literal struct {
+		nodeBase
+		num float64
+		str string
+	}
+	name struct {
+		nodeBase
+		name string
+	}
+	unary struct {
+		nodeBase
+		op string
+		x  node
+	}
+	binary struct {
+		nodeBase
+		op   string
+		x, y node
+	}
+	call struct {
+		nodeBase
+		name string
+		args []node
+
+		// Resolved by the checker
+		spec   indicators.Spec // Indicator functions
+		band   string          // Bollinger functions: upper, middle or lower
+		window time.Duration   // change
+	}
+)
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// parse builds the syntax tree of src.
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.pos, "unexpected %s", describe(tok))
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.next] }
+
+// accept consumes the next token if it is one of the given operators.
+func (p *parser) accept(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.next++
+			return tok, true
+		}
+	}
+	return tok, false
+}
+
+// binaryLevel parses operands joined by left-associative operators.
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{nodeBase: nodeBase{pos: tok.pos}, op: tok.text, x: x, y: y}
+	}
+}
+
+func (p *parser) or() (node, error)      { return p.binaryLevel(p.and, "or") }
+func (p *parser) and() (node, error)     { return p.binaryLevel(p.not, "and") }
+func (p *parser) sum() (node, error)     { return p.binaryLevel(p.product, "+", "-") }
+func (p *parser) product() (node, error) { return p.binaryLevel(p.unary, "*", "/") }
+
+func (p *parser) not() (node, error) {
+	if tok, ok := p.accept("not"); ok {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{nodeBase: nodeBase{pos: tok.pos}, op: "not", x: x}, nil
+	}
+	return p.comparison()
+}
+
+// comparison doesn't chain: a < b < c is an error rather than a surprise.
+func (p *parser) comparison() (node, error) {
+	x, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	ops := []string{"<", "<=", ">", ">=", "==", "!="}
+	tok, ok := p.accept(ops...)
+	if !ok {
+		return x, nil
+	}
+	y, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if next, chained := p.accept(ops...); chained {
+		return nil, errorf(next.pos, "comparisons can't be chained; join them with and")
+	}
+	return &binary{nodeBase: nodeBase{pos: tok.pos}, op: tok.text, x: x, y: y}, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if tok, ok := p.accept("-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{nodeBase: nodeBase{pos: tok.pos}, op: "-", x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber:
+		p.next++
+		return &literal{nodeBase: nodeBase{pos: tok.pos, typ: Number}, num: tok.num}, nil
+	case tokPercent:
+		p.next++
+		return &literal{nodeBase: nodeBase{pos: tok.pos, typ: Percent}, num: tok.num}, nil
+	case tokDuration:
+		p.next++
+		return &literal{nodeBase: nodeBase{pos: tok.pos, typ: Duration}, num: tok.num}, nil
+	case tokString:
+		p.next++
+		return &literal{nodeBase: nodeBase{pos: tok.pos, typ: String}, str: tok.str}, nil
+	case tokIdent:
+		p.next++
+		if tok.text == "true" || tok.text == "false" {
+			return &literal{nodeBase: nodeBase{pos: tok.pos, typ: Bool}, str: tok.text}, nil
+		}
+		if _, ok := p.accept("("); !ok {
+			return &name{nodeBase: nodeBase{pos: tok.pos}, name: tok.text}, nil
+		}
+		c := &call{nodeBase: nodeBase{pos: tok.pos}, name: tok.text}
+		if _, ok := p.accept(")"); ok {
+			return c, nil
+		}
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+			if _, ok := p.accept(")"); ok {
+				return c, nil
+			}
+			if _, ok := p.accept(","); !ok {
+				return nil, errorf(p.peek().pos, "expected , or ) in call to %s, found %s", tok.text, describe(p.peek()))
+			}
+		}
+	}
+	if _, ok := p.accept("("); ok {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, errorf(p.peek().pos, "expected ), found %s", describe(p.peek()))
+		}
+		return x, nil
+	}
+	return nil, errorf(tok.pos, "expected a value, found %s", describe(tok))
+}
+
+func describe(tok token) string {
+	if tok.kind == tokEOF {
+		return "end of expression"
+	}
+	return "'" + tok.text + "'"
+}
@@ -0,0 +1,187 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Bar is one minute of an instrument's price history.
+type Bar struct {
+	Time   time.Time // Start of the minute
+	Close  float64
+	Volume float64
+}
+
+// HistoryConfig describes the provider's historical minutes endpoint.
+type HistoryConfig struct {
+	URL    string // Endpoint with a %s for the API key, like the price URL
+	APIKey string
+
+	PageSize    int           // Minutes per request (default 2000, CoinDesk's maximum)
+	MinInterval time.Duration // Least time between requests (default 250ms)
+	MaxRetries  int           // Attempts per page after a rate limit or server error (default 5)
+	Client      *http.Client
+}
+
+// HistoryFetcher reads minute bars from CoinDesk's historical endpoint, paging backwards from now
+// and spacing out requests to stay within the provider's rate limits.
+type HistoryFetcher struct {
+	cfg  HistoryConfig
+	last time.Time // When the last request was sent
+}
+
+func NewHistoryFetcher(cfg HistoryConfig) *HistoryFetcher {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 2000
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = 250 * time.Millisecond
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &HistoryFetcher{cfg: cfg}
+}
+
+// Since returns the bars ending after since, up to now, oldest first. If fetching fails part way, the bars
+// fetched so far are returned along with the error.
+func (h *HistoryFetcher) Since(ctx context.Context, since time.Time) ([]Bar, error) {
+	var bars []Bar
+	// A bar's close is the price at the end of its minute, so the minute under way at since counts.
+	for to := time.Now(); to.Add(time.Minute).After(since); {
+		limit := min(h.cfg.PageSize, int(to.Sub(since)/time.Minute)+2)
+		page, err := h.page(ctx, to, limit)
+		if err != nil {
+			return bars, err
+		}
+		// Anything at or after the previous page's start was already seen.
+		page = slices.DeleteFunc(page, func(b Bar) bool {
+			return !b.Time.Add(time.Minute).After(since) || (len(bars) > 0 && !b.Time.Before(bars[0].Time))
+		})
+		if len(page) == 0 {
+			break
+		}
+		bars = append(page, bars...)
+		to = bars[0].Time.Add(-time.Minute)
+	}
+	return bars, nil
+}
+
+// page fetches up to limit bars ending at to, retrying when rate limited.
+func (h *HistoryFetcher) page(ctx context.Context, to time.Time, limit int) ([]Bar, error) {
+	u, err := url.Parse(fmt.Sprintf(h.cfg.URL, h.cfg.APIKey))
+	if err != nil {
+		return nil, withoutURL(err)
+	}
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("to_ts", strconv.FormatInt(to.Unix(), 10))
+	u.RawQuery = q.Encode()
+
+	backoff := h.cfg.MinInterval
+	for attempt := 1; ; attempt++ {
+		if err := sleep(ctx, time.Until(h.last.Add(h.cfg.MinInterval))); err != nil {
+			return nil, err
+		}
+		h.last = time.Now()
+
+		bars, retryAfter, err := h.get(ctx, u.String())
+		if err == nil || retryAfter < 0 || attempt == h.cfg.MaxRetries {
+			return bars, err
+		}
+		// Without a Retry-After hint, back off exponentially.
+		if retryAfter == 0 {
+			retryAfter, backoff = backoff, backoff*2
+		}
+		if err := sleep(ctx, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// get performs one request. On failure it returns how long to wait before retrying: zero when the
+// provider gave no hint, or negative when retrying won't help.
+func (h *HistoryFetcher) get(ctx context.Context, endpoint string) ([]Bar, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, -1, withoutURL(err)
+	}
+	resp, err := h.cfg.Client.Do(req)
+	if err != nil {
+		return nil, 0, withoutURL(err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, retryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("fetching history: %s", resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, -1, fmt.Errorf("fetching history: %s", resp.Status)
+	}
+
+	var result struct {
+		Data []struct {
+			Timestamp int64   `json:"TIMESTAMP"` // Unix seconds
+			Close     float64 `json:"CLOSE"`
+			Volume    float64 `json:"VOLUME"`
+		} `json:"Data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, -1, fmt.Errorf("decoding history: %w", err)
+	}
+	bars := make([]Bar, 0, len(result.Data))
+	for _, d := range result.Data {
+		if d.Close > 0 {
+			bars = append(bars, Bar{Time: time.Unix(d.Timestamp, 0).UTC(), Close: d.Close, Volume: d.Volume})
+		}
+	}
+	slices.SortFunc(bars, func(a, b Bar) int { return a.Time.Compare(b.Time) })
+	return bars, 0, nil
+}
+
+// withoutURL drops the request URL that net/url and net/http errors quote, since it carries the API key
+// and the errors end up in logs.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("fetching history: %w", urlErr.Err)
+	}
+	return err
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date, returning zero if absent or invalid.
+func retryAfter(v string) time.Duration {
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
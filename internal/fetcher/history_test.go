@@ -0,0 +1,134 @@
+package fetcher_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/fetcher"
+)
+
+// historyAPI mocks CoinDesk's historical minutes endpoint, serving a bar for every minute up to to_ts.
+// The first rateLimited requests are refused with 429, with retryAfter as the Retry-After header.
+type historyAPI struct {
+	mutex       sync.Mutex
+	requests    []time.Time
+	rateLimited int
+	retryAfter  string
+}
+
+func (a *historyAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mutex.Lock()
+	a.requests = append(a.requests, time.Now())
+	limited := len(a.requests) <= a.rateLimited
+	a.mutex.Unlock()
+	if limited {
+		if a.retryAfter != "" {
+			w.Header().Set("Retry-After", a.retryAfter)
+		}
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+		return
+	}
+	if r.URL.Query().Get("api_key") != "key" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	to, _ := strconv.ParseInt(r.URL.Query().Get("to_ts"), 10, 64)
+	end := to - to%60
+	var bars []string
+	for t := end - int64(limit-1)*60; t <= end; t += 60 {
+		bars = append(bars, fmt.Sprintf(`{"UNIT":"MINUTE","TIMESTAMP":%d,"CLOSE":%d,"VOLUME":1.5}`, t, t/60%1000+60000))
+	}
+	fmt.Fprintf(w, `{"Data":[%s],"Err":{}}`, strings.Join(bars, ","))
+}
+
+func (a *historyAPI) count() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.requests)
+}
+
+func newHistory(t *testing.T, api *historyAPI, key string, cfg fetcher.HistoryConfig) *fetcher.HistoryFetcher {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	cfg.URL = srv.URL + "/index/cc/v1/historical/minutes?instrument=BTC-USD&api_key=%s"
+	cfg.APIKey = key
+	return fetcher.NewHistoryFetcher(cfg)
+}
+
+// TestHistoryPages tests that history is read backwards in pages, spaced out, into one ordered series.
+func TestHistoryPages(t *testing.T) {
+	api := &historyAPI{}
+	h := newHistory(t, api, "key", fetcher.HistoryConfig{PageSize: 30, MinInterval: 50 * time.Millisecond})
+
+	start := time.Now()
+	since := start.Add(-75 * time.Minute)
+	bars, err := h.Since(context.Background(), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first bar is the minute under way at since.
+	if len(bars) != 76 || bars[0].Time.After(since) || !bars[0].Time.Add(time.Minute).After(since) {
+		t.Fatalf("expected 76 minutes from %v, got %d from %v", since, len(bars), bars[0].Time)
+	}
+	for i := 1; i < len(bars); i++ {
+		if bars[i].Time.Sub(bars[i-1].Time) != time.Minute {
+			t.Fatalf("expected consecutive minutes, got %v then %v", bars[i-1].Time, bars[i].Time)
+		}
+	}
+	if bars[0].Close < 60000 || bars[0].Volume != 1.5 {
+		t.Errorf("unexpected bar %+v", bars[0])
+	}
+	if api.count() != 3 || time.Since(start) < 100*time.Millisecond {
+		t.Errorf("expected 3 requests at least 50ms apart, got %d in %v", api.count(), time.Since(start))
+	}
+}
+
+// TestHistoryRateLimited tests that refused requests are retried after the provider's Retry-After,
+// or with backoff when it gives none.
+func TestHistoryRateLimited(t *testing.T) {
+	api := &historyAPI{rateLimited: 1, retryAfter: "1"}
+	h := newHistory(t, api, "key", fetcher.HistoryConfig{MinInterval: time.Millisecond})
+	start := time.Now()
+	bars, err := h.Since(context.Background(), start.Add(-10*time.Minute))
+	if err != nil || len(bars) != 11 {
+		t.Fatalf("expected 11 bars after retrying, got %d: %v", len(bars), err)
+	}
+	if waited := api.requests[1].Sub(api.requests[0]); waited < time.Second {
+		t.Errorf("expected the retry to wait for Retry-After, waited %v", waited)
+	}
+
+	api = &historyAPI{rateLimited: 10}
+	h = newHistory(t, api, "key", fetcher.HistoryConfig{MinInterval: time.Millisecond, MaxRetries: 3})
+	if _, err := h.Since(context.Background(), time.Now().Add(-time.Hour)); err == nil || api.count() != 3 {
+		t.Errorf("expected to give up after 3 attempts, got %d: %v", api.count(), err)
+	}
+}
+
+// TestHistoryError tests that errors other than rate limits aren't retried.
+func TestHistoryError(t *testing.T) {
+	api := &historyAPI{}
+	h := newHistory(t, api, "wrong", fetcher.HistoryConfig{})
+	if _, err := h.Since(context.Background(), time.Now().Add(-time.Hour)); err == nil || api.count() != 1 {
+		t.Errorf("expected a single failed request, got %d: %v", api.count(), err)
+	}
+}
+
+// TestHistoryErrorRedacted tests that errors don't reveal the API key from the request URL.
+func TestHistoryErrorRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	h := fetcher.NewHistoryFetcher(fetcher.HistoryConfig{URL: srv.URL + "/minutes?api_key=%s", APIKey: "s3cret", MaxRetries: 1})
+	_, err := h.Since(context.Background(), time.Now().Add(-time.Hour))
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected an error without the API key, got %v", err)
+	}
+}
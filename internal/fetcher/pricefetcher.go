@@ -21,33 +21,53 @@ func NewPriceFetcher(apiKey, apiURL string) *PriceFetcher {
 	}
 }
 
+// Quote is a price as reported by the provider.
+type Quote struct {
+	Price     float64
+	UpdatedAt time.Time     // Provider's last update of the price, zero if not reported
+	RoundTrip time.Duration // How long the request took
+}
+
 // Fetch makes a HTTP GET request to the CoinDesk API with a 3-second timeout.
-// Using context.WithTimeout prevents hanging requests.
 func (pf *PriceFetcher) Fetch() (float64, error) {
+	q, err := pf.FetchQuote()
+	return q.Price, err
+}
+
+// FetchQuote fetches the price along with the provider's timestamp and the request's round trip.
+// Using context.WithTimeout prevents hanging requests.
+func (pf *PriceFetcher) FetchQuote() (Quote, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(pf.apiURL, pf.apiKey), nil)
 	if err != nil {
-		return 0, err
+		return Quote{}, err
 	}
 
+	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0, err
+		return Quote{}, err
 	}
 	defer resp.Body.Close()
 
 	var result struct {
 		Data map[string]struct {
-			Value float64 `json:"VALUE"`
+			Value     float64 `json:"VALUE"`
+			UpdatedAt int64   `json:"VALUE_LAST_UPDATE_TS"` // Unix seconds
 		} `json:"Data"`
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	if err != nil {
-		return 0, err
+		return Quote{}, err
 	}
 
-	return result.Data["BTC-USD"].Value, nil
+	tick := result.Data["BTC-USD"]
+	q := Quote{Price: tick.Value, RoundTrip: time.Since(start)}
+	if tick.UpdatedAt > 0 {
+		q.UpdatedAt = time.Unix(tick.UpdatedAt, 0).UTC()
+	}
+	return q, nil
 }
@@ -68,3 +68,21 @@ func TestFetchHTTPError(t *testing.T) {
 		t.Fatal("expected error due to HTTP error status, got nil")
 	}
 }
+
+// TestFetchQuote tests that the provider timestamp and round trip are reported with the price.
+func TestFetchQuote(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Data": {"BTC-USD": {"VALUE": 45000.55, "VALUE_LAST_UPDATE_TS": 1716732900}}}`))
+	}))
+	defer mockServer.Close()
+
+	pf := fetcher.NewPriceFetcher("dummy-api-key", mockServer.URL+"?apikey=%s")
+
+	q, err := pf.FetchQuote()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if q.Price != 45000.55 || q.UpdatedAt.Unix() != 1716732900 || q.RoundTrip <= 0 {
+		t.Errorf("unexpected quote %+v", q)
+	}
+}
@@ -0,0 +1,104 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CacheConfig tunes how long rates are reused.
+type CacheConfig struct {
+	TTL        time.Duration // Rates are refetched once this old (default 1h)
+	MaxAge     time.Duration // Older rates are served while refetching fails, up to this age (default 24h)
+	RetryAfter time.Duration // Minimum time between failed fetches (default 30s)
+}
+
+// Cache serves rates from a Source, fetching each base currency's rates at most once per TTL.
+// Fetches run in the background, so Rate never waits on the network: it answers from the cached
+// rates, and has none to give until the first fetch of a currency completes.
+// It is safe for concurrent use.
+type Cache struct {
+	src       Source
+	cfg       CacheConfig
+	now       func() time.Time
+	mutex     sync.Mutex
+	entries   map[string]*entry
+	refreshes sync.WaitGroup // In-flight fetches
+}
+
+// entry holds the rates of one base currency.
+type entry struct {
+	rates    map[string]float64
+	fetched  time.Time // When rates were fetched, zero if never
+	tried    time.Time // When fetching was last attempted
+	fetching bool      // Whether a fetch is in flight
+	err      error     // Why the last attempt failed, nil if it succeeded
+}
+
+func NewCache(src Source, cfg CacheConfig) *Cache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 24 * time.Hour
+	}
+	cfg.MaxAge = max(cfg.MaxAge, cfg.TTL)
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 30 * time.Second
+	}
+	return &Cache{src: src, cfg: cfg, now: time.Now, entries: make(map[string]*entry)}
+}
+
+// Rate returns how many units of to one unit of from buys, starting a fetch of from's rates in the
+// background if the cached ones have expired. ctx bounds that fetch. Converting a currency to
+// itself needs no rates.
+func (c *Cache) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	e := c.entries[from]
+	if e == nil {
+		e = &entry{}
+		c.entries[from] = e
+	}
+	if now.Sub(e.fetched) >= c.cfg.TTL && now.Sub(e.tried) >= c.cfg.RetryAfter && !e.fetching {
+		e.tried, e.fetching = now, true
+		c.refreshes.Add(1)
+		go c.fetch(ctx, from, e, now)
+	}
+
+	if e.fetched.IsZero() || now.Sub(e.fetched) > c.cfg.MaxAge {
+		switch {
+		case e.err != nil:
+			return 0, fmt.Errorf("no current %s rates: %w", from, e.err)
+		case e.fetching:
+			return 0, fmt.Errorf("fetching %s rates", from)
+		}
+		return 0, fmt.Errorf("no current %s rates", from)
+	}
+	rate, ok := e.rates[to]
+	if !ok {
+		return 0, fmt.Errorf("no %s-%s rate", from, to)
+	}
+	return rate, nil
+}
+
+// fetch refreshes e with base's rates, as of the time the fetch started.
+func (c *Cache) fetch(ctx context.Context, base string, e *entry, started time.Time) {
+	defer c.refreshes.Done()
+	rates, err := c.src.Rates(ctx, base)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e.fetching = false
+	if err != nil {
+		e.err = err
+	} else {
+		e.rates, e.fetched, e.err = rates, started, nil
+	}
+}
@@ -0,0 +1,160 @@
+// Package fx provides foreign exchange rates for converting prices between quote currencies.
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source fetches exchange rates. Rates returns, for each currency it knows, how many units of it
+// one unit of base buys: Rates(ctx, "USD") might return {"EUR": 0.92, "BRL": 5.1}.
+type Source interface {
+	Rates(ctx context.Context, base string) (map[string]float64, error)
+}
+
+// HTTPSource fetches rates from a JSON API such as https://api.frankfurter.app/latest?from={base}.
+type HTTPSource struct {
+	URL    string // Endpoint, where {base} is replaced by the base currency
+	Path   string // Dotted path to the object mapping currencies to rates (default "rates")
+	Client *http.Client
+}
+
+func (s *HTTPSource) Rates(ctx context.Context, base string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	endpoint := strings.ReplaceAll(s.URL, "{base}", url.PathEscape(base))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s rates: %s", base, resp.Status)
+	}
+
+	var v any
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding %s rates: %w", base, err)
+	}
+	path := s.Path
+	if path == "" {
+		path = "rates"
+	}
+	for _, key := range strings.Split(path, ".") {
+		node, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("no rates at %q", path)
+		}
+		v = node[key]
+	}
+	node, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no rates at %q", path)
+	}
+
+	// Providers disagree on whether rates are numbers or strings; anything else is skipped.
+	rates := make(map[string]float64, len(node))
+	for currency, value := range node {
+		var rate float64
+		switch value := value.(type) {
+		case float64:
+			rate = value
+		case string:
+			rate, _ = strconv.ParseFloat(value, 64)
+		}
+		if rate > 0 {
+			rates[strings.ToUpper(currency)] = rate
+		}
+	}
+	return rates, nil
+}
+
+// Static serves fixed rates, keyed by pairs like "EUR-USD" giving the price of one EUR in USD.
+// Each pair also answers the inverse conversion.
+type Static map[string]float64
+
+// ParseStatic parses a comma-separated list of pairs and rates, e.g. "EUR-USD=1.08,USD-BRL=5.1".
+func ParseStatic(list string) (Static, error) {
+	rates := make(Static)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		pair, value, ok := strings.Cut(item, "=")
+		base, quote, pairOK := Split(pair)
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || !pairOK || err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %q (expected e.g. EUR-USD=1.08)", item)
+		}
+		rates[base+"-"+quote] = rate
+	}
+	return rates, nil
+}
+
+func (s Static) Rates(_ context.Context, base string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for pair, rate := range s {
+		from, to, _ := Split(pair)
+		switch base {
+		case from:
+			rates[to] = rate
+		case to:
+			rates[from] = 1 / rate
+		}
+	}
+	return rates, nil
+}
+
+// Split splits an instrument or currency pair like "BTC-USD" into its upper-cased base and quote.
+func Split(pair string) (base, quote string, ok bool) {
+	base, quote, ok = strings.Cut(strings.ToUpper(strings.TrimSpace(pair)), "-")
+	return base, quote, ok && base != "" && quote != "" && !strings.Contains(quote, "-")
+}
+
+// Synthetic is an instrument derived by converting a native one into another quote currency,
+// e.g. BTC-EUR from BTC-USD.
+type Synthetic struct {
+	Instrument string
+	From       string
+}
+
+// ParseSynthetics parses a comma-separated list of synthetic instruments. Each is derived from the
+// instrument with the same base quoted in quote, e.g. BTC-EUR from BTC-USD, unless given explicitly
+// as in "BTC-EUR=BTC-USDT".
+func ParseSynthetics(list, quote string) ([]Synthetic, error) {
+	var synthetics []Synthetic
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		instrument, from, explicit := strings.Cut(item, "=")
+		base, to, ok := Split(instrument)
+		if !ok {
+			return nil, fmt.Errorf("invalid instrument %q (expected e.g. BTC-EUR)", instrument)
+		}
+		if !explicit {
+			from = base + "-" + quote
+		}
+		fromBase, fromQuote, ok := Split(from)
+		if !ok || fromBase != base || fromQuote == to {
+			return nil, fmt.Errorf("cannot derive %s-%s from %q", base, to, from)
+		}
+		synthetics = append(synthetics, Synthetic{Instrument: base + "-" + to, From: fromBase + "-" + fromQuote})
+	}
+	return synthetics, nil
+}
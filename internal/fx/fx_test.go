@@ -0,0 +1,183 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHTTPSource tests fetching rates from a JSON API, with numbers or numeric strings.
+func TestHTTPSource(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("from") != "USD" {
+			http.Error(w, "unknown base", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":{"rates":{"EUR":0.92,"brl":"5.1","XXX":null}}}`))
+	}))
+	defer api.Close()
+
+	src := &HTTPSource{URL: api.URL + "?from={base}", Path: "data.rates"}
+	rates, err := src.Rates(context.Background(), "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates["EUR"] != 0.92 || rates["BRL"] != 5.1 {
+		t.Errorf("unexpected rates %v", rates)
+	}
+	if _, err := src.Rates(context.Background(), "JPY"); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+	if _, err := (&HTTPSource{URL: api.URL + "?from={base}"}).Rates(context.Background(), "USD"); err == nil {
+		t.Error("expected an error when the path holds no rates")
+	}
+}
+
+// TestStatic tests fixed rates in both directions.
+func TestStatic(t *testing.T) {
+	rates, err := ParseStatic("EUR-USD=1.25, usd-brl=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd, _ := rates.Rates(context.Background(), "USD")
+	if usd["EUR"] != 0.8 || usd["BRL"] != 5 {
+		t.Errorf("unexpected USD rates %v", usd)
+	}
+
+	for _, list := range []string{"EUR=1", "EUR-USD", "EUR-USD=0", "EUR-USD=x"} {
+		if _, err := ParseStatic(list); err == nil {
+			t.Errorf("expected %q to be rejected", list)
+		}
+	}
+}
+
+// TestParseSynthetics tests deriving instruments from the default quote or an explicit one.
+func TestParseSynthetics(t *testing.T) {
+	got, err := ParseSynthetics("btc-eur, BTC-BRL=BTC-USDT", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Synthetic{{"BTC-EUR", "BTC-USD"}, {"BTC-BRL", "BTC-USDT"}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, list := range []string{"BTC", "BTC-USD", "BTC-EUR=ETH-USD", "BTC-EUR=BTC"} {
+		if _, err := ParseSynthetics(list, "USD"); err == nil {
+			t.Errorf("expected %q to be rejected", list)
+		}
+	}
+}
+
+// countingSource serves rates, or fails while err is set, counting fetches.
+type countingSource struct {
+	rate    float64
+	err     error
+	fetches int
+}
+
+func (s *countingSource) Rates(context.Context, string) (map[string]float64, error) {
+	s.fetches++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return map[string]float64{"EUR": s.rate}, nil
+}
+
+// TestCache tests that rates are reused until the TTL, refetched in the background, and served stale
+// while refetching fails.
+func TestCache(t *testing.T) {
+	src := &countingSource{rate: 0.9}
+	cache := NewCache(src, CacheConfig{TTL: time.Hour, MaxAge: 3 * time.Hour, RetryAfter: time.Minute})
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	// rate asks for a rate, then lets any fetch it started complete.
+	rate := func(want float64) {
+		t.Helper()
+		got, err := cache.Rate(context.Background(), "USD", "EUR")
+		cache.refreshes.Wait()
+		if want == 0 {
+			if err == nil {
+				t.Errorf("expected an error, got %v", got)
+			}
+		} else if err != nil || got != want {
+			t.Errorf("expected %v, got %v (%v)", want, got, err)
+		}
+	}
+
+	rate(0) // Fetching
+	rate(0.9)
+	src.rate = 0.95
+	now = now.Add(59 * time.Minute)
+	rate(0.9) // Cached
+	now = now.Add(time.Minute)
+	rate(0.9) // Expired, served while refetching
+	rate(0.95)
+	if src.fetches != 2 {
+		t.Errorf("expected 2 fetches, got %d", src.fetches)
+	}
+
+	src.err = errors.New("down")
+	now = now.Add(time.Hour)
+	rate(0.95) // Stale but within MaxAge
+	now = now.Add(30 * time.Second)
+	rate(0.95)
+	if src.fetches != 3 {
+		t.Errorf("expected failed fetches to wait RetryAfter, got %d fetches", src.fetches)
+	}
+	now = now.Add(2 * time.Hour)
+	rate(0) // Too old to serve
+
+	src.err = nil
+	now = now.Add(time.Minute)
+	rate(0) // Refetching
+	rate(0.95)
+	if got, err := cache.Rate(context.Background(), "USD", "BRL"); err == nil {
+		t.Errorf("expected an error for an unknown currency, got %v", got)
+	}
+	if got, _ := cache.Rate(context.Background(), "EUR", "EUR"); got != 1 {
+		t.Errorf("expected 1 converting a currency to itself, got %v", got)
+	}
+}
+
+// blockingSource serves rates once released.
+type blockingSource chan struct{}
+
+func (s blockingSource) Rates(ctx context.Context, _ string) (map[string]float64, error) {
+	select {
+	case <-s:
+		return map[string]float64{"EUR": 0.9}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TestCacheNonBlocking tests that a slow rate source never holds up callers.
+func TestCacheNonBlocking(t *testing.T) {
+	src := make(blockingSource)
+	cache := NewCache(src, CacheConfig{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 3 {
+			if _, err := cache.Rate(context.Background(), "USD", "EUR"); err == nil {
+				t.Error("expected no rate before the fetch completes")
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Rate waited for the fetch")
+	}
+
+	close(src)
+	cache.refreshes.Wait()
+	if rate, err := cache.Rate(context.Background(), "USD", "EUR"); err != nil || rate != 0.9 {
+		t.Errorf("expected the fetched rate, got %v (%v)", rate, err)
+	}
+}
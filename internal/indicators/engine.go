@@ -0,0 +1,162 @@
+package indicators
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Engine maintains the indicators clients are subscribed to, for every instrument, fed with each
+// delivered update. Indicators are shared between subscribers and computed over the full stream,
+// whatever filters or throttling a client applies. It is safe for concurrent use.
+type Engine struct {
+	history func() []models.PriceUpdate // Recent updates, oldest first, used to warm up new indicators
+	tracked map[Spec]*tracked
+	mutex   sync.Mutex
+}
+
+// tracked is a spec with at least one subscriber.
+type tracked struct {
+	subscribers int
+	series      map[string]*series // By instrument
+}
+
+// series is one indicator over one instrument's prices.
+type series struct {
+	indicator Indicator
+	seq       uint64
+	at        time.Time
+}
+
+// Snapshot is the latest reading of a set of indicators for an instrument.
+// Values are keyed by spec and nil while an indicator is still warming up.
+type Snapshot struct {
+	Instrument string            `json:"instrument"`
+	Seq        uint64            `json:"seq,omitempty"`
+	Timestamp  time.Time         `json:"timestamp,omitzero"` // Of the last update the values include
+	Values     map[string]*Value `json:"values"`
+}
+
+// NewEngine returns an engine that backfills new indicators from history, typically the ring buffer.
+func NewEngine(history func() []models.PriceUpdate) *Engine {
+	return &Engine{history: history, tracked: make(map[Spec]*tracked)}
+}
+
+// Subscribe starts maintaining specs, warming any not yet tracked up from history.
+// The returned function releases them; indicators nobody subscribes to any more are dropped.
+func (e *Engine) Subscribe(specs []Spec) (release func()) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var history []models.PriceUpdate
+	for _, spec := range specs {
+		t := e.tracked[spec]
+		if t == nil {
+			if history == nil {
+				history = e.history()
+			}
+			t = &tracked{series: make(map[string]*series)}
+			for _, u := range history {
+				t.add(spec, u)
+			}
+			e.tracked[spec] = t
+		}
+		t.subscribers++
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			e.mutex.Lock()
+			defer e.mutex.Unlock()
+			for _, spec := range specs {
+				if t := e.tracked[spec]; t != nil {
+					if t.subscribers--; t.subscribers == 0 {
+						delete(e.tracked, spec)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Update feeds a delivered update to every tracked indicator.
+func (e *Engine) Update(u models.PriceUpdate) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for spec, t := range e.tracked {
+		t.add(spec, u)
+	}
+}
+
+// Values returns the readings of specs for an instrument. Tracked specs report their live values;
+// others are computed from history on the spot.
+func (e *Engine) Values(instrument string, specs []Spec) Snapshot {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	snap := Snapshot{Instrument: instrument, Values: make(map[string]*Value, len(specs))}
+	var history []models.PriceUpdate
+	for _, spec := range specs {
+		s := e.tracked[spec].get(instrument)
+		if s == nil {
+			if history == nil {
+				history = e.history()
+			}
+			t := &tracked{series: make(map[string]*series)}
+			for _, u := range history {
+				if u.Instrument == instrument {
+					t.add(spec, u)
+				}
+			}
+			s = t.get(instrument)
+		}
+		if s == nil {
+			snap.Values[spec.String()] = nil
+			continue
+		}
+		if v, ok := s.indicator.Value(); ok {
+			snap.Values[spec.String()] = &v
+		} else {
+			snap.Values[spec.String()] = nil
+		}
+		if s.at.After(snap.Timestamp) {
+			snap.Seq, snap.Timestamp = s.seq, s.at
+		}
+	}
+	return snap
+}
+
+// Reading returns the live value of a tracked spec for an instrument. It reports false for specs
+// nobody subscribed to and while the indicator warms up.
+func (e *Engine) Reading(instrument string, spec Spec) (Value, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if s := e.tracked[spec].get(instrument); s != nil {
+		return s.indicator.Value()
+	}
+	return Value{}, false
+}
+
+func (t *tracked) get(instrument string) *series {
+	if t == nil {
+		return nil
+	}
+	return t.series[instrument]
+}
+
+// add feeds u to the instrument's indicator. Updates already seen, e.g. both in the backfill and
+// delivered while it ran, are skipped by sequence number.
+func (t *tracked) add(spec Spec, u models.PriceUpdate) {
+	s := t.series[u.Instrument]
+	if s == nil {
+		s = &series{indicator: spec.New()}
+		t.series[u.Instrument] = s
+	}
+	if u.Seq != 0 && u.Seq <= s.seq {
+		return
+	}
+	s.indicator.Add(u.Price, u.Volume)
+	s.seq, s.at = u.Seq, u.Timestamp
+}
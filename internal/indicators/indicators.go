@@ -0,0 +1,170 @@
+// Package indicators computes technical indicators incrementally over the price stream.
+// Each new price costs constant time, whatever the indicator's period.
+package indicators
+
+import "math"
+
+// Value is an indicator reading. Upper and Lower are only set for Bollinger bands, whose Value is the middle band.
+type Value struct {
+	Value float64 `json:"value"`
+	Upper float64 `json:"upper,omitempty"`
+	Lower float64 `json:"lower,omitempty"`
+}
+
+// Indicator consumes prices one at a time, oldest first.
+type Indicator interface {
+	// Add feeds the next price. Volume only matters to VWAP; zero counts as one unit.
+	Add(price, volume float64)
+	// Value returns the current reading. It reports false until enough prices have been seen.
+	Value() (Value, bool)
+}
+
+// window keeps the last n values with their running sum and sum of squares.
+type window struct {
+	values     []float64
+	next       int
+	full       bool
+	sum, sumSq float64
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+func (w *window) push(v float64) {
+	if w.full {
+		old := w.values[w.next]
+		w.sum -= old
+		w.sumSq -= old * old
+	}
+	w.values[w.next] = v
+	w.sum += v
+	w.sumSq += v * v
+	w.next++
+	if w.next == len(w.values) {
+		w.next, w.full = 0, true
+		// Running sums drift as values come and go; recomputing once per lap keeps them exact at amortised O(1).
+		w.sum, w.sumSq = 0, 0
+		for _, x := range w.values {
+			w.sum += x
+			w.sumSq += x * x
+		}
+	}
+}
+
+func (w *window) mean() float64 {
+	return w.sum / float64(len(w.values))
+}
+
+// sma is the simple moving average of the last n prices.
+type sma struct{ w *window }
+
+func newSMA(n int) *sma { return &sma{newWindow(n)} }
+
+func (s *sma) Add(price, _ float64) { s.w.push(price) }
+
+func (s *sma) Value() (Value, bool) {
+	return Value{Value: s.w.mean()}, s.w.full
+}
+
+// ema is the exponential moving average with smoothing 2/(n+1), seeded with the SMA of the first n prices.
+type ema struct {
+	n, count int
+	alpha    float64
+	value    float64
+}
+
+func newEMA(n int) *ema { return &ema{n: n, alpha: 2 / float64(n+1)} }
+
+func (e *ema) Add(price, _ float64) {
+	e.count++
+	switch {
+	case e.count < e.n:
+		e.value += price
+	case e.count == e.n:
+		e.value = (e.value + price) / float64(e.n)
+	default:
+		e.value += e.alpha * (price - e.value)
+	}
+}
+
+func (e *ema) Value() (Value, bool) {
+	return Value{Value: e.value}, e.count >= e.n
+}
+
+// rsi is Wilder's relative strength index over n price changes, between 0 and 100.
+type rsi struct {
+	n, changes       int
+	prev             float64
+	avgGain, avgLoss float64
+}
+
+func newRSI(n int) *rsi { return &rsi{n: n, changes: -1} }
+
+func (r *rsi) Add(price, _ float64) {
+	r.changes++
+	change := price - r.prev
+	r.prev = price
+	if r.changes == 0 {
+		return
+	}
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+	if r.changes <= r.n {
+		// The first averages are plain means of the first n changes.
+		r.avgGain += gain / float64(r.n)
+		r.avgLoss += loss / float64(r.n)
+		return
+	}
+	r.avgGain = (r.avgGain*float64(r.n-1) + gain) / float64(r.n)
+	r.avgLoss = (r.avgLoss*float64(r.n-1) + loss) / float64(r.n)
+}
+
+func (r *rsi) Value() (Value, bool) {
+	if r.changes < r.n {
+		return Value{}, false
+	}
+	switch {
+	case r.avgLoss == 0 && r.avgGain == 0:
+		return Value{Value: 50}, true // A flat market is neither overbought nor oversold
+	case r.avgLoss == 0:
+		return Value{Value: 100}, true
+	}
+	return Value{Value: 100 - 100/(1+r.avgGain/r.avgLoss)}, true
+}
+
+// bollinger bands are the SMA of the last n prices plus and minus k population standard deviations.
+type bollinger struct {
+	w *window
+	k float64
+}
+
+func newBollinger(n int, k float64) *bollinger { return &bollinger{newWindow(n), k} }
+
+func (b *bollinger) Add(price, _ float64) { b.w.push(price) }
+
+func (b *bollinger) Value() (Value, bool) {
+	mean := b.w.mean()
+	variance := max(b.w.sumSq/float64(len(b.w.values))-mean*mean, 0)
+	width := b.k * math.Sqrt(variance)
+	return Value{Value: mean, Upper: mean + width, Lower: mean - width}, b.w.full
+}
+
+// vwap is the volume-weighted average of the last n prices.
+// Without volume it weights every update equally and matches the SMA.
+type vwap struct {
+	priceVolume, volume *window
+}
+
+func newVWAP(n int) *vwap { return &vwap{newWindow(n), newWindow(n)} }
+
+func (v *vwap) Add(price, volume float64) {
+	if volume <= 0 {
+		volume = 1
+	}
+	v.priceVolume.push(price * volume)
+	v.volume.push(volume)
+}
+
+func (v *vwap) Value() (Value, bool) {
+	return Value{Value: v.priceVolume.sum / v.volume.sum}, v.volume.full
+}
@@ -0,0 +1,173 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func feed(ind Indicator, prices ...float64) {
+	for _, p := range prices {
+		ind.Add(p, 0)
+	}
+}
+
+// TestParseSpecs tests spec parsing, canonical names and rejection of bad input.
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs("EMA:20, rsi:14,bollinger:20,bb:10:2.5,ema:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.String())
+	}
+	if want := []string{"ema:20", "rsi:14", "bb:20", "bb:10:2.5"}; len(names) != len(want) || names[0] != want[0] || names[1] != want[1] || names[2] != want[2] || names[3] != want[3] {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	if specs, err := ParseSpecs(""); specs != nil || err != nil {
+		t.Errorf("expected nothing for an empty list, got %v, %v", specs, err)
+	}
+	for _, bad := range []string{"macd:12", "ema", "ema:0", "sma:1001", "rsi:14:2", "bb:20:-1", "vwap:ten"} {
+		if _, err := ParseSpecs(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+// TestMovingAverages tests SMA, EMA and VWAP against their definitions, including warm-up.
+func TestMovingAverages(t *testing.T) {
+	sma := Spec{Kind: "sma", Period: 3}.New()
+	feed(sma, 1, 2)
+	if _, ok := sma.Value(); ok {
+		t.Error("expected SMA to be warming up after 2 of 3 prices")
+	}
+	feed(sma, 3, 10)
+	if v, _ := sma.Value(); !near(v.Value, 5) {
+		t.Errorf("expected SMA of 2,3,10 to be 5, got %v", v.Value)
+	}
+
+	// Seeded with the SMA of the first 3 prices (2), then smoothed by 0.5.
+	ema := Spec{Kind: "ema", Period: 3}.New()
+	feed(ema, 1, 2, 3, 10)
+	if v, ok := ema.Value(); !ok || !near(v.Value, 6) {
+		t.Errorf("expected EMA 6, got %v, %v", v.Value, ok)
+	}
+
+	vwap := Spec{Kind: "vwap", Period: 2}.New()
+	vwap.Add(100, 1)
+	vwap.Add(110, 3)
+	if v, ok := vwap.Value(); !ok || !near(v.Value, 107.5) {
+		t.Errorf("expected VWAP 107.5, got %v, %v", v.Value, ok)
+	}
+	vwap.Add(120, 0) // No volume counts as one unit
+	if v, _ := vwap.Value(); !near(v.Value, 112.5) {
+		t.Errorf("expected VWAP 112.5 after the window moved, got %v", v.Value)
+	}
+}
+
+// TestRSI tests Wilder's RSI on a textbook series and at its extremes.
+func TestRSI(t *testing.T) {
+	// The widely used 14-period example; references rounding their averages quote 70.53 and 66.32.
+	prices := []float64{44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00}
+	rsi := Spec{Kind: "rsi", Period: 14}.New()
+	feed(rsi, prices[:14]...)
+	if _, ok := rsi.Value(); ok {
+		t.Error("expected RSI to need 14 changes")
+	}
+	feed(rsi, prices[14])
+	if v, _ := rsi.Value(); math.Abs(v.Value-70.46) > 0.01 {
+		t.Errorf("expected RSI 70.46, got %.4f", v.Value)
+	}
+	feed(rsi, prices[15])
+	if v, _ := rsi.Value(); math.Abs(v.Value-66.25) > 0.01 {
+		t.Errorf("expected RSI 66.25, got %.4f", v.Value)
+	}
+
+	up := Spec{Kind: "rsi", Period: 2}.New()
+	feed(up, 1, 2, 3)
+	flat := Spec{Kind: "rsi", Period: 2}.New()
+	feed(flat, 1, 1, 1)
+	if u, _ := up.Value(); u.Value != 100 {
+		t.Errorf("expected RSI 100 for a rising market, got %v", u.Value)
+	}
+	if f, _ := flat.Value(); f.Value != 50 {
+		t.Errorf("expected RSI 50 for a flat market, got %v", f.Value)
+	}
+}
+
+// TestBollinger tests the bands and that running sums stay exact as the window turns over.
+func TestBollinger(t *testing.T) {
+	bb := Spec{Kind: "bb", Period: 4, K: 2}.New()
+	feed(bb, 2, 4, 4, 4, 5, 5, 7, 9)
+	v, ok := bb.Value()
+	// The last 4 prices have mean 6.5 and population standard deviation 1.6583.
+	if !ok || !near(v.Value, 6.5) || math.Abs(v.Upper-9.8166) > 1e-4 || math.Abs(v.Lower-3.1834) > 1e-4 {
+		t.Errorf("unexpected bands %+v", v)
+	}
+
+	long := Spec{Kind: "bb", Period: 5, K: 2}.New()
+	for i := range 10003 {
+		long.Add(1e6+float64(i%7)*0.01, 0)
+	}
+	if v, _ := long.Value(); v.Upper < v.Value || v.Lower > v.Value {
+		t.Errorf("bands crossed after many updates: %+v", v)
+	}
+}
+
+func history(prices ...float64) []models.PriceUpdate {
+	start := time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)
+	var updates []models.PriceUpdate
+	for i, p := range prices {
+		updates = append(updates, models.PriceUpdate{Seq: uint64(i + 1), Timestamp: start.Add(time.Duration(i) * time.Second), Price: p, Instrument: "BTC-USD"})
+	}
+	return updates
+}
+
+// TestEngine tests backfill from history, live updates, duplicate suppression and release.
+func TestEngine(t *testing.T) {
+	buffered := history(1, 2, 3, 4)
+	e := NewEngine(func() []models.PriceUpdate { return buffered })
+	sma := Spec{Kind: "sma", Period: 3}
+
+	release := e.Subscribe([]Spec{sma})
+	snap := e.Values("BTC-USD", []Spec{sma})
+	if v := snap.Values["sma:3"]; v == nil || !near(v.Value, 3) || snap.Seq != 4 {
+		t.Fatalf("expected backfilled SMA 3 at seq 4, got %+v", snap)
+	}
+
+	// Update 4 was both buffered and delivered; it must only count once.
+	live := history(1, 2, 3, 4, 8)
+	e.Update(live[3])
+	e.Update(live[4])
+	if v := e.Values("BTC-USD", []Spec{sma}).Values["sma:3"]; !near(v.Value, 5) {
+		t.Errorf("expected SMA 5 after a live update, got %v", v.Value)
+	}
+
+	if v := e.Values("ETH-USD", []Spec{sma}).Values["sma:3"]; v != nil {
+		t.Errorf("expected no value for an instrument without prices, got %+v", v)
+	}
+
+	if v, ok := e.Reading("BTC-USD", sma); !ok || !near(v.Value, 5) {
+		t.Errorf("expected a live reading of 5, got %v, %v", v.Value, ok)
+	}
+	if _, ok := e.Reading("BTC-USD", Spec{Kind: "ema", Period: 2}); ok {
+		t.Error("expected no reading for an untracked spec")
+	}
+
+	release()
+	release()
+	if len(e.tracked) != 0 {
+		t.Errorf("expected released indicators to be dropped, %d left", len(e.tracked))
+	}
+
+	// Untracked specs are computed from history on demand.
+	if v := e.Values("BTC-USD", []Spec{{Kind: "ema", Period: 2}}).Values["ema:2"]; v == nil || !near(v.Value, 3.5) {
+		t.Errorf("expected EMA 3.5 from history, got %+v", v)
+	}
+}
@@ -0,0 +1,110 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Limits on what clients may ask for, bounding the work done per update.
+const (
+	MaxPeriod = 1000
+	MaxSpecs  = 16
+)
+
+const defaultBandWidth = 2 // Bollinger band width in standard deviations
+
+// Spec names an indicator and its parameters, written kind:period, e.g. "ema:20" or "rsi:14".
+// Bollinger bands take an optional width in standard deviations: "bb:20" or "bb:20:2.5".
+type Spec struct {
+	Kind   string // sma, ema, rsi, bb or vwap
+	Period int
+	K      float64 // Band width, bb only
+}
+
+// String returns the canonical form of the spec, used as its name in events and responses.
+func (s Spec) String() string {
+	if s.Kind == "bb" && s.K != defaultBandWidth {
+		return fmt.Sprintf("%s:%d:%s", s.Kind, s.Period, strconv.FormatFloat(s.K, 'f', -1, 64))
+	}
+	return fmt.Sprintf("%s:%d", s.Kind, s.Period)
+}
+
+// New returns a fresh indicator for the spec.
+func (s Spec) New() Indicator {
+	switch s.Kind {
+	case "sma":
+		return newSMA(s.Period)
+	case "ema":
+		return newEMA(s.Period)
+	case "rsi":
+		return newRSI(s.Period)
+	case "bb":
+		return newBollinger(s.Period, s.K)
+	case "vwap":
+		return newVWAP(s.Period)
+	}
+	panic("indicators: unknown kind " + s.Kind)
+}
+
+// ParseSpec parses a single spec such as "ema:20". Kinds are case-insensitive and "bollinger" is accepted for bb.
+func ParseSpec(param string) (Spec, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(param)), ":")
+	kind := parts[0]
+	if kind == "bollinger" {
+		kind = "bb"
+	}
+	switch kind {
+	case "sma", "ema", "rsi", "vwap":
+		if len(parts) != 2 {
+			return Spec{}, fmt.Errorf("invalid indicator %q: expected %s:period", param, kind)
+		}
+	case "bb":
+		if len(parts) != 2 && len(parts) != 3 {
+			return Spec{}, fmt.Errorf("invalid indicator %q: expected bb:period or bb:period:width", param)
+		}
+	default:
+		return Spec{}, fmt.Errorf("unknown indicator %q (expected sma, ema, rsi, bb or vwap)", param)
+	}
+
+	period, err := strconv.Atoi(parts[1])
+	if err != nil || period < 1 || period > MaxPeriod {
+		return Spec{}, fmt.Errorf("invalid indicator %q: period must be between 1 and %d", param, MaxPeriod)
+	}
+	spec := Spec{Kind: kind, Period: period}
+	if kind == "bb" {
+		spec.K = defaultBandWidth
+		if len(parts) == 3 {
+			k, err := strconv.ParseFloat(parts[2], 64)
+			if err != nil || k <= 0 || math.IsInf(k, 0) || math.IsNaN(k) {
+				return Spec{}, fmt.Errorf("invalid indicator %q: width must be a positive number", param)
+			}
+			spec.K = k
+		}
+	}
+	return spec, nil
+}
+
+// ParseSpecs parses a comma-separated list of specs, dropping duplicates. It returns nil for an empty list.
+func ParseSpecs(param string) ([]Spec, error) {
+	var specs []Spec
+	seen := make(map[Spec]bool)
+	for _, item := range strings.Split(param, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		spec, err := ParseSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[spec] {
+			seen[spec] = true
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) > MaxSpecs {
+		return nil, fmt.Errorf("too many indicators: at most %d per request", MaxSpecs)
+	}
+	return specs, nil
+}
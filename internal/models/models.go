@@ -2,8 +2,58 @@ package models
 
 import "time"
 
-// PriceUpdate represents a BTC price at a specific time
+// PriceUpdate represents an instrument price (BTC-USD by default) at a specific time.
+// Seq increases by one per published update and doubles as the SSE event ID for resuming streams.
+// Everything after Instrument is optional and omitted from JSON when unknown.
 type PriceUpdate struct {
-	Timestamp time.Time `json:"timestamp"`
-	Price     float64   `json:"price"`
+	Seq        uint64    `json:"seq,omitempty"`
+	Timestamp  time.Time `json:"timestamp"` // When the server received the price
+	Price      float64   `json:"price"`
+	Instrument string    `json:"instrument,omitempty"`
+
+	// Provenance
+	Sources           []string           `json:"sources,omitempty"`           // Providers the price came from, e.g. ["coindesk"]
+	ProviderTimestamp time.Time          `json:"provider_timestamp,omitzero"` // When the provider last updated the price
+	FetchLatencyMs    float64            `json:"fetch_latency_ms,omitempty"`  // Round trip of the request that fetched it
+	DerivedFrom       string             `json:"derived_from,omitempty"`      // Native instrument a synthetic one was converted from, e.g. "BTC-USD"
+	FXRate            float64            `json:"fx_rate,omitempty"`           // Exchange rate the native price was converted at
+	Providers         map[string]float64 `json:"providers,omitempty"`         // Each provider's latest price when several are merged
+
+	// Top of book, where the provider quotes it
+	Bid float64 `json:"bid,omitempty"`
+	Ask float64 `json:"ask,omitempty"`
+
+	// Volume traded since the previous update, where the provider reports it
+	Volume float64 `json:"volume,omitempty"`
+
+	// Set when anomaly detection flagged the price as suspicious but published it anyway
+	Anomaly *Anomaly `json:"anomaly,omitempty"`
+
+	// Changes, present once a reference price is known
+	Change    *Change `json:"change,omitempty"`     // Versus the previous update of the instrument
+	Change1h  *Change `json:"change_1h,omitempty"`  // Versus the price an hour ago
+	Change24h *Change `json:"change_24h,omitempty"` // Versus the price a day ago
+}
+
+// Change compares a price with an earlier reference price.
+type Change struct {
+	From float64 `json:"from"` // Reference price
+	Abs  float64 `json:"abs"`  // Price minus From
+	Pct  float64 `json:"pct"`  // Abs as a percentage of From
+}
+
+// NewChange computes the change from a reference price to price. It returns nil for a zero reference.
+func NewChange(from, price float64) *Change {
+	if from == 0 {
+		return nil
+	}
+	return &Change{From: from, Abs: price - from, Pct: (price - from) / from * 100}
+}
+
+// Anomaly describes why a price looked suspicious next to the instrument's recent prices.
+type Anomaly struct {
+	Reasons   []string `json:"reasons"`          // Checks the price failed: "jump", "zscore" or "invalid"
+	Reference float64  `json:"reference"`        // Last price accepted as normal
+	JumpPct   float64  `json:"jump_pct"`         // Change from Reference, in percent
+	ZScore    float64  `json:"zscore,omitempty"` // Of the price's log return against the window's, when known
 }
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrTooManyPerIP = errors.New("ratelimit: too many concurrent streams for this address")
+	ErrTooMany      = errors.New("ratelimit: server is at stream capacity")
+)
+
+// ConnLimiter caps concurrent long-lived connections per key and in total.
+// A limit of zero disables that particular cap.
+type ConnLimiter struct {
+	perKey int
+	global int
+	active map[string]int
+	total  int
+	mutex  sync.Mutex
+}
+
+func NewConnLimiter(perKey, global int) *ConnLimiter {
+	return &ConnLimiter{
+		perKey: perKey,
+		global: global,
+		active: make(map[string]int),
+	}
+}
+
+// Acquire reserves a connection slot for key.
+// The returned release function must be called exactly once when the connection ends.
+func (cl *ConnLimiter) Acquire(key string) (func(), error) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	if cl.perKey > 0 && cl.active[key] >= cl.perKey {
+		return nil, ErrTooManyPerIP
+	}
+	if cl.global > 0 && cl.total >= cl.global {
+		return nil, ErrTooMany
+	}
+
+	cl.active[key]++
+	cl.total++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cl.mutex.Lock()
+			defer cl.mutex.Unlock()
+
+			cl.total--
+			if cl.active[key]--; cl.active[key] <= 0 {
+				delete(cl.active, key)
+			}
+		})
+	}, nil
+}
+
+// Active returns the number of connections currently held.
+func (cl *ConnLimiter) Active() int {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+	return cl.total
+}
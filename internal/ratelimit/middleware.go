@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// Config holds the limits applied to incoming requests. Zero values disable the corresponding limit.
+type Config struct {
+	RequestsPerSecond float64        // Sustained request rate allowed per client IP
+	Burst             int            // Requests a client IP may make in a burst above the sustained rate
+	MaxStreamsPerIP   int            // Concurrent streams allowed per client IP
+	MaxStreams        int            // Concurrent streams allowed across all clients
+	StreamRetryAfter  time.Duration  // Retry-After hint sent when a stream cap is hit
+	TrustedProxies    []netip.Prefix // Peers whose X-Forwarded-For header is believed
+}
+
+// Middleware applies request rate limits and stream caps keyed by client IP.
+type Middleware struct {
+	cfg     Config
+	limiter *Limiter
+	streams *ConnLimiter
+}
+
+func New(cfg Config) *Middleware {
+	m := &Middleware{
+		cfg:     cfg,
+		streams: NewConnLimiter(cfg.MaxStreamsPerIP, cfg.MaxStreams),
+	}
+	if cfg.RequestsPerSecond > 0 {
+		m.limiter = NewLimiter(cfg.RequestsPerSecond, max(cfg.Burst, 1))
+	}
+	return m
+}
+
+// Limit rejects requests above the per-IP request rate with 429 Too Many Requests.
+func (m *Middleware) Limit(next http.Handler) http.Handler {
+	if m.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r, m.cfg.TrustedProxies)
+		if ok, wait := m.limiter.Allow(ip); !ok {
+			reject(w, http.StatusTooManyRequests, wait, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitStreams holds a concurrency slot for the lifetime of the wrapped handler.
+// Per-IP exhaustion yields 429 while global exhaustion yields 503, both with Retry-After.
+func (m *Middleware) LimitStreams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r, m.cfg.TrustedProxies)
+		release, err := m.streams.Acquire(ip)
+		if err != nil {
+			status := http.StatusTooManyRequests
+			if errors.Is(err, ErrTooMany) {
+				status = http.StatusServiceUnavailable
+			}
+			log.Printf("[!] rejecting stream from %s: %v", ip, err)
+			reject(w, status, m.cfg.StreamRetryAfter, err.Error())
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reject writes an error response with a Retry-After header rounded up to whole seconds.
+func reject(w http.ResponseWriter, status int, retryAfter time.Duration, msg string) {
+	seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, msg, status)
+}
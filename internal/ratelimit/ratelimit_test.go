@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLimiterRefill tests that a bucket allows a burst, then refills at the configured rate.
+func TestLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(2, 3) // 2 tokens/s, burst of 3
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+
+	ok, wait := l.Allow("1.2.3.4")
+	if ok {
+		t.Fatal("expected request beyond burst to be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got %v", wait)
+	}
+
+	// Other keys have their own bucket.
+	if ok, _ := l.Allow("5.6.7.8"); !ok {
+		t.Error("expected a different IP to be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("1.2.3.4"); !ok {
+		t.Error("expected a token to be available after refill")
+	}
+}
+
+// TestLimiterSweep tests that idle, fully refilled buckets are forgotten.
+func TestLimiterSweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(1, 1)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	now = now.Add(2 * idleSweepInterval)
+	l.Allow("b")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("expected idle bucket to be swept")
+	}
+}
+
+// TestClientIP tests X-Forwarded-For handling with and without trusted proxies.
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("parse prefixes: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted peer spoofing header", "203.0.113.7:5000", "1.1.1.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.5:80", "198.51.100.2", "198.51.100.2"},
+		{"chain of trusted proxies", "10.0.0.5:80", "198.51.100.2, 192.168.1.1, 10.1.1.1", "198.51.100.2"},
+		{"client-supplied prefix ignored", "10.0.0.5:80", "6.6.6.6, 198.51.100.2", "198.51.100.2"},
+		{"garbage hop stops the walk", "10.0.0.5:80", "198.51.100.2, nonsense", "10.0.0.5"},
+		{"ipv6 peer", "[2001:db8::1]:443", "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := ClientIP(req, trusted); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConnLimiter tests per-key and global concurrency caps and slot release.
+func TestConnLimiter(t *testing.T) {
+	cl := NewConnLimiter(2, 3)
+
+	r1, err := cl.Acquire("a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cl.Acquire("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cl.Acquire("a"); err != ErrTooManyPerIP {
+		t.Errorf("expected ErrTooManyPerIP, got %v", err)
+	}
+	if _, err := cl.Acquire("b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cl.Acquire("c"); err != ErrTooMany {
+		t.Errorf("expected ErrTooMany, got %v", err)
+	}
+
+	r1()
+	r1() // releasing twice must not free a second slot
+	if cl.Active() != 2 {
+		t.Errorf("expected 2 active connections, got %d", cl.Active())
+	}
+	if _, err := cl.Acquire("c"); err != nil {
+		t.Errorf("expected slot to be available after release, got %v", err)
+	}
+}
+
+// TestMiddlewareStatusCodes tests the status codes and Retry-After headers returned when limits are hit.
+func TestMiddlewareStatusCodes(t *testing.T) {
+	m := New(Config{
+		RequestsPerSecond: 1,
+		Burst:             1,
+		MaxStreamsPerIP:   1,
+		MaxStreams:        2,
+		StreamRetryAfter:  3 * time.Second,
+	})
+
+	// A stream handler that stays open until released.
+	hold := make(chan struct{})
+	var started sync.WaitGroup
+	stream := m.LimitStreams(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-hold
+	}))
+	open := func(ip string) {
+		req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+		req.RemoteAddr = ip + ":1234"
+		stream.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve := func(h http.Handler, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	started.Add(2)
+	go open("198.51.100.1")
+	go open("198.51.100.2")
+	started.Wait()
+	defer close(hold)
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		ip         string
+		wantStatus int
+		wantRetry  string
+	}{
+		{"per-ip stream cap", stream, "198.51.100.1", http.StatusTooManyRequests, "3"},
+		{"global stream cap", stream, "198.51.100.3", http.StatusServiceUnavailable, "3"},
+		{"first request allowed", m.Limit(http.NotFoundHandler()), "203.0.113.1", http.StatusNotFound, ""},
+		{"second request limited", m.Limit(http.NotFoundHandler()), "203.0.113.1", http.StatusTooManyRequests, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handler, tt.ip)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetry {
+				t.Errorf("expected Retry-After %q, got %q", tt.wantRetry, got)
+			}
+		})
+	}
+}
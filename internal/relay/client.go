@@ -0,0 +1,146 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+const (
+	minBackoff = 250 * time.Millisecond
+	maxBackoff = 30 * time.Second
+
+	// sseTimeout is how long an SSE upstream may be silent: three of the server's default 15s heartbeats.
+	sseTimeout = 45 * time.Second
+)
+
+// Client is the relay side: it follows an upstream injective instance and hands every update to publish.
+//
+// The upstream URL selects the transport:
+//   - tcp://host:port uses the framed relay protocol served by Server
+//   - http(s)://host:port/stream uses the regular SSE endpoint
+//
+// Both resume from the last sequence number seen, so a reconnect only replays what was missed.
+type Client struct {
+	upstream  *url.URL
+	heartbeat time.Duration // Expected upstream heartbeat interval; three missed ones mean the connection is dead
+	silence   time.Duration // How long an SSE upstream may send nothing, not even a heartbeat comment
+
+	lastSeq uint64
+}
+
+func NewClient(upstream string, heartbeat time.Duration) (*Client, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp", "http", "https":
+	default:
+		return nil, fmt.Errorf("relay: unsupported upstream scheme %q (expected tcp, http or https)", u.Scheme)
+	}
+	return &Client{upstream: u, heartbeat: heartbeat, silence: sseTimeout}, nil
+}
+
+// Resume sets the sequence number to resume from, e.g. the newest entry in a warm local buffer.
+func (c *Client) Resume(seq uint64) {
+	c.lastSeq = seq
+}
+
+// Run follows the upstream until ctx is cancelled, reconnecting with exponential backoff.
+func (c *Client) Run(ctx context.Context, publish func(models.PriceUpdate) error) {
+	backoff := minBackoff
+	for {
+		start := time.Now()
+		var err error
+		if c.upstream.Scheme == "tcp" {
+			err = c.followTCP(ctx, publish)
+		} else {
+			err = c.followSSE(ctx, publish)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that stayed up for a while was healthy; start backing off from scratch.
+		if time.Since(start) > maxBackoff {
+			backoff = minBackoff
+		}
+		log.Printf("[!] relay upstream %s lost (resuming from seq %d in %v): %v", c.upstream.Redacted(), c.lastSeq, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (c *Client) followTCP(ctx context.Context, publish func(models.PriceUpdate) error) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.upstream.Host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	if err := writeFrame(w, frameResume, encodeSeq(c.lastSeq)); err != nil {
+		return err
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * c.heartbeat))
+		kind, payload, err := readFrame(r)
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case frameHello:
+			latest, err := decodeSeq(payload)
+			if err != nil {
+				return err
+			}
+			if latest < c.lastSeq {
+				log.Printf("[!] relay upstream restarted (its seq %d < ours %d), following its numbering", latest, c.lastSeq)
+			}
+			log.Printf("relay attached to %s at seq %d", c.upstream.Host, c.lastSeq)
+		case frameUpdate:
+			var update models.PriceUpdate
+			if err := json.Unmarshal(payload, &update); err != nil {
+				return err
+			}
+			if err := c.deliver(update, publish); err != nil {
+				return err
+			}
+		case frameHeartbeat:
+		default:
+			return errors.New("relay: unknown frame type")
+		}
+	}
+}
+
+// deliver publishes an update and advances the resume point.
+// The upstream already skips what we have seen, so anything it sends is accepted, including lower
+// sequence numbers after an upstream restart. Unnumbered (backfilled) updates leave the resume point alone.
+func (c *Client) deliver(update models.PriceUpdate, publish func(models.PriceUpdate) error) error {
+	if err := publish(update); err != nil {
+		return err
+	}
+	if update.Seq != 0 {
+		c.lastSeq = update.Seq
+	}
+	return nil
+}
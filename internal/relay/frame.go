@@ -0,0 +1,72 @@
+package relay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Frames on the relay connection are a one-byte type, a uvarint payload length and the payload:
+//
+//	+------+----------------+-------------------+
+//	| type | length uvarint | payload (length)  |
+//	+------+----------------+-------------------+
+//
+// The relay sends a resume frame first; the upstream answers with a hello frame, then streams
+// update frames (one JSON-encoded PriceUpdate each) interleaved with empty heartbeat frames.
+const (
+	frameResume    byte = 'R' // relay -> upstream: 8-byte big-endian last seen sequence number
+	frameHello     byte = 'O' // upstream -> relay: 8-byte big-endian latest sequence number
+	frameUpdate    byte = 'U' // upstream -> relay: JSON PriceUpdate
+	frameHeartbeat byte = 'H' // upstream -> relay: empty, keeps idle connections verifiably alive
+)
+
+// maxFrameSize bounds payload allocation so a corrupt length can't exhaust memory.
+const maxFrameSize = 64 << 10
+
+var errFrameTooLarge = errors.New("relay: frame too large")
+
+func writeFrame(w *bufio.Writer, kind byte, payload []byte) error {
+	var header [1 + binary.MaxVarintLen64]byte
+	header[0] = kind
+	n := binary.PutUvarint(header[1:], uint64(len(payload)))
+	if _, err := w.Write(header[:1+n]); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if size > maxFrameSize {
+		return 0, nil, errFrameTooLarge
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return kind, payload, nil
+}
+
+func encodeSeq(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+func decodeSeq(payload []byte) (uint64, error) {
+	if len(payload) != 8 {
+		return 0, fmt.Errorf("relay: expected 8-byte sequence, got %d bytes", len(payload))
+	}
+	return binary.BigEndian.Uint64(payload), nil
+}
@@ -0,0 +1,244 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/bus"
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/ringbuffer"
+)
+
+func collect(ch chan models.PriceUpdate) func(models.PriceUpdate) error {
+	return func(u models.PriceUpdate) error {
+		ch <- u
+		return nil
+	}
+}
+
+func expectSeqs(t *testing.T, ch <-chan models.PriceUpdate, seqs ...uint64) {
+	t.Helper()
+	for _, want := range seqs {
+		select {
+		case u := <-ch:
+			if u.Seq != want {
+				t.Fatalf("expected seq %d, got %d", want, u.Seq)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for seq %d", want)
+		}
+	}
+}
+
+// serveUpstream runs a relay Server on addr until the returned stop function is called.
+func serveUpstream(t *testing.T, addr string, history History, b bus.Bus) (string, func()) {
+	t.Helper()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewServer(history, b, 100*time.Millisecond).Serve(ctx, ln)
+		close(done)
+	}()
+	return ln.Addr().String(), func() {
+		cancel()
+		<-done
+	}
+}
+
+// TestTCPRelayResume tests backlog replay, live forwarding and resuming after the upstream restarts.
+func TestTCPRelayResume(t *testing.T) {
+	history := ringbuffer.NewRingBuffer(10, time.Minute)
+	b := bus.NewMemoryBus()
+	now := time.Now().UTC()
+	for seq := uint64(1); seq <= 3; seq++ {
+		history.Add(models.PriceUpdate{Seq: seq, Timestamp: now, Price: float64(seq)})
+	}
+
+	addr, stop := serveUpstream(t, "127.0.0.1:0", history, b)
+
+	c, err := NewClient("tcp://"+addr, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	c.Resume(1) // pretend our own buffer already holds seq 1
+
+	received := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, collect(received))
+
+	expectSeqs(t, received, 2, 3)
+
+	// The upstream subscribes to the bus before replaying history, so once the backlog has arrived live updates flow.
+	update := models.PriceUpdate{Seq: 4, Timestamp: now, Price: 4}
+	history.Add(update)
+	if err := b.Publish(context.Background(), update); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	expectSeqs(t, received, 4)
+
+	// Take the upstream down; an update produced meanwhile must arrive after it comes back.
+	stop()
+	history.Add(models.PriceUpdate{Seq: 5, Timestamp: now, Price: 5})
+	_, stop = serveUpstream(t, addr, history, b)
+	defer stop()
+
+	expectSeqs(t, received, 5)
+}
+
+// TestTCPRelayBackfill tests that a new relay also receives the upstream's unnumbered backfilled prices,
+// and resumes from the last numbered one.
+func TestTCPRelayBackfill(t *testing.T) {
+	history := ringbuffer.NewRingBuffer(10, time.Minute)
+	b := bus.NewMemoryBus()
+	now := time.Now().UTC()
+	history.Add(models.PriceUpdate{Timestamp: now.Add(-30 * time.Second), Price: 1})
+	history.Add(models.PriceUpdate{Seq: 1, Timestamp: now, Price: 2})
+
+	addr, stop := serveUpstream(t, "127.0.0.1:0", history, b)
+	c, err := NewClient("tcp://"+addr, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	received := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, collect(received))
+	expectSeqs(t, received, 0, 1)
+
+	stop()
+	history.Add(models.PriceUpdate{Seq: 2, Timestamp: now, Price: 3})
+	_, stop = serveUpstream(t, addr, history, b)
+	defer stop()
+	expectSeqs(t, received, 2)
+}
+
+// TestTCPRelayUpstreamRestart tests that a relay ahead of a restarted upstream is told its newest seq
+// and receives the upstream's new history.
+func TestTCPRelayUpstreamRestart(t *testing.T) {
+	history := ringbuffer.NewRingBuffer(10, time.Minute)
+	now := time.Now().UTC()
+	for seq := uint64(1); seq <= 3; seq++ {
+		history.Add(models.PriceUpdate{Seq: seq, Timestamp: now, Price: float64(seq)})
+	}
+	addr, stop := serveUpstream(t, "127.0.0.1:0", history, bus.NewMemoryBus())
+
+	c, err := NewClient("tcp://"+addr, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	received := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, collect(received))
+	expectSeqs(t, received, 1, 2, 3)
+
+	// The upstream comes back with its history and numbering started over.
+	stop()
+	history = ringbuffer.NewRingBuffer(10, time.Minute)
+	for seq := uint64(1); seq <= 2; seq++ {
+		history.Add(models.PriceUpdate{Seq: seq, Timestamp: now, Price: float64(10 + seq)})
+	}
+	_, stop = serveUpstream(t, addr, history, bus.NewMemoryBus())
+	defer stop()
+	expectSeqs(t, received, 1, 2)
+}
+
+// TestSSERelayResume tests following an upstream's /stream endpoint with Last-Event-ID resumption.
+func TestSSERelayResume(t *testing.T) {
+	var connections atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch connections.Add(1) {
+		case 1:
+			if id := r.Header.Get("Last-Event-ID"); id != "" {
+				t.Errorf("unexpected Last-Event-ID %q on first connection", id)
+			}
+			for seq := 1; seq <= 2; seq++ {
+				fmt.Fprintf(w, "id: %d\ndata: {\"seq\":%d,\"price\":%d}\n\n", seq, seq, seq)
+			}
+			// Named events other than shutdown are ignored by the relay.
+			fmt.Fprint(w, "event: auth_expired\ndata: {}\n\n")
+			fmt.Fprint(w, "event: shutdown\nretry: 100\ndata: {}\n\n")
+		default:
+			if id := r.Header.Get("Last-Event-ID"); id != "2" {
+				t.Errorf("expected Last-Event-ID 2 on reconnect, got %q", id)
+			}
+			fmt.Fprint(w, "id: 3\ndata: {\"seq\":3,\"price\":3}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer upstream.Close()
+
+	c, err := NewClient(upstream.URL+"/stream", time.Second)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	received := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, collect(received))
+
+	expectSeqs(t, received, 1, 2, 3)
+}
+
+// TestSSERelayHeartbeats tests that heartbeat comments keep an SSE upstream attached, while a silent one is dropped.
+func TestSSERelayHeartbeats(t *testing.T) {
+	var connections atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if connections.Add(1) > 1 {
+			// Reconnected after going silent.
+			fmt.Fprint(w, "id: 9\ndata: {\"seq\":9,\"price\":9}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		for seq := 1; seq <= 3; seq++ {
+			fmt.Fprintf(w, "id: %d\ndata: {\"seq\":%d,\"price\":%d}\n\n", seq, seq, seq)
+			for range 3 {
+				fmt.Fprint(w, ": heartbeat\n\n")
+				w.(http.Flusher).Flush()
+				time.Sleep(40 * time.Millisecond)
+			}
+		}
+		<-r.Context().Done() // Then silence
+	}))
+	defer upstream.Close()
+
+	c, err := NewClient(upstream.URL+"/stream", time.Second)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	c.silence = 200 * time.Millisecond
+
+	received := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, collect(received))
+
+	expectSeqs(t, received, 1, 2, 3)
+	if n := connections.Load(); n != 1 {
+		t.Errorf("expected heartbeats to keep the first connection, got %d connections", n)
+	}
+	expectSeqs(t, received, 9)
+}
+
+// TestNewClientRejectsUnknownScheme tests upstream URL validation.
+func TestNewClientRejectsUnknownScheme(t *testing.T) {
+	if _, err := NewClient("udp://localhost:9090", time.Second); err == nil {
+		t.Error("expected error for udp upstream")
+	}
+}
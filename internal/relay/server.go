@@ -0,0 +1,147 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/bus"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// History supplies recent updates for resuming relays; *ringbuffer.RingBuffer satisfies it.
+type History interface {
+	Since(since time.Time) []models.PriceUpdate
+}
+
+// Server is the upstream side: it streams every update on the local bus to connected relays.
+type Server struct {
+	history   History
+	bus       bus.Bus
+	heartbeat time.Duration
+}
+
+func NewServer(history History, b bus.Bus, heartbeat time.Duration) *Server {
+	return &Server{history: history, bus: b, heartbeat: heartbeat}
+}
+
+// Serve accepts relay connections on ln until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.handle(ctx, conn); err != nil && ctx.Err() == nil {
+				log.Printf("[!] relay %s disconnected: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// handle resumes a relay from its last sequence number, then streams live updates and heartbeats.
+func (s *Server) handle(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	kind, payload, err := readFrame(r)
+	if err != nil {
+		return err
+	}
+	if kind != frameResume {
+		return errors.New("expected resume frame")
+	}
+	lastSeq, err := decodeSeq(payload)
+	if err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	// Subscribe before reading history so nothing published in between is lost; duplicates are skipped by seq.
+	live, err := s.bus.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A relay that is new, or ahead of us because we restarted and our numbering began again, gets
+	// everything, including backfilled prices, which are unnumbered. Others get what they missed.
+	backlog := s.history.Since(time.Time{})
+	var latest uint64
+	for _, update := range backlog {
+		latest = max(latest, update.Seq)
+	}
+	if lastSeq != 0 && lastSeq <= latest {
+		backlog = slices.DeleteFunc(backlog, func(u models.PriceUpdate) bool { return u.Seq <= lastSeq })
+	}
+	// The hello carries our newest seq, so a relay that is ahead of us notices we restarted.
+	if err := writeFrame(w, frameHello, encodeSeq(latest)); err != nil {
+		return err
+	}
+
+	// replayed marks where history ends so live updates that were also replayed are skipped.
+	var replayed uint64
+	for _, update := range backlog {
+		if err := s.send(w, update); err != nil {
+			return err
+		}
+		replayed = max(replayed, update.Seq)
+	}
+	log.Printf("relay %s attached at seq %d (%d replayed)", conn.RemoteAddr(), lastSeq, len(backlog))
+
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(s.heartbeat))
+			if err := writeFrame(w, frameHeartbeat, nil); err != nil {
+				return err
+			}
+		case update, ok := <-live:
+			if !ok {
+				return nil
+			}
+			if update.Seq <= replayed {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(s.heartbeat))
+			if err := s.send(w, update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) send(w *bufio.Writer, update models.PriceUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, frameUpdate, payload)
+}
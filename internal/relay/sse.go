@@ -0,0 +1,95 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// followSSE consumes the upstream's /stream endpoint, resuming with Last-Event-ID.
+// Only unnamed (price) events are relayed; a shutdown event ends the connection so we reconnect elsewhere.
+// Heartbeat comments keep the connection alive; a stream silent for longer than c.silence is dropped.
+func (c *Client) followSSE(ctx context.Context, publish func(models.PriceUpdate) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var silent atomic.Bool
+	watchdog := time.AfterFunc(c.silence, func() {
+		silent.Store(true)
+		cancel()
+	})
+	defer watchdog.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.upstream.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.lastSeq != 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(c.lastSeq, 10))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream responded %s", resp.Status)
+	}
+	log.Printf("relay attached to %s at seq %d", c.upstream.Redacted(), c.lastSeq)
+
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		watchdog.Reset(c.silence)
+		line := scanner.Text()
+		if line != "" {
+			if strings.HasPrefix(line, ":") {
+				continue // comment, e.g. heartbeat
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = value
+			}
+			continue
+		}
+
+		// A blank line dispatches the event.
+		switch event {
+		case "":
+			if data == "" {
+				break
+			}
+			var update models.PriceUpdate
+			if err := json.Unmarshal([]byte(data), &update); err != nil {
+				return err
+			}
+			if err := c.deliver(update, publish); err != nil {
+				return err
+			}
+		case "shutdown":
+			return fmt.Errorf("upstream shutting down")
+		}
+		event, data = "", ""
+	}
+	if silent.Load() {
+		return fmt.Errorf("upstream silent for %v", c.silence)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("upstream closed the stream")
+}
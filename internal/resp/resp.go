@@ -0,0 +1,124 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Conn is a minimal Redis protocol (RESP2) connection covering what injective needs:
+// sending commands as arrays of bulk strings and reading simple, error, integer, bulk and array replies.
+// A Conn is not safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// Error is an error reply sent by the server, as opposed to a transport failure.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Dial connects to addr and authenticates when a password is given.
+func Dial(ctx context.Context, addr, password string) (*Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := NewConn(conn)
+
+	if password != "" {
+		if _, err := c.Do(5*time.Second, "AUTH", password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// NewConn wraps an established network connection.
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+}
+
+// Do sends a command and reads its reply within the timeout.
+func (c *Conn) Do(timeout time.Duration, args ...string) (any, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := c.Send(args...); err != nil {
+		return nil, err
+	}
+	return c.Read()
+}
+
+// Send writes a command without waiting for the reply.
+func (c *Conn) Send(args ...string) error {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return c.w.Flush()
+}
+
+// Read parses one reply. Bulk strings become string, arrays []any, integers int64 and a nil bulk string nil.
+// Error replies are returned as Error.
+func (c *Conn) Read() (any, error) {
+	return ReadValue(c.r)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// ReadValue parses one RESP value from r. It is exported for servers, including test fakes.
+func ReadValue(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = ReadValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+	}
+}
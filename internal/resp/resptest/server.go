@@ -0,0 +1,313 @@
+// Package resptest provides an in-process stand-in for redis-server, for tests.
+//
+// It implements the handful of commands injective uses: AUTH, PING, SUBSCRIBE, PUBLISH,
+// GET, SET (with NX/XX/PX), DEL and PEXPIRE. Lua scripts cannot be interpreted, so EVAL
+// dispatches to Go implementations registered with HandleScript.
+package resptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/resp"
+)
+
+// ScriptFunc emulates a Lua script. It runs atomically with respect to other commands.
+type ScriptFunc func(db *DB, keys, args []string) any
+
+// Server is a fake Redis server listening on a loopback port.
+type Server struct {
+	ln       net.Listener
+	password string
+	db       *DB
+	scripts  map[string]ScriptFunc
+
+	subscribers map[net.Conn]string // connection -> subscribed channel
+	conns       map[net.Conn]struct{}
+	mutex       sync.Mutex // Guards everything above and serialises commands, like Redis' single thread
+}
+
+// NewServer starts a fake server that requires password when it is non-empty.
+// It is closed automatically when the test ends.
+func NewServer(t testing.TB, password string) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("resptest: listen: %v", err)
+	}
+	s := &Server{
+		ln:          ln,
+		password:    password,
+		db:          &DB{data: make(map[string]entry)},
+		scripts:     make(map[string]ScriptFunc),
+		subscribers: make(map[net.Conn]string),
+		conns:       make(map[net.Conn]struct{}),
+	}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// HandleScript registers fn as the implementation of the given Lua script source.
+func (s *Server) HandleScript(script string, fn ScriptFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.scripts[script] = fn
+}
+
+// Close stops the listener and drops every connection, simulating the server going away.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.DropConnections()
+}
+
+// DropConnections closes all client connections, simulating a broker restart or network blip.
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.subscribers, conn)
+	}
+}
+
+// SubscriberCount returns the number of connections in subscribe mode.
+func (s *Server) SubscriberCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.subscribers)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.subscribers, conn)
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		var reply any
+		if cmd := strings.ToUpper(args[0]); cmd == "AUTH" {
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				reply = "OK"
+			} else {
+				reply = resp.Error("WRONGPASS invalid password")
+			}
+		} else if !authed {
+			reply = resp.Error("NOAUTH Authentication required.")
+		} else {
+			reply = s.exec(conn, cmd, args[1:])
+		}
+		conn.Write(encode(reply))
+		s.mutex.Unlock()
+	}
+}
+
+// exec runs a command other than AUTH. Callers must hold the mutex.
+func (s *Server) exec(conn net.Conn, cmd string, args []string) any {
+	switch cmd {
+	case "PING":
+		return "PONG"
+	case "SUBSCRIBE":
+		s.subscribers[conn] = args[0]
+		return []any{"subscribe", args[0], int64(1)}
+	case "PUBLISH":
+		n := int64(0)
+		for sub, ch := range s.subscribers {
+			if ch == args[0] {
+				sub.Write(encode([]any{"message", ch, args[1]}))
+				n++
+			}
+		}
+		return n
+	case "GET":
+		if v, ok := s.db.Get(args[0]); ok {
+			return v
+		}
+		return nil
+	case "SET":
+		return s.set(args)
+	case "DEL":
+		if s.db.Del(args[0]) {
+			return int64(1)
+		}
+		return int64(0)
+	case "PEXPIRE":
+		ms, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return resp.Error("ERR value is not an integer")
+		}
+		if s.db.Expire(args[0], time.Duration(ms)*time.Millisecond) {
+			return int64(1)
+		}
+		return int64(0)
+	case "EVAL":
+		fn, ok := s.scripts[args[0]]
+		if !ok {
+			return resp.Error("NOSCRIPT script not registered with resptest")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n > len(args)-2 {
+			return resp.Error("ERR invalid number of keys")
+		}
+		return fn(s.db, args[2:2+n], args[2+n:])
+	default:
+		return resp.Error("ERR unknown command '" + cmd + "'")
+	}
+}
+
+// set implements SET key value [NX|XX] [PX milliseconds].
+func (s *Server) set(args []string) any {
+	key, value := args[0], args[1]
+	var nx, xx bool
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "PX":
+			if i+1 >= len(args) {
+				return resp.Error("ERR syntax error")
+			}
+			ms, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return resp.Error("ERR value is not an integer")
+			}
+			ttl = time.Duration(ms) * time.Millisecond
+			i++
+		default:
+			return resp.Error("ERR syntax error")
+		}
+	}
+
+	_, exists := s.db.Get(key)
+	if (nx && exists) || (xx && !exists) {
+		return nil
+	}
+	s.db.Set(key, value, ttl)
+	return "OK"
+}
+
+// DB is the fake server's keyspace, handed to script implementations.
+type DB struct {
+	data map[string]entry
+}
+
+type entry struct {
+	value   string
+	expires time.Time // zero means no expiry
+}
+
+func (db *DB) Get(key string) (string, bool) {
+	e, ok := db.data[key]
+	if !ok {
+		return "", false
+	}
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		delete(db.data, key)
+		return "", false
+	}
+	return e.value, true
+}
+
+// Set stores value under key, expiring after ttl when ttl is positive.
+func (db *DB) Set(key, value string, ttl time.Duration) {
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	db.data[key] = e
+}
+
+func (db *DB) Del(key string) bool {
+	_, ok := db.Get(key)
+	delete(db.data, key)
+	return ok
+}
+
+func (db *DB) Expire(key string, ttl time.Duration) bool {
+	v, ok := db.Get(key)
+	if ok {
+		db.Set(key, v, ttl)
+	}
+	return ok
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	v, err := resp.ReadValue(r)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]any)
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("resptest: expected command array, got %v", v)
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		args[i], _ = item.(string)
+	}
+	return args, nil
+}
+
+// encode serialises a reply value in RESP2.
+func encode(v any) []byte {
+	var b strings.Builder
+	writeValue(&b, v)
+	return []byte(b.String())
+}
+
+func writeValue(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("$-1\r\n")
+	case resp.Error:
+		fmt.Fprintf(b, "-%s\r\n", string(v))
+	case int64:
+		fmt.Fprintf(b, ":%d\r\n", v)
+	case int:
+		fmt.Fprintf(b, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(b, "$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		fmt.Fprintf(b, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeValue(b, item)
+		}
+	default:
+		panic(fmt.Sprintf("resptest: cannot encode %T", v))
+	}
+}
@@ -89,3 +89,24 @@ func (rb *RingBuffer) Since(since time.Time) []models.PriceUpdate {
 
 	return result
 }
+
+// After returns all updates with a sequence number greater than seq that are still valid per TTL.
+// It backs SSE resumption via the Last-Event-ID header, which carries the last sequence number a client saw.
+func (rb *RingBuffer) After(seq uint64) []models.PriceUpdate {
+	rb.mutex.Lock()
+	defer rb.mutex.Unlock()
+
+	now := time.Now().UTC()
+	result := make([]models.PriceUpdate, 0, rb.count)
+	start := (rb.head + len(rb.data) - rb.count) % len(rb.data)
+
+	for i := 0; i < rb.count; i++ {
+		u := rb.data[(start+i)%len(rb.data)]
+		if u.Seq <= seq || now.Sub(u.Timestamp) > rb.ttl {
+			continue
+		}
+		result = append(result, u)
+	}
+
+	return result
+}
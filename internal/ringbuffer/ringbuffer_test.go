@@ -98,3 +98,27 @@ func TestRingBufferRace(t *testing.T) {
 	time.Sleep(500 * time.Millisecond)
 	close(stop)
 }
+
+// TestAfter tests that After returns only updates with a higher sequence number.
+func TestAfter(t *testing.T) {
+	rb := ringbuffer.NewRingBuffer(3, time.Minute)
+	now := time.Now().UTC()
+
+	for seq := uint64(1); seq <= 4; seq++ {
+		rb.Add(models.PriceUpdate{Seq: seq, Timestamp: now, Price: float64(seq)})
+	}
+
+	updates := rb.After(2)
+	if len(updates) != 2 || updates[0].Seq != 3 || updates[1].Seq != 4 {
+		t.Errorf("expected seqs [3 4], got %+v", updates)
+	}
+
+	// Seq 1 was overwritten, so resuming from 0 yields what is left.
+	if updates := rb.After(0); len(updates) != 3 {
+		t.Errorf("expected 3 updates, got %d", len(updates))
+	}
+
+	if updates := rb.After(4); len(updates) != 0 {
+		t.Errorf("expected no updates after latest seq, got %d", len(updates))
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/matheusdutrademoura/injective/internal/alerts"
+	"github.com/matheusdutrademoura/injective/internal/auth"
+	"github.com/matheusdutrademoura/injective/internal/expr"
+)
+
+const maxRuleBytes = 64 << 10 // Largest rule definition accepted
+
+// alertsHandler serves the alert rule API:
+//
+//	GET    /alerts                          list rules
+//	POST   /alerts                          create a rule; the response is the only one showing its secret
+//	GET    /alerts/{id}                     show a rule
+//	PUT    /alerts/{id}                     replace a rule
+//	DELETE /alerts/{id}                     delete a rule
+//	GET    /alerts/dead-letters             list undeliverable notifications
+//	POST   /alerts/dead-letters/{id}/retry  queue a dead letter again
+//
+// Rules belong to the token's subject and may only watch instruments it allows; tokens without a subject
+// are refused, as their rules would be shared by every such token. With leader election only the leader
+// manages alerts; other replicas answer 503 so clients retry until they reach it.
+func (s *Server) alertsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /alerts", func(w http.ResponseWriter, r *http.Request) {
+		rules := s.alerts.Rules(owner(r))
+		for i := range rules {
+			rules[i].Secret = ""
+		}
+		writeJSON(w, http.StatusOK, orEmpty(rules))
+	})
+	mux.HandleFunc("POST /alerts", func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := readRule(w, r)
+		if !ok {
+			return
+		}
+		created, err := s.alerts.Create(owner(r), rule)
+		if err != nil {
+			alertError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/alerts/"+created.ID)
+		writeJSON(w, http.StatusCreated, created)
+	})
+	mux.HandleFunc("GET /alerts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rule, err := s.alerts.Rule(owner(r), r.PathValue("id"))
+		if err != nil {
+			alertError(w, err)
+			return
+		}
+		rule.Secret = ""
+		writeJSON(w, http.StatusOK, rule)
+	})
+	mux.HandleFunc("PUT /alerts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := readRule(w, r)
+		if !ok {
+			return
+		}
+		updated, err := s.alerts.Update(owner(r), r.PathValue("id"), rule)
+		if err != nil {
+			alertError(w, err)
+			return
+		}
+		updated.Secret = ""
+		writeJSON(w, http.StatusOK, updated)
+	})
+	mux.HandleFunc("DELETE /alerts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.alerts.Delete(owner(r), r.PathValue("id")); err != nil {
+			alertError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /alerts/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, orEmpty(s.alerts.DeadLetters(owner(r))))
+	})
+	mux.HandleFunc("POST /alerts/dead-letters/{id}/retry", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.alerts.Retry(owner(r), r.PathValue("id")); err != nil {
+			alertError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if owner(r) == "" {
+			http.Error(w, "alerts require a token with a subject (sub)", http.StatusForbidden)
+			return
+		}
+		if !s.leading() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(reconnectHint.Seconds())))
+			http.Error(w, "alerts are managed by the leader", http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// readRule decodes and validates a rule from the request body, answering 400 or 403 itself when it can't.
+func readRule(w http.ResponseWriter, r *http.Request) (alerts.Rule, bool) {
+	var rule alerts.Rule
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRuleBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rule); err != nil {
+		http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+		return rule, false
+	}
+	if err := rule.Validate(); err != nil {
+		var exprErr *expr.Error
+		if errors.As(err, &exprErr) {
+			http.Error(w, "invalid rule expr:\n"+exprErr.Show(rule.Expr), http.StatusBadRequest)
+		} else {
+			http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+		}
+		return rule, false
+	}
+	if claims := auth.ClaimsFrom(r.Context()); claims != nil && !claims.Allows(rule.Instrument) {
+		http.Error(w, fmt.Sprintf("instrument %s not allowed", rule.Instrument), http.StatusForbidden)
+		return rule, false
+	}
+	return rule, true
+}
+
+// owner returns whose rules a request acts on: the token subject, or "" when there is none.
+func owner(r *http.Request) string {
+	if claims := auth.ClaimsFrom(r.Context()); claims != nil {
+		return claims.Subject
+	}
+	return ""
+}
+
+func alertError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, alerts.ErrNotFound):
+		http.Error(w, "alert not found", http.StatusNotFound)
+		return
+	case errors.Is(err, alerts.ErrWebhookNotAllowed):
+		http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[!] saving alerts: %v", err)
+	http.Error(w, "could not save alert", http.StatusInternalServerError)
+}
+
+// orEmpty makes empty lists encode as [] rather than null.
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
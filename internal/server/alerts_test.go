@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/alerts"
+	"github.com/matheusdutrademoura/injective/internal/auth"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// TestAlertsAPI tests rule CRUD per token subject and webhook delivery when a delivered price crosses a level.
+func TestAlertsAPI(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("JWT_SECRET", "alerts-secret")
+	t.Setenv("ALERTS_ALLOW_PRIVATE", "true") // The test webhook listens on loopback
+	s := NewServer()
+	handler := s.Handler()
+
+	token := func(sub string) string {
+		tok, err := auth.Sign(auth.Claims{Subject: sub, ExpiresAt: time.Now().Add(time.Minute).Unix(), Instruments: []string{"BTC-USD"}}, []byte("alerts-secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	do := func(method, target, sub, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token(sub))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	received := make(chan *http.Request, 1)
+	var secret string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Injective-Timestamp"), 10, 64)
+		if r.Header.Get("X-Injective-Signature") != alerts.Signature(secret, ts, body) {
+			t.Errorf("bad signature on %s", body)
+		}
+		received <- r
+	}))
+	defer hook.Close()
+
+	rec := do(http.MethodPost, "/alerts", "alice", `{"instrument":"btc-usd","kind":"cross_above","level":100,"webhook":"`+hook.URL+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var rule alerts.Rule
+	json.Unmarshal(rec.Body.Bytes(), &rule)
+	if rule.Secret == "" || rule.Owner != "alice" || rec.Header().Get("Location") != "/alerts/"+rule.ID {
+		t.Fatalf("unexpected creation response %s", rec.Body)
+	}
+	secret = rule.Secret
+
+	if rec := do(http.MethodGet, "/alerts", "alice", ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), secret) || !strings.Contains(rec.Body.String(), rule.ID) {
+		t.Errorf("expected the rule listed without its secret, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodGet, "/alerts", "bob", ""); rec.Body.String() != "[]\n" {
+		t.Errorf("expected bob to see no rules, got %s", rec.Body)
+	}
+	if rec := do(http.MethodGet, "/alerts/"+rule.ID, "bob", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for bob, got %d", rec.Code)
+	}
+	for _, body := range []string{`{"instrument":"BTC-USD","kind":"cross_above"}`, `{"bogus":1}`, `not json`} {
+		if rec := do(http.MethodPost, "/alerts", "alice", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+	if rec := do(http.MethodPost, "/alerts", "alice", `{"instrument":"BTC-USD","kind":"expression","expr":"price > ema(50) and","webhook":"http://x"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "column 20") {
+		t.Errorf("expected a positioned expression error, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/alerts", "alice", `{"instrument":"ETH-USD","kind":"stale","window":"1m","webhook":"http://x"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an instrument outside the token, got %d", rec.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.alerts.Run(ctx)
+	now := time.Now().UTC()
+	s.deliver(models.PriceUpdate{Seq: 1, Timestamp: now, Price: 99, Instrument: "BTC-USD"})
+	s.deliver(models.PriceUpdate{Seq: 2, Timestamp: now.Add(time.Second), Price: 101, Instrument: "BTC-USD"})
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a webhook when the price crossed 100")
+	}
+
+	if rec := do(http.MethodDelete, "/alerts/"+rule.ID, "alice", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/alerts/"+rule.ID, "alice", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the rule gone, got %d", rec.Code)
+	}
+
+	// Without a subject, rules would be shared by every such token.
+	if rec := do(http.MethodPost, "/alerts", "", `{"instrument":"BTC-USD","kind":"cross_above","level":100,"webhook":"`+hook.URL+`"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a token without a subject, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/alerts", "", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 listing without a subject, got %d", rec.Code)
+	}
+}
+
+// TestAlertsRestricted tests that alerts are off without authentication, webhooks are limited to
+// ALERTS_WEBHOOK_HOSTS, and with leader election followers neither manage nor evaluate rules.
+func TestAlertsRestricted(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	if s := NewServer(); s.alerts != nil {
+		t.Error("expected alerts off without JWT_SECRET")
+	}
+
+	received := make(chan struct{}, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { received <- struct{}{} }))
+	defer hook.Close()
+	t.Setenv("JWT_SECRET", "alerts-secret")
+	t.Setenv("ALERTS_WEBHOOK_HOSTS", "127.0.0.1")
+	t.Setenv("ALERTS_ALLOW_PRIVATE", "true")
+	t.Setenv("ELECTION", "file")
+	t.Setenv("ELECTION_FILE", filepath.Join(t.TempDir(), "lease"))
+	s := NewServer()
+	handler := s.Handler()
+	tok, err := auth.Sign(auth.Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Minute).Unix()}, []byte("alerts-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := func(webhook string) *httptest.ResponseRecorder {
+		body := `{"instrument":"BTC-USD","kind":"cross_above","level":100,"webhook":"` + webhook + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/alerts", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tok)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	cross := func() {
+		now := time.Now().UTC()
+		s.deliver(models.PriceUpdate{Seq: 1, Timestamp: now, Price: 99, Instrument: "BTC-USD"})
+		s.deliver(models.PriceUpdate{Seq: 2, Timestamp: now.Add(time.Second), Price: 101, Instrument: "BTC-USD"})
+	}
+
+	if rec := create(hook.URL); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 on a follower, got %d", rec.Code)
+	}
+	s.alerts.Create("alice", alerts.Rule{Instrument: "BTC-USD", Kind: alerts.CrossAbove, Level: 100, Webhook: hook.URL})
+	cross() // Not evaluated
+
+	s.publishing.Store(true) // As the leader's Broadcaster does
+	if rec := create("http://169.254.169.254/latest/meta-data"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a webhook outside ALERTS_WEBHOOK_HOSTS, got %d: %s", rec.Code, rec.Body)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.alerts.Run(ctx)
+	cross()
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the leader to notify")
+	}
+	select {
+	case <-received:
+		t.Error("expected a single notification, the follower's crossing unevaluated")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/expr"
+	"github.com/matheusdutrademoura/injective/internal/indicators"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// moveFilter passes only updates that moved far enough from the last price sent to a client,
+// per instrument. The first update of each instrument always passes.
+type moveFilter struct {
+	minBps float64 // Minimum move in basis points of the last sent price
+	minAbs float64 // Minimum absolute move
+	last   map[string]float64
+}
+
+// parseMoveFilter reads ?min_change_bps= and ?min_change_abs=. It returns nil when neither is set.
+// With both set an update must clear both thresholds.
+func parseMoveFilter(q url.Values) (*moveFilter, error) {
+	f := &moveFilter{last: make(map[string]float64)}
+	for param, dst := range map[string]*float64{"min_change_bps": &f.minBps, "min_change_abs": &f.minAbs} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("invalid %s %q: expected a non-negative number", param, v)
+		}
+		*dst = n
+	}
+	if f.minBps == 0 && f.minAbs == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// allow reports whether u should be sent and, if so, remembers its price as the new reference.
+// A nil filter allows everything.
+func (f *moveFilter) allow(u models.PriceUpdate) bool {
+	if f == nil {
+		return true
+	}
+	if prev, ok := f.last[u.Instrument]; ok {
+		move := math.Abs(u.Price - prev)
+		if move < f.minAbs || (prev != 0 && move/math.Abs(prev)*10000 < f.minBps) {
+			return false
+		}
+	}
+	f.last[u.Instrument] = u.Price
+	return true
+}
+
+// parseThrottle reads ?interval= (a duration, e.g. 30s) or ?max_rate= (updates per unit, e.g. 1/s,
+// 10/m, or a plain number per second) and returns the minimum gap between updates, 0 when unset.
+func parseThrottle(q url.Values) (time.Duration, error) {
+	interval, rate := q.Get("interval"), q.Get("max_rate")
+	switch {
+	case interval != "" && rate != "":
+		return 0, errors.New("use either interval or max_rate, not both")
+	case interval != "":
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid interval %q: expected a positive duration such as 30s", interval)
+		}
+		return d, nil
+	case rate != "":
+		count, per, _ := strings.Cut(rate, "/")
+		unit, ok := map[string]time.Duration{"": time.Second, "s": time.Second, "m": time.Minute, "h": time.Hour}[per]
+		n, err := strconv.ParseFloat(count, 64)
+		if !ok || err != nil || n <= 0 || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("invalid max_rate %q: expected a positive rate such as 1/s or 10/m", rate)
+		}
+		return time.Duration(float64(unit) / n), nil
+	}
+	return 0, nil
+}
+
+// parseWhere compiles ?where=, e.g. `price > ema(50) and change(1h) < -3%`. It returns nil when unset.
+// Errors show the expression with a caret under the offending position.
+func parseWhere(q url.Values) (*expr.Program, error) {
+	src := q.Get("where")
+	if src == "" {
+		return nil, nil
+	}
+	p, err := expr.Compile(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where:\n%s", showExprError(src, err))
+	}
+	return p, nil
+}
+
+// showExprError renders compile errors with their position marked.
+func showExprError(src string, err error) string {
+	var e *expr.Error
+	if errors.As(err, &e) {
+		return e.Show(src)
+	}
+	return err.Error()
+}
+
+// exprEnv gives expressions the server's indicators and price history.
+type exprEnv struct{ s *Server }
+
+func (e exprEnv) Indicator(instrument string, spec indicators.Spec) (indicators.Value, bool) {
+	return e.s.indicators.Reading(instrument, spec)
+}
+
+func (e exprEnv) PriceAt(instrument string, t time.Time) (float64, bool) {
+	return e.s.enricher.PriceAt(instrument, t)
+}
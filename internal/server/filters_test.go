@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// TestMoveFilter tests that updates pass only when they move far enough from the last price sent.
+func TestMoveFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		prices []float64
+		want   []float64
+	}{
+		{"bps", "min_change_bps=10", []float64{1000, 1000.5, 1000.9, 1001, 1001.5, 1002.1}, []float64{1000, 1001, 1002.1}},
+		{"abs", "min_change_abs=5", []float64{100, 104, 96, 95, 101}, []float64{100, 95, 101}},
+		{"both", "min_change_bps=100&min_change_abs=5", []float64{100, 102, 106, 111}, []float64{100, 106, 111}},
+		{"drops count too", "min_change_abs=5", []float64{100, 90, 94, 84}, []float64{100, 90, 84}},
+		{"unset", "", []float64{100, 100, 100.01}, []float64{100, 100, 100.01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			f, err := parseMoveFilter(q)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var got []float64
+			for _, p := range tt.prices {
+				if f.allow(models.PriceUpdate{Instrument: "BTC-USD", Price: p}) {
+					got = append(got, p)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestMoveFilterPerInstrument tests that each instrument has its own reference price.
+func TestMoveFilterPerInstrument(t *testing.T) {
+	f, _ := parseMoveFilter(url.Values{"min_change_abs": {"5"}})
+	for _, u := range []models.PriceUpdate{{Instrument: "BTC-USD", Price: 100}, {Instrument: "ETH-USD", Price: 101}} {
+		if !f.allow(u) {
+			t.Errorf("expected first %s update to pass", u.Instrument)
+		}
+	}
+}
+
+// TestSseHandlerMoveFilter tests that replayed and live updates are filtered and bad thresholds rejected.
+func TestSseHandlerMoveFilter(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	s := NewServer()
+	now := time.Now().UTC()
+	for i, p := range []float64{100, 100.5, 103, 103.2} {
+		s.updateBuffer.Add(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: now, Price: p, Instrument: "BTC-USD"})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?min_change_abs=2&since=0", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	ctx, cancel := context.WithTimeout(req.Context(), 50*time.Millisecond)
+	defer cancel()
+	s.SseHandler(w, req.WithContext(ctx))
+
+	body := w.Body.String()
+	if strings.Count(body, "data:") != 2 || !strings.Contains(body, `"price":103,`) || strings.Contains(body, "100.5") {
+		t.Errorf("expected prices 100 and 103 only, got:\n%s", body)
+	}
+
+	for _, q := range []string{"min_change_bps=-1", "min_change_abs=lots"} {
+		rec := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+		s.SseHandler(rec, httptest.NewRequest(http.MethodGet, "/stream?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rec.Code)
+		}
+	}
+}
+
+// TestParseThrottle tests the interval and max_rate parameters.
+func TestParseThrottle(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"interval=30s", 30 * time.Second, false},
+		{"max_rate=1/s", time.Second, false},
+		{"max_rate=4", 250 * time.Millisecond, false},
+		{"max_rate=10/m", 6 * time.Second, false},
+		{"max_rate=0.5/h", 2 * time.Hour, false},
+		{"interval=0s", 0, true},
+		{"interval=soon", 0, true},
+		{"max_rate=1/d", 0, true},
+		{"max_rate=-1/s", 0, true},
+		{"interval=1s&max_rate=1/s", 0, true},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		got, err := parseThrottle(q)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseThrottle(%q) = %v, %v; want %v, error %v", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestSseHandlerThrottled tests that ?interval slows a live stream down to one update per period.
+func TestSseHandlerThrottled(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("UPDATE_INTERVAL", "10ms")
+	s := NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?interval=100ms", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	reqCtx, stop := context.WithTimeout(req.Context(), 450*time.Millisecond)
+	defer stop()
+	s.SseHandler(w, req.WithContext(reqCtx))
+
+	// About 45 updates were published; the client should see one per 100ms.
+	if n := strings.Count(w.Body.String(), "data:"); n < 3 || n > 6 {
+		t.Errorf("expected about 5 updates, got %d", n)
+	}
+}
+
+// TestSseHandlerWhere tests that ?where= filters updates and reports expression errors by position.
+func TestSseHandlerWhere(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	s := NewServer()
+	now := time.Now().UTC()
+	for i, p := range []float64{100, 104, 99, 103} {
+		u := models.PriceUpdate{Seq: uint64(i + 1), Timestamp: now, Price: p, Instrument: "BTC-USD"}
+		if i > 0 {
+			u.Change = models.NewChange(100, p)
+		}
+		s.updateBuffer.Add(u)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?since=0&where="+url.QueryEscape("price > 100 and change() > 3.5%"), nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	ctx, cancel := context.WithTimeout(req.Context(), 50*time.Millisecond)
+	defer cancel()
+	s.SseHandler(w, req.WithContext(ctx))
+
+	body := w.Body.String()
+	if strings.Count(body, "data:") != 1 || !strings.Contains(body, `"price":104,`) {
+		t.Errorf("expected only the update at 104, got:\n%s", body)
+	}
+
+	rec := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	s.SseHandler(rec, httptest.NewRequest(http.MethodGet, "/stream?where="+url.QueryEscape("price > emaa(50)"), nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "        ^\ncolumn 9: unknown function") {
+		t.Errorf("expected a positioned error, got %d:\n%s", rec.Code, rec.Body)
+	}
+}
@@ -1,76 +1,458 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/matheusdutrademoura/injective/internal/alerts"
+	"github.com/matheusdutrademoura/injective/internal/anomaly"
+	"github.com/matheusdutrademoura/injective/internal/auth"
+	"github.com/matheusdutrademoura/injective/internal/bus"
 	"github.com/matheusdutrademoura/injective/internal/client"
+	"github.com/matheusdutrademoura/injective/internal/cors"
+	"github.com/matheusdutrademoura/injective/internal/election"
+	"github.com/matheusdutrademoura/injective/internal/enrich"
 	"github.com/matheusdutrademoura/injective/internal/fetcher"
+	"github.com/matheusdutrademoura/injective/internal/indicators"
 	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/ratelimit"
+	"github.com/matheusdutrademoura/injective/internal/relay"
 	"github.com/matheusdutrademoura/injective/internal/ringbuffer"
+	"github.com/matheusdutrademoura/injective/internal/source"
+	"github.com/matheusdutrademoura/injective/internal/spread"
+	"github.com/matheusdutrademoura/injective/internal/stats"
 )
 
 const (
-	updateInterval   = 5 * time.Second                     // How often we fetch new price data
-	historyWindow    = 1 * time.Hour                       // How much historical data we want to keep with fixed memory usage
-	maxBufferEntries = int(historyWindow / updateInterval) // 3600s / 5s = 720 entries
+	defaultUpdateInterval = 5 * time.Second  // How often we fetch new price data, unless UPDATE_INTERVAL says otherwise
+	historyWindow         = 1 * time.Hour    // How much historical data we want to keep with fixed memory usage
+	reconnectHint         = 3 * time.Second  // Base SSE retry hint sent to clients on shutdown
+	defaultHeartbeat      = 15 * time.Second // How often idle streams get a comment so clients and proxies see they're alive
+	defaultInstrument     = "BTC-USD"        // The only instrument fetched from CoinDesk today
+	throttledClientBuffer = 16               // Channel capacity of clients streaming with ?interval or ?max_rate
 )
 
 // Server ties all components together and handles HTTP requests
 type Server struct {
 	clientManager *client.ClientManager
 	updateBuffer  *ringbuffer.RingBuffer
-	priceFetcher  *fetcher.PriceFetcher
+	source        source.PriceSource // nil in relay mode, where updates come from the upstream
+	enricher      *enrich.Enricher
+	indicators    *indicators.Engine
+	stats         *stats.Tracker
+	spreads       *spread.Tracker
+	anomalies     *anomaly.Log    // Suspicious prices screened by this instance's source
+	alerts        *alerts.Manager // nil when ALERTS_ENABLED=false
+	validator     *auth.Validator // nil when JWT_SECRET is unset and the endpoints are public
+	limits        *ratelimit.Middleware
+	streamCORS    cors.Policy
+	apiCORS       cors.Policy
+	bus           bus.Bus
+	elector       *election.Elector // nil when this instance always fetches (single replica)
+	relayClient   *relay.Client     // Set in relay mode, replacing the Broadcaster as the source of updates
+	relayServer   *relay.Server     // Set when this instance serves downstream relays
+	relayListen   string
+
+	updateInterval time.Duration
+	heartbeat      time.Duration
+	tickUpdates    int // Updates published at once per tick: each native instrument's and its synthetics'
+
+	history        *fetcher.HistoryFetcher // Backfills the history window on startup; nil to start empty
+	backfillWindow time.Duration
+	backfillLimit  time.Duration // How long startup may spend backfilling
+
+	lastSeq    atomic.Uint64  // Sequence number of the most recent update
+	ready      atomic.Bool    // Set once startup backfill is done
+	publishing atomic.Bool    // Set while this instance's Broadcaster runs
+	streams    sync.WaitGroup // In-flight SSE handlers, awaited by Shutdown
+
+	streamsMutex sync.Mutex // Orders counting streams against Shutdown
+	closing      bool       // Set by Shutdown; no stream is counted after
 }
 
 func NewServer() *Server {
-	coindeskApiKey := os.Getenv("COINDESK_API_KEY")
-	if coindeskApiKey == "" {
-		log.Fatal("COINDESK_API_KEY env var not set")
+	mode := envString("MODE", "standalone")
+	if mode != "standalone" && mode != "upstream" && mode != "relay" {
+		log.Fatalf("unknown MODE=%q (expected standalone, upstream or relay)", mode)
+	}
+
+	trustedProxies, err := ratelimit.ParsePrefixes(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	updateInterval := envDuration("UPDATE_INTERVAL", defaultUpdateInterval)
+	if updateInterval <= 0 {
+		log.Fatal("UPDATE_INTERVAL must be positive")
+	}
+
+	// Relays receive prices from another instance and have no source of their own.
+	// Streaming sources may publish faster than UPDATE_INTERVAL, so history is sized by their rate.
+	var priceSource source.PriceSource
+	tickInterval := updateInterval
+	anomalies := anomaly.NewLog(envInt("ANOMALY_LOG_SIZE", 1000))
+	if mode != "relay" {
+		priceSource, tickInterval = newPriceSource(updateInterval, anomalies)
+	}
+	maxBufferEntries := int(historyWindow / tickInterval) // 3600s / 5s = 720 entries by default
+	// Relays read FX_INSTRUMENTS too, so client buffers fit their upstream's synthetics.
+	tickUpdates := 1 + len(parseSynthetics())
+
+	statsWindows, err := stats.ParseWindows(envString("STATS_WINDOWS", "5m,1h,24h"))
+	if err != nil || len(statsWindows) == 0 {
+		log.Fatalf("invalid STATS_WINDOWS: %v", err)
+	}
+
+	s := &Server{
+		clientManager:  client.NewClientManager(),
+		updateBuffer:   ringbuffer.NewRingBuffer(maxBufferEntries, historyWindow),
+		source:         priceSource,
+		enricher:       enrich.New(),
+		stats:          stats.New(statsWindows),
+		spreads:        spread.NewTracker(),
+		anomalies:      anomalies,
+		updateInterval: updateInterval,
+		heartbeat:      envDuration("STREAM_HEARTBEAT", defaultHeartbeat),
+		tickUpdates:    tickUpdates,
+		limits: ratelimit.New(ratelimit.Config{
+			RequestsPerSecond: envFloat("RATE_LIMIT_RPS", 5),
+			Burst:             envInt("RATE_LIMIT_BURST", 20),
+			MaxStreamsPerIP:   envInt("MAX_STREAMS_PER_IP", 10),
+			MaxStreams:        envInt("MAX_STREAMS", 10000),
+			StreamRetryAfter:  envDuration("STREAM_RETRY_AFTER", 10*time.Second),
+			TrustedProxies:    trustedProxies,
+		}),
+		streamCORS: cors.Policy{
+			AllowedOrigins:   cors.ParseOrigins(os.Getenv("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods:   []string{http.MethodGet},
+			AllowedHeaders:   []string{"Authorization", "Last-Event-ID", "Cache-Control"},
+			ExposedHeaders:   []string{"Retry-After"},
+			AllowCredentials: envBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           envDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		apiCORS: cors.Policy{
+			AllowedOrigins:   cors.ParseOrigins(os.Getenv("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			ExposedHeaders:   []string{"Retry-After"},
+			AllowCredentials: envBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           envDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+	}
+	for _, policy := range []cors.Policy{s.streamCORS, s.apiCORS} {
+		if err := policy.Validate(); err != nil {
+			log.Fatalf("invalid CORS settings: %v", err)
+		}
+	}
+	// New indicators warm up from the buffered history rather than starting cold.
+	s.indicators = indicators.NewEngine(func() []models.PriceUpdate { return s.updateBuffer.Since(time.Time{}) })
+
+	switch kind := os.Getenv("BUS"); kind {
+	case "", "memory":
+		s.bus = bus.NewMemoryBus()
+	case "redis":
+		s.bus = bus.NewRedisBus(bus.RedisConfig{
+			Addr:     envString("REDIS_ADDR", "localhost:6379"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			Channel:  envString("REDIS_CHANNEL", "injective:prices"),
+		})
+	default:
+		log.Fatalf("unknown BUS=%q (expected memory or redis)", kind)
+	}
+
+	heartbeat := envDuration("RELAY_HEARTBEAT", 5*time.Second)
+	if mode == "relay" {
+		upstream := os.Getenv("RELAY_UPSTREAM")
+		if upstream == "" {
+			log.Fatal("RELAY_UPSTREAM env var not set (e.g. tcp://upstream:9090)")
+		}
+		if s.relayClient, err = relay.NewClient(upstream, heartbeat); err != nil {
+			log.Fatalf("invalid RELAY_UPSTREAM: %v", err)
+		}
+	}
+	// Upstreams serve relays; relays may too, which chains them into a fan-out tree.
+	s.relayListen = os.Getenv("RELAY_LISTEN")
+	if mode == "upstream" && s.relayListen == "" {
+		s.relayListen = ":9090"
+	}
+	if s.relayListen != "" {
+		s.relayServer = relay.NewServer(s.updateBuffer, s.bus, heartbeat)
+	}
+
+	// With several replicas only the elected leader polls the upstream API; the rest relay from the bus.
+	var lease election.Lease
+	switch kind := os.Getenv("ELECTION"); kind {
+	case "", "none":
+	case "file":
+		lease = election.NewFileLease(envString("ELECTION_FILE", "injective.lease"))
+	case "redis":
+		lease = election.NewRedisLease(envString("REDIS_ADDR", "localhost:6379"), os.Getenv("REDIS_PASSWORD"), envString("ELECTION_KEY", "injective:leader"))
+	default:
+		log.Fatalf("unknown ELECTION=%q (expected none, file or redis)", kind)
+	}
+	if lease != nil {
+		if s.elector, err = election.NewElector(lease, instanceID(), envDuration("ELECTION_TTL", 15*time.Second)); err != nil {
+			log.Fatalf("invalid ELECTION_TTL: %v", err)
+		}
+	}
+	if _, local := s.bus.(*bus.MemoryBus); local && s.elector != nil {
+		log.Println("[!] leader election with the in-memory bus: followers will never receive updates")
 	}
 
-	coindeskApiURL := os.Getenv("COINDESK_API_URL")
-	if coindeskApiURL == "" {
-		log.Fatal("COINDESK_API_URL env var not set")
+	// Relays get history from their upstream, which backfills its own.
+	if url := os.Getenv("COINDESK_HISTORY_URL"); url != "" && mode != "relay" {
+		s.history = fetcher.NewHistoryFetcher(fetcher.HistoryConfig{
+			URL:         url,
+			APIKey:      os.Getenv("COINDESK_API_KEY"),
+			PageSize:    envInt("BACKFILL_PAGE_SIZE", 2000),
+			MinInterval: envDuration("BACKFILL_MIN_INTERVAL", 250*time.Millisecond),
+			MaxRetries:  envInt("BACKFILL_MAX_RETRIES", 5),
+		})
+		s.backfillWindow = envDuration("BACKFILL_WINDOW", historyWindow)
+		s.backfillLimit = envDuration("BACKFILL_TIMEOUT", 30*time.Second)
 	}
 
-	return &Server{
-		clientManager: client.NewClientManager(),
-		priceFetcher:  fetcher.NewPriceFetcher(coindeskApiKey, coindeskApiURL),
-		updateBuffer:  ringbuffer.NewRingBuffer(maxBufferEntries, historyWindow),
+	// Token validation is opt-in so local development keeps working without a token issuer.
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		s.validator = auth.NewValidator([]byte(secret))
 	}
+
+	// Rules make the server post to URLs of the creator's choosing, so they are only taken from
+	// authenticated users. Relays have no prices of their own to evaluate; their upstream does.
+	if envBool("ALERTS_ENABLED", s.validator != nil && mode != "relay") {
+		switch {
+		case s.validator == nil:
+			log.Fatal("ALERTS_ENABLED requires JWT_SECRET, so only authenticated users can manage alerts")
+		case mode == "relay":
+			log.Fatal("ALERTS_ENABLED on a relay: alerts are evaluated where prices are fetched")
+		}
+		s.alerts, err = alerts.New(alerts.Config{
+			Path:         os.Getenv("ALERTS_FILE"),
+			MaxAttempts:  envInt("ALERTS_MAX_ATTEMPTS", 8),
+			MinBackoff:   envDuration("ALERTS_MIN_BACKOFF", time.Second),
+			MaxBackoff:   envDuration("ALERTS_MAX_BACKOFF", 5*time.Minute),
+			Timeout:      envDuration("ALERTS_TIMEOUT", 10*time.Second),
+			WebhookHosts: strings.Fields(strings.ReplaceAll(os.Getenv("ALERTS_WEBHOOK_HOSTS"), ",", " ")),
+			AllowPrivate: envBool("ALERTS_ALLOW_PRIVATE", false),
+			Env:          exprEnv{s},
+			Watch:        s.indicators.Subscribe,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return s
 }
 
-// Broadcaster runs in a goroutine, continuously fetching prices,
-// storing them in the ring buffer, and broadcasting to all clients.
-func (s *Server) Broadcaster() {
-	for {
-		price, err := s.priceFetcher.Fetch()
+// Handler returns the HTTP routes served by this instance.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	// Each route carries its own CORS policy, applied outside auth so preflights (which carry no token) are answered.
+	mux.Handle("/stream", s.streamCORS.Handler(s.limits.LimitStreams(s.protect(http.HandlerFunc(s.SseHandler)))))
+	mux.Handle("/indicators", s.apiCORS.Handler(s.protect(http.HandlerFunc(s.IndicatorsHandler))))
+	mux.Handle("/stats", s.apiCORS.Handler(s.protect(http.HandlerFunc(s.StatsHandler))))
+	mux.Handle("/anomalies", s.apiCORS.Handler(s.protect(http.HandlerFunc(s.AnomaliesHandler))))
+	mux.Handle("/metrics", s.protect(http.HandlerFunc(s.MetricsHandler)))
+	if s.alerts != nil {
+		alerts := s.apiCORS.Handler(s.protect(s.alertsHandler()))
+		mux.Handle("/alerts", alerts)
+		mux.Handle("/alerts/", alerts)
+	}
+	mux.Handle("/", s.ServeFrontend())
+
+	// Request rate limiting runs first so abusive clients are turned away before any other work is done.
+	// Probes are exempt: a load balancer checking from one address must never be told to back off.
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok\n")) })
+	root.HandleFunc("/readyz", s.ReadyHandler)
+	root.Handle("/", s.limits.Limit(mux))
+	return root
+}
+
+// protect wraps API handlers with bearer token validation when a JWT secret is configured.
+func (s *Server) protect(h http.Handler) http.Handler {
+	if s.validator == nil {
+		return h
+	}
+	return s.validator.Middleware(h)
+}
+
+// Run backfills recent history, then subscribes to the bus and delivers every update to the ring buffer and connected clients,
+// while the Broadcaster publishes freshly fetched prices to the same bus. It returns when ctx is cancelled.
+// When leader election is configured the Broadcaster only runs while this instance is the leader.
+func (s *Server) Run(ctx context.Context) error {
+	// History comes first, so live prices follow it in order. Readiness waits for it, so load
+	// balancers only send clients once ?since= and charts have something to show.
+	if s.history != nil {
+		s.backfill(ctx)
+	}
+	s.ready.Store(true)
+
+	// Subscribe before the Broadcaster starts so the first update isn't missed.
+	updates, err := s.bus.Subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("subscribing to bus: %w", err)
+	}
+
+	if s.relayServer != nil {
+		ln, err := net.Listen("tcp", s.relayListen)
 		if err != nil {
-			log.Printf("error fetching price after retries: %v", err)
-			continue
+			return fmt.Errorf("relay listener: %w", err)
 		}
+		log.Printf("Relay endpoint available at tcp://%s", ln.Addr())
+		go func() {
+			if err := s.relayServer.Serve(ctx, ln); err != nil {
+				log.Printf("[!] relay listener stopped: %v", err)
+			}
+		}()
+	}
+
+	// With leader election alerts run with the Broadcaster, on the leader alone.
+	if s.alerts != nil && s.elector == nil {
+		go s.alerts.Run(ctx)
+	}
 
+	switch {
+	case s.relayClient != nil:
+		// Relays republish what the upstream sends onto the local bus, so the usual fan-out keeps
+		// the ring buffer warm and serves local clients.
+		s.relayClient.Resume(s.lastSeq.Load())
+		go s.relayClient.Run(ctx, func(update models.PriceUpdate) error {
+			return s.bus.Publish(ctx, update)
+		})
+	case s.elector != nil:
+		go s.elector.Run(ctx, s.Broadcaster)
+	default:
+		go s.Broadcaster(ctx)
+	}
+
+	for update := range updates {
+		s.deliver(update)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("bus subscription closed")
+}
+
+// backfill fills the history window with the provider's minute prices, within BACKFILL_TIMEOUT.
+// Backfilled prices feed history, changes, indicators and statistics like live ones, but are not
+// evaluated by alerts: they are past events. Each replica backfills on its own, so they are left
+// unnumbered rather than taking sequence numbers that the leader's live prices use.
+func (s *Server) backfill(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.backfillLimit)
+	defer cancel()
+	start := time.Now()
+	bars, err := s.history.Since(ctx, start.Add(-s.backfillWindow))
+	if err != nil {
+		log.Printf("[!] backfilling history: %v (continuing with %d prices)", err, len(bars))
+	}
+
+	for _, bar := range bars {
+		// Bars are stamped with the start of their minute; their close is the price at its end,
+		// or now for the minute still in progress.
+		at := bar.Time.Add(time.Minute)
+		if at.After(start) {
+			at = start
+		}
 		update := models.PriceUpdate{
-			Timestamp: time.Now().UTC(),
-			Price:     price,
+			Timestamp:  at.UTC(),
+			Price:      bar.Close,
+			Instrument: defaultInstrument,
+			Sources:    []string{"coindesk"},
+			Volume:     bar.Volume,
 		}
-
+		s.enricher.Enrich(&update)
 		s.updateBuffer.Add(update)
-		s.clientManager.Broadcast(update)
+		s.indicators.Update(update)
+		s.stats.Add(update)
+	}
+	if len(bars) > 0 {
+		log.Printf("Backfilled %d prices since %s in %v", len(bars), bars[0].Time.Format(time.RFC3339), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// Broadcaster runs in a goroutine, numbering the price source's updates, adding change statistics
+// and publishing them to the bus until ctx is cancelled.
+func (s *Server) Broadcaster(ctx context.Context) {
+	// Only the leader evaluates alerts and delivers notifications, so each is sent once. It takes
+	// over the rules and queue the previous leader left in ALERTS_FILE.
+	if s.alerts != nil && s.elector != nil {
+		if err := s.alerts.Reload(); err != nil {
+			log.Printf("[!] %v", err)
+		}
+		go s.alerts.Run(ctx)
+	}
+	s.publishing.Store(true)
+	defer s.publishing.Store(false)
 
-		time.Sleep(updateInterval)
+	err := s.source.Run(ctx, func(update models.PriceUpdate) {
+		s.enricher.Enrich(&update)
+		update.Seq = s.lastSeq.Add(1)
+		if err := s.bus.Publish(ctx, update); err != nil {
+			log.Printf("error publishing update %d: %v", update.Seq, err)
+		}
+	})
+	if err != nil {
+		log.Printf("[!] price source stopped: %v", err)
 	}
 }
 
+// leading reports whether this instance is the one publishing prices, as alerts require.
+func (s *Server) leading() bool {
+	return s.elector == nil || s.publishing.Load()
+}
+
+// deliver stores an update received from the bus, feeds it to indicators, statistics and alerts,
+// and broadcasts it to local clients.
+func (s *Server) deliver(update models.PriceUpdate) {
+	// Track the highest sequence seen, including other publishers', so numbering
+	// continues where it left off if this instance starts publishing.
+	for {
+		last := s.lastSeq.Load()
+		if update.Seq <= last || s.lastSeq.CompareAndSwap(last, update.Seq) {
+			break
+		}
+	}
+
+	// Followers keep the change history warm so it carries over if they become leader.
+	s.enricher.Observe(update)
+	s.updateBuffer.Add(update)
+	s.indicators.Update(update)
+	s.stats.Add(update)
+	s.spreads.Observe(update)
+	if s.alerts != nil && s.leading() {
+		s.alerts.Evaluate(update)
+	}
+	s.clientManager.Broadcast(update)
+}
+
 // SseHandler handles HTTP SSE connections.
-// It streams missed updates based on ?since=timestamp and live updates thereafter.
+// It streams missed updates based on the Last-Event-ID header or ?since=timestamp, and live updates thereafter.
+// Clients may narrow the stream with ?instruments=A,B and to significant moves with ?min_change_bps=
+// or ?min_change_abs=, relative to the last price they were sent, and slow the stream down with ?interval=30s
+// or ?max_rate=1/s, receiving the latest price once per period. ?where=price > ema(50) keeps only updates
+// for which the condition holds. With ?indicators=ema:20,rsi:14 each live update is followed by an
+// indicator event, with ?stats=true by a stats event, and with ?spread=true by a spread event when several
+// providers are merged. Authenticated clients are further
+// restricted to the instruments in their token and disconnected when it expires.
 func (s *Server) SseHandler(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -78,24 +460,122 @@ func (s *Server) SseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	claims := auth.ClaimsFrom(r.Context())
+	instruments := parseInstruments(r.URL.Query().Get("instruments"))
+	for _, instrument := range instruments {
+		if claims != nil && !claims.Allows(instrument) {
+			http.Error(w, fmt.Sprintf("instrument %s not allowed", instrument), http.StatusForbidden)
+			return
+		}
+	}
+
+	moves, err := parseMoveFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := parseThrottle(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	specs, err := indicators.ParseSpecs(r.URL.Query().Get("indicators"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	where, err := parseWhere(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	withStats, err := parseFlag(r.URL.Query(), "stats")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	withSpread, err := parseFlag(r.URL.Query(), "spread")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	env := exprEnv{s}
+
+	// wants reports whether an update should be delivered to this client.
+	// The move filter runs last, as it remembers what was sent.
+	wants := func(u models.PriceUpdate) bool {
+		if len(instruments) > 0 && !slices.Contains(instruments, u.Instrument) {
+			return false
+		}
+		if claims != nil && !claims.Allows(u.Instrument) {
+			return false
+		}
+		if where != nil && !where.Eval(u, env) {
+			return false
+		}
+		return moves.allow(u)
+	}
+
+	// We buffer one tick's updates to avoid blocking the broadcaster on slow clients: a tick is one
+	// update, plus one per synthetic instrument derived from it, delivered in a burst.
+	// If the client is too slow to consume updates, the connection will be dropped.
+	// The handler is counted before registering so Shutdown cannot miss it.
+	// Throttled clients may be flushed one held update per instrument at once, so they get more room.
+	if !s.trackStream() {
+		refuseStream(w)
+		return
+	}
+	defer s.streams.Done()
+	buffer := s.tickUpdates
+	if interval > 0 {
+		buffer = max(throttledClientBuffer, s.tickUpdates)
+	}
+	client := client.NewClientWithBuffer(buffer)
+	client.Interval = interval
+	if err := s.clientManager.Register(client); err != nil {
+		refuseStream(w)
+		return
+	}
+	defer s.clientManager.Unregister(client)
+	if len(specs) > 0 {
+		defer s.indicators.Subscribe(specs)()
+	}
+	if where != nil {
+		defer s.indicators.Subscribe(where.Indicators())()
+	}
+
+	// The stream outlives the server's WriteTimeout, so lift the deadline for this response.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	// We use a buffer size of 1 to avoid blocking the broadcaster on slow clients.
-	// If the client is too slow to consume updates, the connection will be dropped.
-	client := client.NewClientWithBuffer(1)
-	s.clientManager.Register(client)
-
-	// Unregister client on connection close or context cancellation
-	go func() {
-		<-r.Context().Done()
-		s.clientManager.Unregister(client)
-	}()
+	// lastID tracks the last event ID written so a shutdown notice can tell the client where to resume.
+	lastID := r.Header.Get("Last-Event-ID")
+	send := func(update models.PriceUpdate) bool {
+		if !wants(update) {
+			return false
+		}
+		writeUpdate(w, update)
+		if update.Seq != 0 {
+			lastID = strconv.FormatUint(update.Seq, 10)
+		}
+		return true
+	}
 
-	sinceParam := r.URL.Query().Get("since")
-	if sinceParam != "" {
+	// Browsers resend the last seen event ID on reconnect; it takes precedence over ?since.
+	if lastID != "" {
+		seq, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			log.Printf("invalid Last-Event-ID header: %v", err)
+			return
+		}
+		for _, update := range s.updateBuffer.After(seq) {
+			send(update)
+		}
+		flusher.Flush()
+	} else if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
 		sinceUnix, err := strconv.ParseInt(sinceParam, 10, 64)
 		if err != nil {
 			log.Printf("invalid 'since' param: %v", err)
@@ -105,18 +585,262 @@ func (s *Server) SseHandler(w http.ResponseWriter, r *http.Request) {
 		sinceTime := time.Unix(sinceUnix, 0).UTC()
 		missedUpdates := s.updateBuffer.Since(sinceTime)
 		for _, update := range missedUpdates {
-			data, _ := json.Marshal(update)
-			fmt.Fprintf(w, "data: %s\n\n", data)
+			send(update)
+		}
+		flusher.Flush()
+	}
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	// A nil channel never fires, so unauthenticated streams simply have no expiry.
+	var expired <-chan time.Time
+	if claims != nil {
+		// Tokens are accepted within the validator's leeway past exp, so they expire at the end of it.
+		timer := time.NewTimer(time.Until(s.validator.Deadline(claims)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			// SSE comment lines are ignored by EventSource but prove the connection is alive.
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-expired:
+			fmt.Fprint(w, "event: auth_expired\ndata: {\"reason\":\"token expired\"}\n\n")
+			flusher.Flush()
+			return
+		case update, ok := <-client.Chan:
+			if !ok {
+				// Either the server is shutting down, in which case we say goodbye,
+				// or the manager dropped us for being slow; both end the response so the client reconnects.
+				if s.clientManager.Closing() {
+					writeShutdown(w, lastID)
+					flusher.Flush()
+				}
+				return
+			}
+			// Indicators and statistics reflect the latest prices, so they only follow live updates, not replayed history.
+			if send(update) {
+				if len(specs) > 0 {
+					writeEvent(w, "indicator", s.indicators.Values(update.Instrument, specs))
+				}
+				if withStats {
+					writeEvent(w, "stats", s.stats.Snapshot(update.Instrument, time.Now()))
+				}
+				if sp, ok := spread.Of(update); withSpread && ok {
+					writeEvent(w, "spread", sp)
+				}
+			}
 			flusher.Flush()
 		}
+	}
+}
 
+// writeUpdate writes a single price update as an SSE data event.
+// The sequence number becomes the event ID so browsers resume from it via Last-Event-ID.
+func writeUpdate(w http.ResponseWriter, update models.PriceUpdate) {
+	data, _ := json.Marshal(update)
+	if update.Seq != 0 {
+		fmt.Fprintf(w, "id: %d\n", update.Seq)
 	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
 
-	for update := range client.Chan {
-		data, _ := json.Marshal(update)
-		fmt.Fprintf(w, "data: %s\n\n", data)
-		flusher.Flush()
+// writeEvent writes a named SSE event accompanying a price update, such as indicator readings.
+// It carries no ID, so the client's Last-Event-ID still points at the last price update.
+func writeEvent(w http.ResponseWriter, event string, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// IndicatorsHandler serves a snapshot of indicators for one instrument:
+// /indicators?instrument=BTC-USD&indicators=sma:20,bb:20. Readings still warming up are null.
+func (s *Server) IndicatorsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	instrument, ok := snapshotInstrument(w, r)
+	if !ok {
+		return
+	}
+	specs, err := indicators.ParseSpecs(r.URL.Query().Get("indicators"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(specs) == 0 {
+		http.Error(w, "missing indicators, e.g. ?indicators=ema:20,rsi:14", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.indicators.Values(instrument, specs))
+}
+
+// StatsHandler serves rolling statistics for one instrument over each window: /stats?instrument=BTC-USD.
+func (s *Server) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	instrument, ok := snapshotInstrument(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.stats.Snapshot(instrument, time.Now()))
+}
+
+// ReadyHandler reports whether the instance is ready for clients: 503 while startup backfill is running.
+func (s *Server) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		http.Error(w, "backfilling history", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// MetricsHandler serves metrics in the Prometheus text format: the spread between providers, each
+// provider's price and deviation, how often each was the outlier, and counts of suspicious prices.
+func (s *Server) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	allow := func(string) bool { return true }
+	if claims := auth.ClaimsFrom(r.Context()); claims != nil {
+		allow = claims.Allows
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.spreads.WriteMetrics(w, allow)
+	s.anomalies.WriteMetrics(w, allow)
+}
+
+// AnomaliesHandler lists the latest suspicious prices for review, newest first: /anomalies?instrument=BTC-USD&limit=50.
+// Without ?instrument= every instrument the client may see is listed.
+func (s *Server) AnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	instruments := parseInstruments(r.URL.Query().Get("instrument"))
+	claims := auth.ClaimsFrom(r.Context())
+	for _, instrument := range instruments {
+		if claims != nil && !claims.Allows(instrument) {
+			http.Error(w, fmt.Sprintf("instrument %s not allowed", instrument), http.StatusForbidden)
+			return
+		}
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q: expected a positive number", v), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	reports := s.anomalies.Recent(limit, func(instrument string) bool {
+		return (len(instruments) == 0 || slices.Contains(instruments, instrument)) && (claims == nil || claims.Allows(instrument))
+	})
+	writeJSON(w, http.StatusOK, orEmpty(reports))
+}
+
+// snapshotInstrument reads the instrument of a snapshot request from ?instrument=, defaulting to BTC-USD,
+// and checks the token allows it. It answers the request itself when it reports false.
+func snapshotInstrument(w http.ResponseWriter, r *http.Request) (string, bool) {
+	instruments := parseInstruments(r.URL.Query().Get("instrument"))
+	switch len(instruments) {
+	case 0:
+		instruments = []string{defaultInstrument}
+	case 1:
+	default:
+		http.Error(w, "only one instrument per request", http.StatusBadRequest)
+		return "", false
+	}
+	if claims := auth.ClaimsFrom(r.Context()); claims != nil && !claims.Allows(instruments[0]) {
+		http.Error(w, fmt.Sprintf("instrument %s not allowed", instruments[0]), http.StatusForbidden)
+		return "", false
+	}
+	return instruments[0], true
+}
+
+// writeShutdown tells the client the server is going away and when to reconnect.
+// The retry hint is jittered so clients of a draining replica don't reconnect in lockstep.
+func writeShutdown(w http.ResponseWriter, lastID string) {
+	retry := reconnectHint + rand.N(reconnectHint)
+	data, _ := json.Marshal(struct {
+		Reason      string `json:"reason"`
+		RetryMs     int64  `json:"retry_ms"`
+		LastEventID string `json:"last_event_id,omitempty"`
+	}{"server shutting down", retry.Milliseconds(), lastID})
+	fmt.Fprintf(w, "event: shutdown\nretry: %d\ndata: %s\n\n", retry.Milliseconds(), data)
+}
+
+// trackStream counts a starting stream handler for Shutdown to wait on, unless shutdown has begun.
+// Counting and the check share Shutdown's lock, so no handler is added once it waits.
+func (s *Server) trackStream() bool {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+	if s.closing {
+		return false
+	}
+	s.streams.Add(1)
+	return true
+}
+
+// refuseStream turns a client away during shutdown, hinting when to reconnect, presumably elsewhere.
+func refuseStream(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(reconnectHint.Seconds())))
+	http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+}
+
+// Shutdown stops accepting stream clients, sends every connected client a shutdown event
+// and waits for their handlers to finish, giving up when ctx expires.
+// It should run before http.Server.Shutdown, which otherwise blocks on the open streams.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.streamsMutex.Lock()
+	s.closing = true
+	s.streamsMutex.Unlock()
+	s.clientManager.Shutdown()
+	defer s.bus.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseFlag reads an optional true/false query parameter.
+func parseFlag(q url.Values, name string) (bool, error) {
+	v := q.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: expected true or false", name, v)
+	}
+	return b, nil
+}
+
+// parseInstruments splits a comma-separated instrument list, normalizing to upper case.
+func parseInstruments(param string) []string {
+	var instruments []string
+	for _, instrument := range strings.Split(param, ",") {
+		if instrument = strings.ToUpper(strings.TrimSpace(instrument)); instrument != "" {
+			instruments = append(instruments, instrument)
+		}
 	}
+	return instruments
 }
 
 // ServeFrontend serves static files from the ./frontend directory.
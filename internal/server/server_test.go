@@ -2,15 +2,23 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/matheusdutrademoura/injective/internal/anomaly"
+	"github.com/matheusdutrademoura/injective/internal/auth"
+	"github.com/matheusdutrademoura/injective/internal/client"
 	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/websocket/wstest"
 )
 
 // mockFlusherWriter implements http.ResponseWriter + http.Flusher for testing SSE
@@ -79,3 +87,691 @@ func TestSseHandlerBasic(t *testing.T) {
 		t.Errorf("Expected Flush to be called on ResponseWriter")
 	}
 }
+
+// TestSseHandlerAuth tests that token claims restrict instruments and close the stream on expiry.
+func TestSseHandlerAuth(t *testing.T) {
+	os.Setenv("COINDESK_API_KEY", "dummy")
+	os.Setenv("COINDESK_API_URL", "http://localhost:9999/%s")
+	os.Setenv("JWT_SECRET", "stream-secret")
+	defer os.Unsetenv("JWT_SECRET")
+
+	s := NewServer()
+	handler := s.Handler()
+
+	sign := func(claims auth.Claims) string {
+		token, err := auth.Sign(claims, []byte("stream-secret"))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		token      string
+		wantStatus int
+		wantBody   string
+		minRunning time.Duration // How long the handler must stream before returning
+	}{
+		{
+			name:       "missing token",
+			target:     "/stream",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "instrument not allowed",
+			target:     "/stream?instruments=BTC-USD",
+			token:      sign(auth.Claims{ExpiresAt: time.Now().Add(time.Minute).Unix(), Instruments: []string{"ETH-USD"}}),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "expires mid-stream, at the end of the leeway",
+			target:     "/stream",
+			token:      sign(auth.Claims{ExpiresAt: time.Now().Add(-3 * time.Second).Unix()}), // inside the validator's 5s leeway
+			wantStatus: http.StatusOK,
+			wantBody:   "event: auth_expired",
+			minRunning: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+
+			start := time.Now()
+			done := make(chan struct{})
+			go func() {
+				handler.ServeHTTP(w, req)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				t.Fatal("handler did not return")
+			}
+
+			if running := time.Since(start); running < tt.minRunning {
+				t.Errorf("expected the stream to last at least %v, ended after %v", tt.minRunning, running)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestSseHandlerResumeAndShutdown tests Last-Event-ID resumption and the goodbye event sent on shutdown.
+func TestSseHandlerResumeAndShutdown(t *testing.T) {
+	os.Setenv("COINDESK_API_KEY", "dummy")
+	os.Setenv("COINDESK_API_URL", "http://localhost:9999/%s")
+
+	s := NewServer()
+	now := time.Now().UTC()
+	for seq := uint64(1); seq <= 3; seq++ {
+		s.updateBuffer.Add(models.PriceUpdate{Seq: seq, Timestamp: now, Price: float64(seq)})
+	}
+
+	req := httptest.NewRequest("GET", "/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		s.SseHandler(w, req)
+		close(done)
+	}()
+
+	// Wait for the handler to register before shutting down.
+	for deadline := time.Now().Add(time.Second); s.clientManager.Count() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("client never registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown did not drain handlers: %v", err)
+	}
+	<-done
+
+	body := w.Body.String()
+	if strings.Contains(body, "id: 1\n") || !strings.Contains(body, "id: 2\n") || !strings.Contains(body, "id: 3\n") {
+		t.Errorf("expected only events after Last-Event-ID 1, got %q", body)
+	}
+	if !strings.Contains(body, "event: shutdown\nretry: ") || !strings.Contains(body, `"last_event_id":"3"`) {
+		t.Errorf("expected shutdown event with last event ID 3, got %q", body)
+	}
+
+	// New streams are refused once shutdown has begun.
+	w2 := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	s.SseHandler(w2, httptest.NewRequest("GET", "/stream", nil))
+	if w2.Code != http.StatusServiceUnavailable || w2.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 503 with Retry-After after shutdown, got %d", w2.Code)
+	}
+}
+
+// TestRunDeliversThroughBus tests that fetched prices travel over the bus into the ring buffer and to clients.
+func TestRunDeliversThroughBus(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Data": {"BTC-USD": {"VALUE": 64000.25}}}`))
+	}))
+	defer api.Close()
+
+	os.Setenv("COINDESK_API_KEY", "dummy")
+	os.Setenv("COINDESK_API_URL", api.URL+"?api_key=%s")
+
+	s := NewServer()
+	c := client.NewClientWithBuffer(1)
+	s.clientManager.Register(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.Run(ctx) }()
+
+	select {
+	case update := <-c.Chan:
+		if update.Seq != 1 || update.Price != 64000.25 || update.Instrument != "BTC-USD" {
+			t.Errorf("unexpected update %+v", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not receive an update")
+	}
+
+	if missed := s.updateBuffer.After(0); len(missed) != 1 {
+		t.Errorf("expected update in ring buffer, got %d entries", len(missed))
+	}
+
+	cancel()
+	if err := <-runErr; err != nil {
+		t.Errorf("expected clean exit on cancel, got %v", err)
+	}
+}
+
+// TestSimulatedSource tests that the server runs offline with the simulated price source.
+func TestSimulatedSource(t *testing.T) {
+	t.Setenv("COINDESK_API_KEY", "")
+	t.Setenv("COINDESK_API_URL", "")
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("SIM_SEED", "7")
+	t.Setenv("SIM_START_PRICE", "100")
+	t.Setenv("UPDATE_INTERVAL", "10ms")
+
+	s := NewServer()
+	c := client.NewClientWithBuffer(8)
+	s.clientManager.Register(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	var prev float64
+	for seq := uint64(1); seq <= 3; seq++ {
+		select {
+		case update := <-c.Chan:
+			if update.Seq != seq || update.Instrument != "BTC-USD" || update.Price <= 0 {
+				t.Errorf("unexpected update %+v", update)
+			}
+			if seq == 1 && update.Price != 100 {
+				t.Errorf("expected the start price first, got %v", update.Price)
+			}
+			if len(update.Sources) != 1 || update.Sources[0] != "simulated" {
+				t.Errorf("expected simulated provenance, got %v", update.Sources)
+			}
+			if seq > 1 && (update.Change == nil || update.Change.From != prev) {
+				t.Errorf("expected change from %v, got %+v", prev, update.Change)
+			}
+			prev = update.Price
+		case <-time.After(2 * time.Second):
+			t.Fatal("client did not receive an update")
+		}
+	}
+}
+
+// TestRecordAndReplaySource tests that a recorded session replays the same prices through the pipeline.
+func TestRecordAndReplaySource(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "prices.ndjson")
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("RECORD_FILE", recording)
+	t.Setenv("UPDATE_INTERVAL", "10ms")
+
+	receive := func(s *Server, n int) []models.PriceUpdate {
+		c := client.NewClientWithBuffer(n)
+		s.clientManager.Register(c)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx)
+
+		var got []models.PriceUpdate
+		for len(got) < n {
+			select {
+			case u := <-c.Chan:
+				got = append(got, u)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("received %d of %d updates", len(got), n)
+			}
+		}
+		return got
+	}
+	recorded := receive(NewServer(), 3)
+
+	t.Setenv("SOURCE", "replay")
+	t.Setenv("RECORD_FILE", "")
+	t.Setenv("REPLAY_FILE", recording)
+	t.Setenv("REPLAY_SPEED", "max")
+	replayed := receive(NewServer(), 3)
+
+	for i := range recorded {
+		if replayed[i].Price != recorded[i].Price || replayed[i].Seq != uint64(i+1) {
+			t.Errorf("update %d: recorded %+v, replayed %+v", i, recorded[i], replayed[i])
+		}
+	}
+}
+
+// TestWebSocketSource tests that ticks from a streaming provider reach clients as they arrive.
+func TestWebSocketSource(t *testing.T) {
+	feed := wstest.NewServer(t)
+	t.Setenv("COINDESK_API_KEY", "")
+	t.Setenv("SOURCE", "websocket")
+	t.Setenv("WS_URL", feed.URL())
+	t.Setenv("WS_SUBSCRIBE", `{"subscribe":"BTC-USD"}`)
+	t.Setenv("WS_PRICE_PATH", "tick.price")
+	t.Setenv("WS_MAX_RATE", "100")
+
+	s := NewServer()
+	c := client.NewClientWithBuffer(1)
+	s.clientManager.Register(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	if got := feed.WaitForReceived(1); len(got) != 1 {
+		t.Fatalf("expected a subscription, got %q", got)
+	}
+	feed.Send(`{"tick":{"price":"65000.75"}}`)
+
+	select {
+	case update := <-c.Chan:
+		if update.Seq != 1 || update.Price != 65000.75 || update.Instrument != "BTC-USD" {
+			t.Errorf("unexpected update %+v", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not receive the tick")
+	}
+}
+
+// TestSseHandlerIndicators tests that live updates are followed by indicator events warmed up from history.
+func TestSseHandlerIndicators(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("UPDATE_INTERVAL", "20ms")
+	s := NewServer()
+	now := time.Now().UTC()
+	for i := range 5 {
+		s.updateBuffer.Add(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: now, Price: 100, Instrument: defaultInstrument})
+	}
+	s.lastSeq.Store(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?indicators=sma:5,rsi:3&since=0", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	reqCtx, stop := context.WithTimeout(req.Context(), 150*time.Millisecond)
+	defer stop()
+	s.SseHandler(w, req.WithContext(reqCtx))
+
+	body := w.Body.String()
+	events := strings.Count(body, "event: indicator")
+	if events == 0 || events != strings.Count(body, "data:")-5-events {
+		t.Fatalf("expected one indicator event per live update, got:\n%s", body)
+	}
+	if strings.Contains(body, `"sma:5":null`) || !strings.Contains(body, `"rsi:3":{"value":`) {
+		t.Errorf("expected indicators warmed up from history, got:\n%s", body)
+	}
+
+	rec := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	s.SseHandler(rec, httptest.NewRequest(http.MethodGet, "/stream?indicators=macd:12", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown indicator, got %d", rec.Code)
+	}
+}
+
+// TestIndicatorsHandler tests the REST snapshot, computed from buffered history.
+func TestIndicatorsHandler(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	s := NewServer()
+	now := time.Now().UTC()
+	for i, p := range []float64{100, 102, 104, 106} {
+		s.updateBuffer.Add(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: now, Price: p, Instrument: defaultInstrument})
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/indicators?instrument=btc-usd&indicators=sma:2,ema:10", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var snap struct {
+		Instrument string
+		Seq        uint64
+		Values     map[string]*struct{ Value float64 }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Instrument != defaultInstrument || snap.Seq != 4 || snap.Values["sma:2"] == nil || snap.Values["sma:2"].Value != 105 {
+		t.Errorf("unexpected snapshot %s", rec.Body)
+	}
+	if v, ok := snap.Values["ema:10"]; !ok || v != nil {
+		t.Errorf("expected ema:10 to be null while warming up, got %s", rec.Body)
+	}
+
+	for _, q := range []string{"", "indicators=sma:0", "instrument=A,B&indicators=sma:2"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/indicators?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", q, rec.Code)
+		}
+	}
+}
+
+// TestStats tests the stats endpoint and stream event, fed by delivered updates.
+func TestStats(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("STATS_WINDOWS", "1h,5m")
+	s := NewServer()
+	now := time.Now().UTC()
+	for i, p := range []float64{100, 110, 99} {
+		s.deliver(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: now.Add(time.Duration(i-2) * time.Minute), Price: p, Instrument: defaultInstrument})
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var snap struct {
+		Seq     uint64
+		Windows []struct {
+			Window      string
+			Ticks       int
+			Max         float64
+			MaxDrawdown float64 `json:"max_drawdown"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil {
+		t.Fatalf("%d: %s", rec.Code, rec.Body)
+	}
+	if snap.Seq != 3 || len(snap.Windows) != 2 || snap.Windows[0].Window != "5m" || snap.Windows[0].Ticks != 3 || snap.Windows[0].Max != 110 || snap.Windows[0].MaxDrawdown != 10 {
+		t.Errorf("unexpected stats %s", rec.Body)
+	}
+
+	// Live updates are followed by a stats event.
+	req := httptest.NewRequest(http.MethodGet, "/stream?stats=true", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	ctx, cancel := context.WithCancel(req.Context())
+	done := make(chan struct{})
+	go func() {
+		s.SseHandler(w, req.WithContext(ctx))
+		close(done)
+	}()
+	for s.clientManager.Count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	s.deliver(models.PriceUpdate{Seq: 4, Timestamp: now.Add(time.Second), Price: 101, Instrument: defaultInstrument})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+	if body := w.Body.String(); !strings.Contains(body, "event: stats\ndata: {\"instrument\":\"BTC-USD\",\"seq\":4") {
+		t.Errorf("expected a stats event after the update, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream?stats=maybe", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad stats flag, got %d", rec.Code)
+	}
+}
+
+// TestMergedSources tests that several SOURCE entries publish a consensus listing each provider's price.
+func TestMergedSources(t *testing.T) {
+	feed := wstest.NewServer(t)
+	t.Setenv("COINDESK_API_KEY", "")
+	t.Setenv("SOURCE", "simulated,websocket")
+	t.Setenv("SIM_START_PRICE", "100")
+	t.Setenv("SIM_VOLATILITY", "0")
+	t.Setenv("UPDATE_INTERVAL", "10ms")
+	t.Setenv("WS_URL", feed.URL())
+	t.Setenv("WS_PROVIDER", "exchange")
+	t.Setenv("WS_MAX_RATE", "100")
+	t.Setenv("DEDUP_PRICES", "false")
+
+	s := NewServer()
+	c := client.NewClientWithBuffer(64)
+	s.clientManager.Register(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	if !feed.WaitForClients(1) {
+		t.Fatal("websocket source did not connect")
+	}
+	feed.Send(`{"price":101}`)
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case u := <-c.Chan:
+			if len(u.Providers) == 2 && u.Providers["exchange"] == 101 && u.Providers["simulated"] == 100 {
+				if u.Price != 100.5 {
+					t.Errorf("expected the median price, got %v", u.Price)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("expected an update merging both providers")
+		}
+	}
+}
+
+// TestSpread tests the spread event and metrics of merged updates.
+func TestSpread(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	s := NewServer()
+	now := time.Now().UTC()
+	merged := models.PriceUpdate{Seq: 1, Timestamp: now, Price: 128, Instrument: defaultInstrument, Providers: map[string]float64{"a": 127, "b": 128, "c": 130}}
+	s.deliver(merged)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `injective_spread_bps{instrument="BTC-USD"} 234.375`) || !strings.Contains(body, `injective_spread_outliers_total{instrument="BTC-USD",provider="c"} 1`) {
+		t.Errorf("unexpected metrics:\n%s", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stream?spread=true", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	ctx, cancel := context.WithCancel(req.Context())
+	done := make(chan struct{})
+	go func() {
+		s.SseHandler(w, req.WithContext(ctx))
+		close(done)
+	}()
+	for s.clientManager.Count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	merged.Seq = 2
+	s.deliver(merged)
+	s.deliver(models.PriceUpdate{Seq: 3, Timestamp: now, Price: 100, Instrument: defaultInstrument})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+	body := w.Body.String()
+	if !strings.Contains(body, "event: spread\ndata: {\"instrument\":\"BTC-USD\",\"seq\":2,") || !strings.Contains(body, `"outlier":"c"`) {
+		t.Errorf("expected a spread event after the merged update, got:\n%s", body)
+	}
+	if strings.Count(body, "event: spread") != 1 {
+		t.Errorf("expected no spread event for a single provider, got:\n%s", body)
+	}
+}
+
+// TestAnomalies tests that a bad tick from the source is quarantined and listed for review.
+func TestAnomalies(t *testing.T) {
+	feed := wstest.NewServer(t)
+	t.Setenv("COINDESK_API_KEY", "")
+	t.Setenv("SOURCE", "websocket")
+	t.Setenv("WS_URL", feed.URL())
+	t.Setenv("WS_MAX_RATE", "1000")
+	t.Setenv("ANOMALY_MODE", "quarantine")
+	t.Setenv("DEDUP_PRICES", "false")
+
+	s := NewServer()
+	c := client.NewClientWithBuffer(8)
+	s.clientManager.Register(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	if !feed.WaitForClients(1) {
+		t.Fatal("websocket source did not connect")
+	}
+	for _, p := range []float64{64000, 32000, 64100} {
+		feed.Send(fmt.Sprintf(`{"price":%g}`, p))
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, want := range []float64{64000, 64100} {
+		select {
+		case u := <-c.Chan:
+			if u.Price != want {
+				t.Errorf("expected %v, got %v", want, u.Price)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("client did not receive the update")
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/anomalies?instrument=btc-usd", nil))
+	var reports []anomaly.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &reports); err != nil {
+		t.Fatalf("%d: %s", rec.Code, rec.Body)
+	}
+	if len(reports) != 1 || reports[0].Price != 32000 || reports[0].Action != anomaly.Quarantined || reports[0].Reference != 64000 {
+		t.Errorf("expected the 32000 tick quarantined, got %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `injective_anomalies_total{instrument="BTC-USD",action="quarantined"} 1`) {
+		t.Errorf("expected the anomaly counted, got:\n%s", rec.Body)
+	}
+}
+
+// TestProbesNotRateLimited tests that health and readiness probes from one address are never refused.
+func TestProbesNotRateLimited(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("RATE_LIMIT_RPS", "1")
+	t.Setenv("RATE_LIMIT_BURST", "1")
+	s := NewServer()
+	s.ready.Store(true)
+	handler := s.Handler()
+
+	for range 5 {
+		for _, path := range []string{"/healthz", "/readyz"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %s to answer 200, got %d", path, rec.Code)
+			}
+		}
+	}
+	var rec *httptest.ResponseRecorder
+	for range 2 {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	}
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected other routes still limited, got %d", rec.Code)
+	}
+}
+
+// TestBackfill tests that history is loaded before the instance reports ready and live prices follow it.
+func TestBackfill(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		to, _ := strconv.ParseInt(r.URL.Query().Get("to_ts"), 10, 64)
+		end := to - to%60
+		var bars []string
+		for t := end - 9*60; t <= end; t += 60 {
+			bars = append(bars, fmt.Sprintf(`{"TIMESTAMP":%d,"CLOSE":64000,"VOLUME":2}`, t))
+		}
+		fmt.Fprintf(w, `{"Data":[%s]}`, strings.Join(bars, ","))
+	}))
+	defer api.Close()
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("SIM_START_PRICE", "65000")
+	t.Setenv("UPDATE_INTERVAL", "10ms")
+	t.Setenv("COINDESK_HISTORY_URL", api.URL+"?api_key=%s")
+	t.Setenv("BACKFILL_WINDOW", "5m")
+
+	s := NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while backfilling, got %d", rec.Code)
+	}
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.ready.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected ready after backfilling, got %d", rec.Code)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	history := s.updateBuffer.Since(time.Time{})
+	if len(history) < 7 || history[0].Price != 64000 || history[0].Sources[0] != "coindesk" || history[0].Volume != 2 {
+		t.Fatalf("expected the last 5 minutes backfilled, got %+v", history)
+	}
+	var live uint64
+	for i := 1; i < len(history); i++ {
+		if history[i].Timestamp.Before(history[i-1].Timestamp) {
+			t.Fatalf("expected live prices to follow history in order, got %+v then %+v", history[i-1], history[i])
+		}
+		switch {
+		case history[i].Price == 64000 && history[i].Seq != 0:
+			t.Fatalf("expected backfilled prices unnumbered, got %+v", history[i])
+		case history[i].Price != 64000:
+			if live++; history[i].Seq != live {
+				t.Fatalf("expected live prices numbered from 1 as on every replica, got %+v", history[i])
+			}
+		}
+	}
+	if last := history[len(history)-1]; last.Price == 64000 {
+		t.Errorf("expected live prices after the backfill, got %+v", last)
+	}
+}
+
+// TestSseHandlerSynthetics tests that a default client keeps up with a tick's burst of native and synthetic updates.
+func TestSseHandlerSynthetics(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	t.Setenv("UPDATE_INTERVAL", "40ms")
+	t.Setenv("FX_INSTRUMENTS", "BTC-EUR,BTC-GBP")
+	t.Setenv("FX_SOURCE", "static")
+	t.Setenv("FX_RATES", "USD-EUR=0.9,USD-GBP=0.8")
+	s := NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+	reqCtx, stop := context.WithTimeout(req.Context(), 300*time.Millisecond)
+	defer stop()
+	s.SseHandler(w, req.WithContext(reqCtx))
+
+	body := w.Body.String()
+	native := strings.Count(body, `"instrument":"BTC-USD"`)
+	if native < 4 || strings.Count(body, `"instrument":"BTC-EUR"`) < native-1 || strings.Count(body, `"instrument":"BTC-GBP"`) < native-1 {
+		t.Errorf("expected every tick's synthetics delivered, got:\n%s", body)
+	}
+	if !strings.Contains(body, "data:") || s.clientManager.Count() != 0 {
+		t.Errorf("expected the client to stay connected until it left, got:\n%s", body)
+	}
+}
+
+// TestShutdownRacingStreams tests that streams arriving while Shutdown runs are either drained or refused.
+func TestShutdownRacingStreams(t *testing.T) {
+	t.Setenv("SOURCE", "simulated")
+	s := NewServer()
+	var handlers sync.WaitGroup
+	for range 20 {
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			w := &mockFlusherWriter{ResponseRecorder: *httptest.NewRecorder()}
+			s.SseHandler(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown did not drain handlers: %v", err)
+	}
+	handlers.Wait()
+}
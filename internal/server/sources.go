@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/anomaly"
+	"github.com/matheusdutrademoura/injective/internal/fetcher"
+	"github.com/matheusdutrademoura/injective/internal/fx"
+	"github.com/matheusdutrademoura/injective/internal/source"
+)
+
+// newPriceSource builds the price feed selected by SOURCE, polling sources ticking every interval.
+// A comma-separated list of sources merges them into a consensus price.
+// It also returns the shortest expected gap between updates.
+// With RECORD_FILE set, everything the source emits is also appended to that file for later replay.
+// Suspicious prices are screened out as ANOMALY_MODE directs and recorded in anomalies for review.
+// FX_INSTRUMENTS adds synthetic instruments converted from native ones, e.g. BTC-EUR from BTC-USD.
+// Consecutive identical prices are suppressed unless DEDUP_PRICES=false.
+func newPriceSource(interval time.Duration, anomalies *anomaly.Log) (source.PriceSource, time.Duration) {
+	kind := envString("SOURCE", "coindesk")
+	var src source.PriceSource
+	if kinds := strings.Split(kind, ","); len(kinds) > 1 {
+		src, interval = newMergedSource(kinds, interval)
+	} else {
+		src, interval = newProviderSource(kind, interval)
+	}
+
+	if path := os.Getenv("RECORD_FILE"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("opening RECORD_FILE: %v", err)
+		}
+		log.Printf("Recording %s prices to %s", kind, path)
+		src = source.NewRecorder(src, kind, f)
+	}
+
+	// Bad ticks stay in recordings, so replaying them exercises the detector. Synthetic instruments
+	// are derived from screened prices and need no screening of their own.
+	if mode := envString("ANOMALY_MODE", "flag"); mode != "off" {
+		m, err := anomaly.ParseMode(mode)
+		if err != nil {
+			log.Fatalf("invalid ANOMALY_MODE: %v (or off)", err)
+		}
+		detector := anomaly.NewDetector(anomaly.Config{
+			Mode:       m,
+			Window:     envInt("ANOMALY_WINDOW", 60),
+			ZScore:     envFloat("ANOMALY_ZSCORE", 6),
+			MaxJump:    envFloat("ANOMALY_MAX_JUMP", 10),
+			MinJump:    envFloat("ANOMALY_MIN_JUMP", 0.5),
+			MinSamples: envInt("ANOMALY_MIN_SAMPLES", 20),
+			Confirm:    envInt("ANOMALY_CONFIRM", 3),
+		})
+		src = source.NewScreen(src, detector, func(r anomaly.Report) {
+			log.Printf("[!] suspicious %s price %g (%s, %+.2f%% from %g): %s",
+				r.Instrument, r.Price, strings.Join(r.Reasons, ", "), r.JumpPct, r.Reference, r.Action)
+			anomalies.Add(r)
+		})
+	}
+
+	// Synthetic instruments are derived after recording, so replays convert at the rates of their own time.
+	if synthetics := parseSynthetics(); len(synthetics) > 0 {
+		rates, provider := newRateSource()
+		cache := fx.NewCache(rates, fx.CacheConfig{
+			TTL:    envDuration("FX_TTL", time.Hour),
+			MaxAge: envDuration("FX_MAX_AGE", 24*time.Hour),
+		})
+		src = source.NewConverter(src, cache, provider, synthetics)
+		// History holds one entry per instrument and tick.
+		interval /= time.Duration(1 + len(synthetics))
+	}
+
+	// Unchanged prices are dropped after recording, so recordings keep every tick the source saw.
+	if envBool("DEDUP_PRICES", true) {
+		src = source.NewDedup(src, envDuration("DEDUP_KEEPALIVE", 0))
+	}
+	return src, interval
+}
+
+// newProviderSource builds a single provider's feed and returns it with its fastest publish interval.
+func newProviderSource(kind string, interval time.Duration) (source.PriceSource, time.Duration) {
+	if kind == "websocket" {
+		return newStreamSource(interval)
+	}
+	return newBaseSource(kind, interval), interval
+}
+
+// newMergedSource runs several providers, publishing their median price on every update from any of them.
+// Providers silent for MERGE_MAX_AGE are left out of the consensus.
+func newMergedSource(kinds []string, interval time.Duration) (*source.Merge, time.Duration) {
+	providers := make(map[string]source.PriceSource)
+	var rate float64 // Updates per second across providers
+	for _, kind := range kinds {
+		kind = strings.TrimSpace(kind)
+		name := kind
+		if kind == "websocket" {
+			name = envString("WS_PROVIDER", "websocket")
+		}
+		if _, ok := providers[name]; ok {
+			log.Fatalf("SOURCE lists %s twice", name)
+		}
+		src, every := newProviderSource(kind, interval)
+		providers[name] = src
+		rate += float64(time.Second) / float64(every)
+	}
+
+	maxAge := envDuration("MERGE_MAX_AGE", 3*interval)
+	log.Printf("Merging prices from %s (providers silent for %v are left out)", strings.Join(slices.Sorted(maps.Keys(providers)), ", "), maxAge)
+	return source.NewMerge(providers, maxAge), time.Duration(float64(time.Second) / rate)
+}
+
+// parseSynthetics returns the synthetic instruments listed in FX_INSTRUMENTS.
+func parseSynthetics() []fx.Synthetic {
+	list := os.Getenv("FX_INSTRUMENTS")
+	if list == "" {
+		return nil
+	}
+	synthetics, err := fx.ParseSynthetics(list, quoteCurrency(defaultInstrument))
+	if err != nil {
+		log.Fatalf("invalid FX_INSTRUMENTS: %v", err)
+	}
+	return synthetics
+}
+
+// newRateSource builds the exchange rate source selected by FX_SOURCE and returns it with its provider name.
+func newRateSource() (fx.Source, string) {
+	switch kind := envString("FX_SOURCE", "http"); kind {
+	case "http":
+		url := os.Getenv("FX_URL")
+		if url == "" {
+			log.Fatal("FX_URL env var not set (e.g. https://api.frankfurter.app/latest?from={base}, or use FX_SOURCE=static)")
+		}
+		log.Printf("Converting prices at rates from %s", url)
+		return &fx.HTTPSource{URL: url, Path: envString("FX_RATES_PATH", "rates")}, envString("FX_PROVIDER", "http")
+
+	case "static":
+		rates, err := fx.ParseStatic(os.Getenv("FX_RATES"))
+		if err != nil || len(rates) == 0 {
+			log.Fatalf("invalid FX_RATES (expected e.g. EUR-USD=1.08,USD-BRL=5.1): %v", err)
+		}
+		log.Printf("Converting prices at fixed rates %s", os.Getenv("FX_RATES"))
+		return rates, envString("FX_PROVIDER", "static")
+
+	default:
+		log.Fatalf("unknown FX_SOURCE=%q (expected http or static)", kind)
+		return nil, ""
+	}
+}
+
+// quoteCurrency returns the currency an instrument is quoted in, e.g. USD for BTC-USD.
+func quoteCurrency(instrument string) string {
+	_, quote, _ := fx.Split(instrument)
+	return quote
+}
+
+func newBaseSource(kind string, interval time.Duration) source.PriceSource {
+	switch kind {
+	case "coindesk":
+		if os.Getenv("COINDESK_API_KEY") == "" {
+			log.Fatal("COINDESK_API_KEY env var not set (or use SOURCE=simulated to run offline)")
+		}
+		if os.Getenv("COINDESK_API_URL") == "" {
+			log.Fatal("COINDESK_API_URL env var not set")
+		}
+		return newCoinDeskPoller(interval)
+
+	case "simulated":
+		// Without a seed every run takes a different path; set one to reproduce a session.
+		seed := rand.Uint64()
+		if os.Getenv("SIM_SEED") != "" {
+			seed = uint64(envInt("SIM_SEED", 0))
+		}
+		log.Printf("Simulating %s prices (seed %d)", defaultInstrument, seed)
+		return source.NewSimulated(source.SimulatedConfig{
+			Instrument:        defaultInstrument,
+			StartPrice:        envFloat("SIM_START_PRICE", 60000),
+			Drift:             envFloat("SIM_DRIFT", 0),
+			Volatility:        envFloat("SIM_VOLATILITY", 0.8),
+			Interval:          interval,
+			Seed:              seed,
+			SpreadBps:         envFloat("SIM_SPREAD_BPS", 0),
+			JumpProbability:   envFloat("SIM_JUMP_PROB", 0),
+			JumpSize:          envFloat("SIM_JUMP_SIZE", 0.02),
+			OutageProbability: envFloat("SIM_OUTAGE_PROB", 0),
+			OutageDuration:    envDuration("SIM_OUTAGE_DURATION", 30*time.Second),
+		})
+
+	case "replay":
+		path := os.Getenv("REPLAY_FILE")
+		if path == "" {
+			log.Fatal("REPLAY_FILE env var not set")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("opening REPLAY_FILE: %v", err)
+		}
+
+		cfg := source.ReplayConfig{KeepTimestamps: envBool("REPLAY_KEEP_TIMESTAMPS", false)}
+		switch speed := envString("REPLAY_SPEED", "1"); speed {
+		case "step":
+			cfg.Stepwise = true
+		case "max":
+		default:
+			if cfg.Speed, err = strconv.ParseFloat(speed, 64); err != nil || cfg.Speed <= 0 {
+				log.Fatalf("invalid REPLAY_SPEED=%q (expected a positive factor, max or step)", speed)
+			}
+		}
+		replay := source.NewReplay(f, cfg)
+
+		// Stepwise playback advances one update per line typed on stdin.
+		if cfg.Stepwise {
+			log.Printf("Replaying %s stepwise: press Enter to publish the next update", path)
+			go func() {
+				for stdin := bufio.NewScanner(os.Stdin); stdin.Scan(); {
+					replay.Step(context.Background())
+				}
+			}()
+		} else {
+			log.Printf("Replaying %s at %s speed", path, envString("REPLAY_SPEED", "1"))
+		}
+		return replay
+
+	default:
+		log.Fatalf("unknown SOURCE=%q (expected coindesk, simulated, replay or websocket)", kind)
+		return nil
+	}
+}
+
+func newCoinDeskPoller(interval time.Duration) *source.Poller {
+	f := fetcher.NewPriceFetcher(os.Getenv("COINDESK_API_KEY"), os.Getenv("COINDESK_API_URL"))
+	return source.NewPoller(f, "coindesk", defaultInstrument, interval)
+}
+
+// newStreamSource subscribes to a provider's WebSocket feed, falling back to polling CoinDesk
+// during outages when an API key is configured. It returns the source and its fastest publish interval.
+func newStreamSource(interval time.Duration) (*source.Stream, time.Duration) {
+	url := os.Getenv("WS_URL")
+	if url == "" {
+		log.Fatal("WS_URL env var not set (e.g. wss://feed.example.com/ws)")
+	}
+	maxRate := envFloat("WS_MAX_RATE", 1)
+	if maxRate <= 0 {
+		log.Fatal("WS_MAX_RATE must be positive")
+	}
+
+	cfg := source.StreamConfig{
+		URL:           url,
+		Subscribe:     os.Getenv("WS_SUBSCRIBE"),
+		PricePath:     envString("WS_PRICE_PATH", "price"),
+		Instrument:    defaultInstrument,
+		Provider:      envString("WS_PROVIDER", "websocket"),
+		TimePath:      os.Getenv("WS_TIME_PATH"),
+		BidPath:       os.Getenv("WS_BID_PATH"),
+		AskPath:       os.Getenv("WS_ASK_PATH"),
+		VolumePath:    os.Getenv("WS_VOLUME_PATH"),
+		MaxRate:       maxRate,
+		ReadTimeout:   envDuration("WS_READ_TIMEOUT", 30*time.Second),
+		FallbackAfter: envDuration("WS_FALLBACK_AFTER", 10*time.Second),
+	}
+	if os.Getenv("COINDESK_API_KEY") != "" && os.Getenv("COINDESK_API_URL") != "" {
+		cfg.Fallback = newCoinDeskPoller(interval)
+	} else {
+		log.Println("[!] no COINDESK_API_KEY: prices stop while the WebSocket feed is down")
+	}
+	log.Printf("Streaming %s prices from %s", defaultInstrument, url)
+	return source.NewStream(cfg), min(interval, time.Duration(float64(time.Second)/maxRate))
+}
@@ -0,0 +1,90 @@
+package source
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"github.com/matheusdutrademoura/injective/internal/fx"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Rates converts between currencies, like fx.Cache.
+type Rates interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// Converter is a PriceSource that publishes synthetic instruments alongside the native ones they
+// are derived from: every BTC-USD update is followed by a BTC-EUR update at the price times the
+// USD-EUR rate. Synthetic updates name the native instrument in DerivedFrom, carry the rate applied,
+// and add the FX provider to their sources.
+type Converter struct {
+	src        PriceSource
+	rates      Rates
+	provider   string
+	synthetics []fx.Synthetic
+}
+
+// NewConverter wraps src, deriving synthetics with rates labelled as coming from provider.
+func NewConverter(src PriceSource, rates Rates, provider string, synthetics []fx.Synthetic) *Converter {
+	return &Converter{src: src, rates: rates, provider: provider, synthetics: synthetics}
+}
+
+// Run forwards src's updates, each followed by the synthetic instruments derived from it.
+// While a rate is unavailable its synthetic instrument is skipped; the native one keeps flowing.
+func (c *Converter) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	// Ask for every rate up front, so a cache can start fetching before the first tick.
+	for _, synthetic := range c.synthetics {
+		c.rate(ctx, synthetic)
+	}
+
+	failing := make(map[string]bool)
+	return c.src.Run(ctx, func(update models.PriceUpdate) {
+		emit(update)
+
+		for _, synthetic := range c.synthetics {
+			if synthetic.From != update.Instrument {
+				continue
+			}
+			rate, err := c.rate(ctx, synthetic)
+			if err != nil {
+				if !failing[synthetic.Instrument] {
+					log.Printf("[!] not publishing %s: %v", synthetic.Instrument, err)
+					failing[synthetic.Instrument] = true
+				}
+				continue
+			}
+			if failing[synthetic.Instrument] {
+				log.Printf("publishing %s again", synthetic.Instrument)
+				delete(failing, synthetic.Instrument)
+			}
+			emit(convert(update, synthetic.Instrument, rate, c.provider))
+		}
+	})
+}
+
+// rate returns the rate converting synthetic's native instrument into it.
+func (c *Converter) rate(ctx context.Context, synthetic fx.Synthetic) (float64, error) {
+	_, from, _ := fx.Split(synthetic.From)
+	_, to, _ := fx.Split(synthetic.Instrument)
+	return c.rates.Rate(ctx, from, to)
+}
+
+// convert derives instrument's update from a native update at rate.
+func convert(native models.PriceUpdate, instrument string, rate float64, provider string) models.PriceUpdate {
+	update := native
+	update.Instrument = instrument
+	update.Price *= rate
+	update.Bid *= rate
+	update.Ask *= rate
+	update.Sources = append(slices.Clone(native.Sources), "fx:"+provider)
+	if native.Providers != nil {
+		update.Providers = make(map[string]float64, len(native.Providers))
+		for name, price := range native.Providers {
+			update.Providers[name] = price * rate
+		}
+	}
+	update.DerivedFrom = native.Instrument
+	update.FXRate = rate
+	return update
+}
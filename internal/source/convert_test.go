@@ -0,0 +1,53 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/fx"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// fakeRates converts at fixed rates keyed by "FROM-TO", failing for pairs it lacks.
+type fakeRates map[string]float64
+
+func (r fakeRates) Rate(_ context.Context, from, to string) (float64, error) {
+	if rate, ok := r[from+"-"+to]; ok {
+		return rate, nil
+	}
+	return 0, errors.New("no rate")
+}
+
+// TestConverter tests that synthetic instruments follow their native one, marked as converted.
+func TestConverter(t *testing.T) {
+	src := staticSource{
+		{Instrument: "BTC-USD", Price: 100, Bid: 99, Ask: 101, Volume: 2, Sources: []string{"coindesk"}},
+		{Instrument: "ETH-USD", Price: 10},
+	}
+	synthetics := []fx.Synthetic{
+		{Instrument: "BTC-EUR", From: "BTC-USD"},
+		{Instrument: "BTC-BRL", From: "BTC-USD"}, // No rate
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var got []models.PriceUpdate
+	NewConverter(src, fakeRates{"USD-EUR": 0.5}, "ecb", synthetics).Run(ctx, func(u models.PriceUpdate) { got = append(got, u) })
+
+	if len(got) != 3 || got[0].Instrument != "BTC-USD" || got[2].Instrument != "ETH-USD" {
+		t.Fatalf("expected BTC-USD, BTC-EUR and ETH-USD, got %+v", got)
+	}
+	eur := got[1]
+	if eur.Instrument != "BTC-EUR" || eur.Price != 50 || eur.Bid != 49.5 || eur.Ask != 50.5 || eur.Volume != 2 {
+		t.Errorf("unexpected conversion %+v", eur)
+	}
+	if eur.DerivedFrom != "BTC-USD" || eur.FXRate != 0.5 || !slices.Equal(eur.Sources, []string{"coindesk", "fx:ecb"}) {
+		t.Errorf("expected provenance of the conversion, got %+v", eur)
+	}
+	if len(got[0].Sources) != 1 || got[0].DerivedFrom != "" {
+		t.Errorf("expected the native update untouched, got %+v", got[0])
+	}
+}
@@ -0,0 +1,38 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Dedup is a PriceSource that drops updates repeating the previous price of the same instrument,
+// so clients aren't sent ticks that carry no news. Updates reporting traded volume always pass.
+type Dedup struct {
+	src       PriceSource
+	keepAlive time.Duration
+}
+
+// NewDedup wraps src. When keepAlive is positive an unchanged price is still let through once
+// that long has passed since the last update, so consumers can tell a flat market from a stalled feed.
+func NewDedup(src PriceSource, keepAlive time.Duration) *Dedup {
+	return &Dedup{src: src, keepAlive: keepAlive}
+}
+
+func (d *Dedup) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	type last struct {
+		price float64
+		at    time.Time
+	}
+	seen := make(map[string]last)
+
+	return d.src.Run(ctx, func(update models.PriceUpdate) {
+		prev, ok := seen[update.Instrument]
+		if ok && prev.price == update.Price && update.Volume == 0 && (d.keepAlive <= 0 || update.Timestamp.Sub(prev.at) < d.keepAlive) {
+			return
+		}
+		seen[update.Instrument] = last{update.Price, update.Timestamp}
+		emit(update)
+	})
+}
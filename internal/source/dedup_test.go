@@ -0,0 +1,54 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// TestDedup tests that repeated prices are dropped per instrument, except for keep-alives.
+func TestDedup(t *testing.T) {
+	start := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	tick := func(sec int, instrument string, price float64) models.PriceUpdate {
+		return models.PriceUpdate{Timestamp: start.Add(time.Duration(sec) * time.Second), Instrument: instrument, Price: price}
+	}
+	src := staticSource{
+		tick(0, "BTC-USD", 100),
+		tick(5, "BTC-USD", 100), // repeat
+		tick(5, "ETH-USD", 100), // same price, other instrument
+		tick(10, "BTC-USD", 101),
+		tick(15, "BTC-USD", 101), // repeat
+		tick(40, "BTC-USD", 101), // keep-alive due
+		tick(45, "BTC-USD", 101), // repeat
+		tick(50, "BTC-USD", 100), // back to an earlier price is still a change
+		tick(55, "ETH-USD", 100.5),
+	}
+
+	tests := []struct {
+		name      string
+		keepAlive time.Duration
+		want      []int // Seconds of the ticks let through
+	}{
+		{"no keep-alive", 0, []int{0, 5, 10, 50, 55}},
+		{"keep-alive", 30 * time.Second, []int{0, 5, 10, 40, 50, 55}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			var got []models.PriceUpdate
+			NewDedup(src, tt.keepAlive).Run(ctx, func(u models.PriceUpdate) { got = append(got, u) })
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d updates, got %d", len(tt.want), len(got))
+			}
+			for i, u := range got {
+				if sec := int(u.Timestamp.Sub(start).Seconds()); sec != tt.want[i] {
+					t.Errorf("update %d: expected tick at %ds, got %ds", i, tt.want[i], sec)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Merge is a PriceSource combining several providers' feeds into one. Every update from any provider
+// is published as the consensus of each provider's latest price for the instrument: their median,
+// with the best bid and ask among them, listing each provider's price in Providers.
+// Providers silent for longer than maxAge are left out until they update again.
+type Merge struct {
+	providers map[string]PriceSource
+	maxAge    time.Duration
+}
+
+// NewMerge runs each of providers, keyed by the name reported in updates' sources.
+func NewMerge(providers map[string]PriceSource, maxAge time.Duration) *Merge {
+	return &Merge{providers: providers, maxAge: maxAge}
+}
+
+// Run publishes until ctx is cancelled or every provider has stopped, returning their errors.
+// A provider stopping early is logged and the others carry on.
+func (m *Merge) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	type tick struct {
+		provider string
+		update   models.PriceUpdate
+	}
+	ticks := make(chan tick)
+	stopped := make(chan error)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for name, src := range m.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := src.Run(ctx, func(u models.PriceUpdate) {
+				select {
+				case ticks <- tick{name, u}:
+				case <-ctx.Done():
+				}
+			})
+			if err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			select {
+			case stopped <- err:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	latest := make(map[string]map[string]models.PriceUpdate) // By instrument, then provider
+	var errs []error
+	for running := len(m.providers); running > 0; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-stopped:
+			running--
+			if err != nil {
+				log.Printf("[!] price source %v", err)
+				errs = append(errs, err)
+			}
+		case t := <-ticks:
+			quotes := latest[t.update.Instrument]
+			if quotes == nil {
+				quotes = make(map[string]models.PriceUpdate)
+				latest[t.update.Instrument] = quotes
+			}
+			quotes[t.provider] = t.update
+			emit(m.consensus(t.provider, quotes))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// consensus builds the update published when provider has just updated quotes, the latest update
+// from each provider of an instrument.
+func (m *Merge) consensus(provider string, quotes map[string]models.PriceUpdate) models.PriceUpdate {
+	update := quotes[provider]
+	update.Sources = []string{provider}
+
+	var names []string
+	var prices []float64
+	for name, q := range quotes {
+		if update.Timestamp.Sub(q.Timestamp) <= m.maxAge {
+			names = append(names, name)
+			prices = append(prices, q.Price)
+		}
+	}
+	if len(names) < 2 {
+		return update
+	}
+
+	slices.Sort(names)
+	update.Sources = names
+	update.Providers = make(map[string]float64, len(names))
+	update.Bid, update.Ask = 0, 0
+	for _, name := range names {
+		q := quotes[name]
+		update.Providers[name] = q.Price
+		if q.Bid > 0 && q.Ask > 0 {
+			if q.Bid > update.Bid {
+				update.Bid = q.Bid
+			}
+			if update.Ask == 0 || q.Ask < update.Ask {
+				update.Ask = q.Ask
+			}
+		}
+	}
+	update.Price = median(prices)
+	return update
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
@@ -0,0 +1,77 @@
+package source
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// chanSource emits the updates sent on it until ctx is cancelled or it is closed.
+type chanSource chan models.PriceUpdate
+
+func (s chanSource) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case u, ok := <-s:
+			if !ok {
+				return nil
+			}
+			emit(u)
+		}
+	}
+}
+
+// TestMerge tests that every provider update publishes the consensus of the fresh providers.
+func TestMerge(t *testing.T) {
+	start := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	a, b, c := make(chanSource), make(chanSource), make(chanSource)
+	out := make(chan models.PriceUpdate, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		m := NewMerge(map[string]PriceSource{"a": a, "b": b, "c": c}, 10*time.Second)
+		done <- m.Run(ctx, func(u models.PriceUpdate) { out <- u })
+	}()
+	tick := func(sec int, price, bid, ask float64) models.PriceUpdate {
+		return models.PriceUpdate{Timestamp: start.Add(time.Duration(sec) * time.Second), Price: price, Bid: bid, Ask: ask, Instrument: "BTC-USD", Sources: []string{"feed"}}
+	}
+
+	a <- tick(0, 100, 99, 101)
+	if u := <-out; u.Price != 100 || !slices.Equal(u.Sources, []string{"a"}) || u.Providers != nil {
+		t.Errorf("expected a lone provider's price as is, got %+v", u)
+	}
+	b <- tick(1, 102, 101.5, 102.5)
+	c <- tick(2, 110, 0, 0)
+	<-out // a and b
+	u := <-out
+	if u.Price != 102 || !slices.Equal(u.Sources, []string{"a", "b", "c"}) || len(u.Providers) != 3 || u.Providers["c"] != 110 {
+		t.Errorf("expected the median of three providers, got %+v", u)
+	}
+	if u.Bid != 101.5 || u.Ask != 101 {
+		t.Errorf("expected the best bid and ask among providers quoting them, got %v/%v", u.Bid, u.Ask)
+	}
+
+	// a is now 11s old and left out.
+	b <- tick(11, 104, 0, 0)
+	if u := <-out; u.Price != 107 || len(u.Providers) != 2 || !slices.Equal(u.Sources, []string{"b", "c"}) {
+		t.Errorf("expected the stale provider left out, got %+v", u)
+	}
+
+	close(a)
+	close(b)
+	close(c)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil once every provider stopped, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return once every provider stopped")
+	}
+}
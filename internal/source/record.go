@@ -0,0 +1,49 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Record is one line of a recording: an update as its source emitted it, plus when and where it was received.
+type Record struct {
+	RecordedAt time.Time          `json:"recorded_at"`
+	Source     string             `json:"source"`
+	Elapsed    float64            `json:"elapsed_ms"` // Time since the previous update from the source
+	Update     models.PriceUpdate `json:"update"`
+}
+
+// Recorder is a PriceSource that passes another source's updates through while appending them to w as NDJSON.
+type Recorder struct {
+	src  PriceSource
+	name string
+	enc  *json.Encoder
+}
+
+// NewRecorder records src, labelled name in the recording, to w.
+func NewRecorder(src PriceSource, name string, w io.Writer) *Recorder {
+	return &Recorder{src: src, name: name, enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	var last time.Time
+	return r.src.Run(ctx, func(update models.PriceUpdate) {
+		now := time.Now().UTC()
+		rec := Record{RecordedAt: now, Source: r.name, Update: update}
+		if !last.IsZero() {
+			rec.Elapsed = float64(now.Sub(last).Microseconds()) / 1000
+		}
+		last = now
+
+		// A failing recording shouldn't take the live feed down with it.
+		if err := r.enc.Encode(rec); err != nil {
+			log.Printf("error recording update: %v", err)
+		}
+		emit(update)
+	})
+}
@@ -0,0 +1,147 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// staticSource emits fixed updates and then waits for cancellation.
+type staticSource []models.PriceUpdate
+
+func (s staticSource) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	for _, u := range s {
+		emit(u)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// recording builds an NDJSON recording with updates gap apart.
+func recording(t *testing.T, gap time.Duration, prices ...float64) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	start := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	for i, p := range prices {
+		at := start.Add(time.Duration(i) * gap)
+		enc.Encode(Record{RecordedAt: at, Source: "coindesk", Update: models.PriceUpdate{Seq: uint64(i + 1), Timestamp: at, Price: p, Instrument: "BTC-USD"}})
+	}
+	return &buf
+}
+
+// replayAll runs a replay to the end and returns what it emitted.
+func replayAll(t *testing.T, p *Replay) []models.PriceUpdate {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var got []models.PriceUpdate
+	if err := p.Run(ctx, func(u models.PriceUpdate) { got = append(got, u) }); !errors.Is(err, ErrEndOfRecording) {
+		t.Fatalf("expected ErrEndOfRecording, got %v", err)
+	}
+	return got
+}
+
+// TestRecordAndReplay tests that a recording made by Recorder plays back the same prices.
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	src := staticSource{
+		{Timestamp: time.Now().UTC(), Price: 100, Instrument: "BTC-USD"},
+		{Timestamp: time.Now().UTC(), Price: 101, Instrument: "BTC-USD"},
+	}
+	passed := collect(t, NewRecorder(src, "coindesk", &buf), 2)
+	if passed[1].Price != 101 {
+		t.Errorf("expected updates to pass through, got %+v", passed)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 recorded lines, got %d", len(lines))
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("decoding record: %v", err)
+	}
+	if rec.Source != "coindesk" || rec.RecordedAt.IsZero() || rec.Update.Price != 101 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+
+	got := replayAll(t, NewReplay(&buf, ReplayConfig{}))
+	if len(got) != 2 || got[0].Price != 100 || got[1].Price != 101 {
+		t.Errorf("unexpected replay %+v", got)
+	}
+}
+
+// TestReplaySpeed tests that recorded gaps are scaled by the playback speed.
+func TestReplaySpeed(t *testing.T) {
+	start := time.Now()
+	got := replayAll(t, NewReplay(recording(t, time.Second, 100, 101, 102, 103), ReplayConfig{Speed: 20}))
+	elapsed := time.Since(start)
+
+	// Three one-second gaps at 20x take 150ms.
+	if elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected about 150ms of playback, took %v", elapsed)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 updates, got %d", len(got))
+	}
+	for _, u := range got {
+		if u.Seq != 0 {
+			t.Errorf("expected recorded sequence numbers to be cleared, got %d", u.Seq)
+		}
+		if time.Since(u.Timestamp) > time.Minute {
+			t.Errorf("expected timestamps rebased to now, got %v", u.Timestamp)
+		}
+	}
+
+	kept := replayAll(t, NewReplay(recording(t, time.Second, 100, 101), ReplayConfig{KeepTimestamps: true}))
+	if want := time.Date(2025, 5, 26, 12, 0, 1, 0, time.UTC); !kept[1].Timestamp.Equal(want) {
+		t.Errorf("expected recorded timestamp %v, got %v", want, kept[1].Timestamp)
+	}
+}
+
+// TestReplayStepwise tests that stepwise playback emits one update per Step.
+func TestReplayStepwise(t *testing.T) {
+	p := NewReplay(recording(t, time.Hour, 100, 101), ReplayConfig{Stepwise: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	emitted := make(chan models.PriceUpdate, 2)
+	done := make(chan error, 1)
+	go func() { done <- p.Run(ctx, func(u models.PriceUpdate) { emitted <- u }) }()
+
+	select {
+	case u := <-emitted:
+		t.Fatalf("emitted %+v before the first step", u)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for _, want := range []float64{100, 101} {
+		if err := p.Step(ctx); err != nil {
+			t.Fatalf("step: %v", err)
+		}
+		if u := <-emitted; u.Price != want {
+			t.Errorf("expected %v, got %v", want, u.Price)
+		}
+	}
+	if err := <-done; !errors.Is(err, ErrEndOfRecording) {
+		t.Errorf("expected ErrEndOfRecording, got %v", err)
+	}
+}
+
+// TestReplayMalformed tests that a corrupt recording reports the offending line.
+func TestReplayMalformed(t *testing.T) {
+	buf := recording(t, time.Second, 100)
+	buf.WriteString("{not json}\n")
+
+	err := NewReplay(buf, ReplayConfig{}).Run(context.Background(), func(models.PriceUpdate) {})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+}
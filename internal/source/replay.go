@@ -0,0 +1,108 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// ErrEndOfRecording is returned by Replay.Run once every record has been played.
+var ErrEndOfRecording = errors.New("end of recording")
+
+// ReplayConfig controls how a recording is played back.
+type ReplayConfig struct {
+	// Speed scales the recorded gaps between updates: 1 plays at the original pace, 60 plays an hour
+	// in a minute. Zero or less plays as fast as the pipeline accepts updates.
+	Speed float64
+
+	// Stepwise emits one update per call to Step, ignoring Speed.
+	Stepwise bool
+
+	// KeepTimestamps emits the recorded timestamps instead of the current time. Rebased timestamps
+	// keep replayed updates inside the history window and ?since queries working.
+	KeepTimestamps bool
+}
+
+// Replay is a PriceSource playing back a recording made by Recorder.
+type Replay struct {
+	r    io.Reader
+	cfg  ReplayConfig
+	step chan struct{}
+}
+
+func NewReplay(r io.Reader, cfg ReplayConfig) *Replay {
+	return &Replay{r: r, cfg: cfg, step: make(chan struct{})}
+}
+
+// Step releases the next update in stepwise mode. It blocks until the update is emitted or the replay ends.
+func (p *Replay) Step(ctx context.Context) error {
+	select {
+	case p.step <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Replay) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	scanner := bufio.NewScanner(p.r)
+	var prev time.Time
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("recording line %d: %w", line, err)
+		}
+
+		if err := p.wait(ctx, prev, rec.RecordedAt); err != nil {
+			return nil // cancelled
+		}
+		prev = rec.RecordedAt
+
+		update := rec.Update
+		update.Seq = 0 // the server numbers updates as it publishes them
+		if !p.cfg.KeepTimestamps {
+			update.Timestamp = time.Now().UTC()
+		}
+		emit(update)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ErrEndOfRecording
+}
+
+// wait paces playback before the record received at next, the previous one having been received at prev.
+func (p *Replay) wait(ctx context.Context, prev, next time.Time) error {
+	var ready <-chan time.Time
+	switch {
+	case p.cfg.Stepwise:
+		select {
+		case <-p.step:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case p.cfg.Speed > 0 && !prev.IsZero() && next.After(prev):
+		timer := time.NewTimer(time.Duration(float64(next.Sub(prev)) / p.cfg.Speed))
+		defer timer.Stop()
+		ready = timer.C
+	default:
+		return ctx.Err()
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,34 @@
+package source
+
+import (
+	"context"
+
+	"github.com/matheusdutrademoura/injective/internal/anomaly"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Screen is a PriceSource passing updates through an anomaly detector before they are published.
+// Suspicious updates are quarantined, flagged or passed through according to the detector's mode,
+// and reported either way.
+type Screen struct {
+	src      PriceSource
+	detector *anomaly.Detector
+	report   func(anomaly.Report)
+}
+
+// NewScreen wraps src, calling report for every suspicious update.
+func NewScreen(src PriceSource, detector *anomaly.Detector, report func(anomaly.Report)) *Screen {
+	return &Screen{src: src, detector: detector, report: report}
+}
+
+func (s *Screen) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	return s.src.Run(ctx, func(update models.PriceUpdate) {
+		publish, r := s.detector.Screen(&update)
+		if r != nil {
+			s.report(*r)
+		}
+		if publish {
+			emit(update)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package source
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// secondsPerYear annualizes drift and volatility, crypto markets never closing.
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// SimulatedConfig parameterises a geometric Brownian motion price path.
+type SimulatedConfig struct {
+	Instrument string
+	StartPrice float64
+	Drift      float64       // Annualized expected log return, e.g. 0.1 for +10% a year
+	Volatility float64       // Annualized standard deviation of log returns, e.g. 0.8
+	Interval   time.Duration // Time between ticks
+	Seed       uint64        // Same seed, same path
+	SpreadBps  float64       // Bid/ask spread around the price in basis points; zero quotes no bid/ask
+
+	// Jumps model sudden moves: each tick jumps with JumpProbability, by a normally
+	// distributed log return with standard deviation JumpSize.
+	JumpProbability float64
+	JumpSize        float64
+
+	// Outages model provider failures: each tick starts one with OutageProbability,
+	// and no ticks are emitted for OutageDuration. The price keeps moving meanwhile.
+	OutageProbability float64
+	OutageDuration    time.Duration
+}
+
+// Simulated is a PriceSource generating prices offline, for development, demos and tests.
+type Simulated struct {
+	cfg   SimulatedConfig
+	rng   *rand.Rand
+	price float64
+	dt    float64 // Interval in years
+
+	outageTicks int // Ticks left in the current outage
+}
+
+func NewSimulated(cfg SimulatedConfig) *Simulated {
+	return &Simulated{
+		cfg:   cfg,
+		rng:   rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+		price: cfg.StartPrice,
+		dt:    cfg.Interval.Seconds() / secondsPerYear,
+	}
+}
+
+// Run emits a tick every interval until ctx is cancelled.
+func (s *Simulated) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if price, ok := s.next(); ok {
+			update := models.PriceUpdate{Timestamp: time.Now().UTC(), Price: price, Instrument: s.cfg.Instrument, Sources: []string{"simulated"}}
+			if s.cfg.SpreadBps > 0 {
+				half := price * s.cfg.SpreadBps / 20000
+				update.Bid, update.Ask = price-half, price+half
+			}
+			emit(update)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// next advances the path by one interval. It reports false while an outage is in progress.
+func (s *Simulated) next() (float64, bool) {
+	// The first tick is the start price itself.
+	price := s.price
+	mu, sigma := s.cfg.Drift, s.cfg.Volatility
+	logReturn := (mu-sigma*sigma/2)*s.dt + sigma*math.Sqrt(s.dt)*s.rng.NormFloat64()
+	if s.cfg.JumpProbability > 0 && s.rng.Float64() < s.cfg.JumpProbability {
+		logReturn += s.cfg.JumpSize * s.rng.NormFloat64()
+	}
+	s.price *= math.Exp(logReturn)
+
+	if s.outageTicks > 0 {
+		s.outageTicks--
+		return 0, false
+	}
+	if s.cfg.OutageProbability > 0 && s.rng.Float64() < s.cfg.OutageProbability {
+		s.outageTicks = max(int(s.cfg.OutageDuration/s.cfg.Interval), 1) - 1
+		return 0, false
+	}
+	return price, true
+}
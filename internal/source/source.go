@@ -0,0 +1,61 @@
+// Package source provides the price feeds the server publishes from.
+package source
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/fetcher"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// PriceSource produces price updates. Run emits updates until ctx is cancelled, returning nil,
+// or the source can't continue, returning an error. Emitted updates have no sequence number;
+// the server assigns one before publishing.
+type PriceSource interface {
+	Run(ctx context.Context, emit func(models.PriceUpdate)) error
+}
+
+// Fetcher returns the current quote on demand, like fetcher.PriceFetcher.
+type Fetcher interface {
+	FetchQuote() (fetcher.Quote, error)
+}
+
+// Poller adapts a Fetcher into a PriceSource by fetching every interval.
+type Poller struct {
+	fetcher    Fetcher
+	provider   string
+	instrument string
+	interval   time.Duration
+}
+
+// NewPoller polls f for instrument every interval, labelling updates with the provider's name.
+func NewPoller(f Fetcher, provider, instrument string, interval time.Duration) *Poller {
+	return &Poller{fetcher: f, provider: provider, instrument: instrument, interval: interval}
+}
+
+// Run fetches immediately and then every interval. Failed fetches are logged and skipped.
+func (p *Poller) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	for {
+		q, err := p.fetcher.FetchQuote()
+		if err != nil {
+			log.Printf("error fetching price: %v", err)
+		} else {
+			emit(models.PriceUpdate{
+				Timestamp:         time.Now().UTC(),
+				Price:             q.Price,
+				Instrument:        p.instrument,
+				Sources:           []string{p.provider},
+				ProviderTimestamp: q.UpdatedAt,
+				FetchLatencyMs:    float64(q.RoundTrip.Microseconds()) / 1000,
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.interval):
+		}
+	}
+}
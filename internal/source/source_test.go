@@ -0,0 +1,167 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/fetcher"
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// collect runs src until n updates were emitted or the timeout passes.
+func collect(t *testing.T, src PriceSource, n int) []models.PriceUpdate {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var got []models.PriceUpdate
+	done := make(chan error, 1)
+	go func() {
+		done <- src.Run(ctx, func(u models.PriceUpdate) {
+			mu.Lock()
+			defer mu.Unlock()
+			if got = append(got, u); len(got) == n {
+				cancel()
+			}
+		})
+	}()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) < n {
+		t.Fatalf("expected %d updates, got %d", n, len(got))
+	}
+	return got[:n]
+}
+
+type fakeFetcher struct {
+	prices []float64
+	errs   []error
+	calls  int
+}
+
+func (f *fakeFetcher) FetchQuote() (fetcher.Quote, error) {
+	i := f.calls % len(f.prices)
+	f.calls++
+	return fetcher.Quote{Price: f.prices[i], UpdatedAt: time.Unix(1_700_000_000, 0), RoundTrip: 25 * time.Millisecond}, f.errs[i]
+}
+
+// TestPollerSkipsFailedFetches tests that the polling adapter emits successful fetches only.
+func TestPollerSkipsFailedFetches(t *testing.T) {
+	f := &fakeFetcher{prices: []float64{100, 0, 102}, errs: []error{nil, errors.New("timeout"), nil}}
+	got := collect(t, NewPoller(f, "coindesk", "BTC-USD", time.Millisecond), 2)
+
+	if got[0].Price != 100 || got[1].Price != 102 {
+		t.Errorf("expected prices 100 and 102, got %v and %v", got[0].Price, got[1].Price)
+	}
+	if got[0].Instrument != "BTC-USD" || got[0].Timestamp.IsZero() || got[0].Seq != 0 {
+		t.Errorf("unexpected update %+v", got[0])
+	}
+	if len(got[0].Sources) != 1 || got[0].Sources[0] != "coindesk" || got[0].FetchLatencyMs != 25 || got[0].ProviderTimestamp.Unix() != 1_700_000_000 {
+		t.Errorf("expected provenance in %+v", got[0])
+	}
+}
+
+// TestSimulatedDeterministic tests that a seed reproduces the same path and another seed doesn't.
+func TestSimulatedDeterministic(t *testing.T) {
+	cfg := SimulatedConfig{StartPrice: 60000, Volatility: 0.8, Interval: time.Second, JumpProbability: 0.1, JumpSize: 0.05, Seed: 42}
+	a, b := NewSimulated(cfg), NewSimulated(cfg)
+	cfg.Seed = 43
+	c := NewSimulated(cfg)
+
+	differs := false
+	for i := 0; i < 100; i++ {
+		pa, _ := a.next()
+		pb, _ := b.next()
+		pc, _ := c.next()
+		if pa != pb {
+			t.Fatalf("tick %d: same seed diverged: %v vs %v", i, pa, pb)
+		}
+		differs = differs || pa != pc
+	}
+	if !differs {
+		t.Error("expected different seeds to produce different paths")
+	}
+}
+
+// TestSimulatedDynamics tests the drift, volatility and jump parameters.
+func TestSimulatedDynamics(t *testing.T) {
+	t.Run("no volatility follows drift exactly", func(t *testing.T) {
+		s := NewSimulated(SimulatedConfig{StartPrice: 100, Drift: 0.5, Interval: 24 * time.Hour})
+		var price float64
+		for i := 0; i <= 365; i++ {
+			price, _ = s.next()
+		}
+		if want := 100 * math.Exp(0.5); math.Abs(price-want) > 1e-6 {
+			t.Errorf("expected %v after a year, got %v", want, price)
+		}
+	})
+
+	t.Run("realized volatility matches", func(t *testing.T) {
+		s := NewSimulated(SimulatedConfig{StartPrice: 100, Volatility: 0.8, Interval: time.Minute, Seed: 1})
+		const n = 20000
+		prev, _ := s.next()
+		var sum, sumSq float64
+		for i := 0; i < n; i++ {
+			p, _ := s.next()
+			r := math.Log(p / prev)
+			sum, sumSq, prev = sum+r, sumSq+r*r, p
+		}
+		stdev := math.Sqrt(sumSq/n - (sum/n)*(sum/n))
+		if annualized := stdev / math.Sqrt(s.dt); math.Abs(annualized-0.8) > 0.04 {
+			t.Errorf("expected annualized volatility near 0.8, got %.3f", annualized)
+		}
+	})
+
+	t.Run("jumps", func(t *testing.T) {
+		s := NewSimulated(SimulatedConfig{StartPrice: 100, Interval: time.Second, JumpProbability: 1, JumpSize: 0.1, Seed: 7})
+		s.next()
+		if p, _ := s.next(); p == 100 {
+			t.Error("expected a jump to move the price")
+		}
+	})
+}
+
+// TestSimulatedOutage tests that outages suppress ticks for their duration while the price keeps moving.
+func TestSimulatedOutage(t *testing.T) {
+	s := NewSimulated(SimulatedConfig{
+		StartPrice: 100, Drift: 1, Interval: time.Second,
+		OutageProbability: 1, OutageDuration: 3 * time.Second,
+	})
+	for i := 0; i < 3; i++ {
+		if _, ok := s.next(); ok {
+			t.Fatalf("tick %d: expected outage", i)
+		}
+	}
+	s.cfg.OutageProbability = 0
+	p, ok := s.next()
+	if !ok {
+		t.Fatal("expected ticks to resume after the outage")
+	}
+	if p <= 100 {
+		t.Errorf("expected the price to have drifted during the outage, got %v", p)
+	}
+}
+
+// TestSimulatedRun tests that the simulated source emits ticks for its instrument.
+func TestSimulatedRun(t *testing.T) {
+	src := NewSimulated(SimulatedConfig{Instrument: "BTC-USD", StartPrice: 60000, Volatility: 0.8, Interval: time.Millisecond, Seed: 1, SpreadBps: 2})
+	got := collect(t, src, 5)
+	if got[0].Price != 60000 || got[0].Instrument != "BTC-USD" || got[0].Sources[0] != "simulated" {
+		t.Errorf("unexpected first update %+v", got[0])
+	}
+	if got[0].Bid != 59994 || got[0].Ask != 60006 {
+		t.Errorf("expected a 2bps spread around 60000, got bid %v ask %v", got[0].Bid, got[0].Ask)
+	}
+	if got[4].Price == 60000 {
+		t.Error("expected the price to move")
+	}
+}
@@ -0,0 +1,327 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/websocket"
+)
+
+// StreamConfig describes a provider's WebSocket tick feed.
+type StreamConfig struct {
+	URL        string // ws:// or wss:// endpoint
+	Subscribe  string // Message sent after every (re)connect, e.g. {"action":"subscribe","symbol":"BTC-USD"}
+	PricePath  string // Dotted path to the price in each message, e.g. "data.price" or "ticks.0.p"
+	Instrument string
+	Provider   string // Name reported in updates' sources
+
+	// Optional paths to the provider's timestamp (Unix seconds or milliseconds, or RFC 3339), best bid and ask,
+	// and the volume traded in each tick.
+	TimePath   string
+	BidPath    string
+	AskPath    string
+	VolumePath string
+
+	// MaxRate caps published updates per second. Ticks arriving faster are conflated:
+	// only the latest is published when the next slot opens. Zero publishes every tick.
+	MaxRate float64
+
+	ReadTimeout time.Duration // Reconnect when the feed is silent this long (default 30s)
+	MinBackoff  time.Duration // First reconnect delay (default 500ms)
+	MaxBackoff  time.Duration // Reconnect delay cap (default 30s)
+
+	// Fallback, typically a Poller, takes over once the feed has been down for FallbackAfter
+	// and stops as soon as the feed is back.
+	Fallback      PriceSource
+	FallbackAfter time.Duration
+}
+
+// Stream is a PriceSource forwarding ticks from a persistent WebSocket subscription as they arrive.
+type Stream struct {
+	cfg StreamConfig
+}
+
+func NewStream(cfg StreamConfig) *Stream {
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 30 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	return &Stream{cfg: cfg}
+}
+
+// Run keeps the subscription alive until ctx is cancelled, publishing ticks from the feed, or from
+// the fallback while the feed is down, at no more than MaxRate.
+func (s *Stream) Run(ctx context.Context, emit func(models.PriceUpdate)) error {
+	// Cancelling ctx on return stops the connection goroutine, which is awaited last.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ticks := make(chan models.PriceUpdate)
+	up := make(chan bool)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.connect(ctx, ticks, up)
+	}()
+
+	// The feed counts as down from the start, so the fallback covers a feed that never connects.
+	var fallbackTimer <-chan time.Time
+	if s.cfg.Fallback != nil {
+		fallbackTimer = time.After(s.cfg.FallbackAfter)
+	}
+	var stopFallback func()
+	defer func() {
+		if stopFallback != nil {
+			stopFallback()
+		}
+	}()
+
+	var minInterval time.Duration
+	if s.cfg.MaxRate > 0 {
+		minInterval = time.Duration(float64(time.Second) / s.cfg.MaxRate)
+	}
+	var lastEmit time.Time
+	var pending *models.PriceUpdate
+	var flush <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case connected := <-up:
+			switch {
+			case connected:
+				fallbackTimer = nil
+				if stopFallback != nil {
+					log.Printf("price stream restored, stopping fallback source")
+					stopFallback()
+					stopFallback = nil
+				}
+			case s.cfg.Fallback != nil && stopFallback == nil && fallbackTimer == nil:
+				fallbackTimer = time.After(s.cfg.FallbackAfter)
+			}
+
+		case <-fallbackTimer:
+			fallbackTimer = nil
+			log.Printf("[!] price stream down for %v, falling back to polling", s.cfg.FallbackAfter)
+			stopFallback = s.startFallback(ctx, ticks)
+
+		case update := <-ticks:
+			if since := time.Since(lastEmit); pending == nil && since >= minInterval {
+				emit(update)
+				lastEmit = time.Now()
+			} else {
+				if pending == nil {
+					flush = time.After(minInterval - since)
+				} else {
+					update.Volume += pending.Volume // Conflated ticks still traded
+				}
+				pending = &update
+			}
+
+		case <-flush:
+			emit(*pending)
+			lastEmit, pending, flush = time.Now(), nil, nil
+		}
+	}
+}
+
+// startFallback runs the fallback source into ticks and returns a function stopping it.
+func (s *Stream) startFallback(ctx context.Context, ticks chan<- models.PriceUpdate) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := s.cfg.Fallback.Run(ctx, func(update models.PriceUpdate) {
+			select {
+			case ticks <- update:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			log.Printf("[!] fallback source stopped: %v", err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// connect maintains the WebSocket session, reconnecting with exponential backoff, and reports state changes on up.
+func (s *Stream) connect(ctx context.Context, ticks chan<- models.PriceUpdate, up chan<- bool) {
+	backoff := s.cfg.MinBackoff
+	for {
+		connected, err := s.session(ctx, ticks, up)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = s.cfg.MinBackoff
+			select {
+			case up <- false:
+			case <-ctx.Done():
+				return
+			}
+		}
+		log.Printf("[!] price stream disconnected: %v (retrying in %v)", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.cfg.MaxBackoff)
+	}
+}
+
+// session runs one connection until it fails. It reports whether the subscription was established.
+func (s *Stream) session(ctx context.Context, ticks chan<- models.PriceUpdate, up chan<- bool) (bool, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	conn, err := websocket.Dial(dialCtx, s.cfg.URL, nil)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if s.cfg.Subscribe != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(s.cfg.Subscribe)); err != nil {
+			return false, fmt.Errorf("subscribing: %w", err)
+		}
+	}
+	select {
+	case up <- true:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.cfg.ReadTimeout))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+
+		// Acknowledgements, heartbeats and other messages without a price are skipped.
+		update, ok := s.parse(msg)
+		if !ok {
+			continue
+		}
+		select {
+		case ticks <- update:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// parse turns a feed message into an update. It reports false for messages without a price.
+func (s *Stream) parse(msg []byte) (models.PriceUpdate, bool) {
+	var v any
+	if err := json.Unmarshal(msg, &v); err != nil {
+		return models.PriceUpdate{}, false
+	}
+	price, err := lookupNumber(v, s.cfg.PricePath)
+	if err != nil || price <= 0 {
+		return models.PriceUpdate{}, false
+	}
+
+	update := models.PriceUpdate{Timestamp: time.Now().UTC(), Price: price, Instrument: s.cfg.Instrument}
+	if s.cfg.Provider != "" {
+		update.Sources = []string{s.cfg.Provider}
+	}
+	if s.cfg.TimePath != "" {
+		update.ProviderTimestamp = lookupTime(v, s.cfg.TimePath)
+	}
+	if s.cfg.BidPath != "" && s.cfg.AskPath != "" {
+		bid, bidErr := lookupNumber(v, s.cfg.BidPath)
+		ask, askErr := lookupNumber(v, s.cfg.AskPath)
+		if bidErr == nil && askErr == nil {
+			update.Bid, update.Ask = bid, ask
+		}
+	}
+	if s.cfg.VolumePath != "" {
+		if volume, err := lookupNumber(v, s.cfg.VolumePath); err == nil && volume > 0 {
+			update.Volume = volume
+		}
+	}
+	return update, true
+}
+
+var errNotFound = errors.New("not found")
+
+// lookup finds the value at a dotted path in decoded JSON. Numeric segments index into arrays.
+func lookup(v any, path string) (any, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, errNotFound
+			}
+			v = node[i]
+		default:
+			return nil, errNotFound
+		}
+	}
+	if v == nil {
+		return nil, errNotFound
+	}
+	return v, nil
+}
+
+// lookupNumber finds a number, or numeric string, at a dotted path.
+func lookupNumber(v any, path string) (float64, error) {
+	v, err := lookup(v, path)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, errNotFound
+}
+
+// lookupTime finds a timestamp at a dotted path, returning the zero time if there is none.
+// Numbers above 1e12 are taken as Unix milliseconds, smaller ones as seconds.
+func lookupTime(v any, path string) time.Time {
+	v, err := lookup(v, path)
+	if err != nil {
+		return time.Time{}
+	}
+	switch t := v.(type) {
+	case float64:
+		if t > 1e12 {
+			return time.UnixMilli(int64(t)).UTC()
+		}
+		return time.Unix(int64(t), 0).UTC()
+	case string:
+		parsed, _ := time.Parse(time.RFC3339Nano, t)
+		return parsed.UTC()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,166 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+	"github.com/matheusdutrademoura/injective/internal/websocket/wstest"
+)
+
+// runStream starts a Stream and returns the channel of updates it emits.
+func runStream(t *testing.T, cfg StreamConfig) <-chan models.PriceUpdate {
+	t.Helper()
+	cfg.MinBackoff = 10 * time.Millisecond
+	cfg.MaxBackoff = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan models.PriceUpdate, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewStream(cfg).Run(ctx, func(u models.PriceUpdate) { out <- u })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return out
+}
+
+func next(t *testing.T, updates <-chan models.PriceUpdate) models.PriceUpdate {
+	t.Helper()
+	select {
+	case u := <-updates:
+		return u
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for update")
+		return models.PriceUpdate{}
+	}
+}
+
+const subscribe = `{"action":"subscribe","symbol":"BTC-USD"}`
+
+// TestStreamForwardsTicks tests that ticks are forwarded as they arrive and other messages skipped.
+func TestStreamForwardsTicks(t *testing.T) {
+	feed := wstest.NewServer(t)
+	updates := runStream(t, StreamConfig{URL: feed.URL(), Subscribe: subscribe, PricePath: "data.0.p", Instrument: "BTC-USD"})
+
+	if got := feed.WaitForReceived(1); len(got) != 1 || got[0] != subscribe {
+		t.Fatalf("expected subscription, got %q", got)
+	}
+	feed.Send(`{"type":"subscribed"}`)
+	feed.Send(`{"data":[{"p":"64000.5"}]}`)
+	feed.Send(`{"data":[{"p":64001}]}`)
+
+	for _, want := range []float64{64000.5, 64001} {
+		if u := next(t, updates); u.Price != want || u.Instrument != "BTC-USD" || u.Timestamp.IsZero() {
+			t.Errorf("expected %v, got %+v", want, u)
+		}
+	}
+}
+
+// TestStreamConflates tests that a burst of ticks is reduced to the first and the latest.
+func TestStreamConflates(t *testing.T) {
+	feed := wstest.NewServer(t)
+	updates := runStream(t, StreamConfig{URL: feed.URL(), PricePath: "p", MaxRate: 5})
+	if !feed.WaitForClients(1) {
+		t.Fatal("stream did not connect")
+	}
+
+	for i := 1; i <= 5; i++ {
+		feed.Send(fmt.Sprintf(`{"p":%d}`, i))
+	}
+	first := next(t, updates)
+	start := time.Now()
+	second := next(t, updates)
+	if first.Price != 1 || second.Price != 5 {
+		t.Errorf("expected prices 1 and 5, got %v and %v", first.Price, second.Price)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("expected the conflated tick to wait for the next slot, got %v", waited)
+	}
+	select {
+	case u := <-updates:
+		t.Errorf("unexpected extra update %+v", u)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// TestStreamResubscribes tests that a dropped connection is re-established and resubscribed.
+func TestStreamResubscribes(t *testing.T) {
+	feed := wstest.NewServer(t)
+	updates := runStream(t, StreamConfig{URL: feed.URL(), Subscribe: subscribe, PricePath: "p"})
+	feed.WaitForReceived(1)
+
+	feed.DropConnections()
+	if got := feed.WaitForReceived(2); len(got) != 2 || got[1] != subscribe {
+		t.Fatalf("expected a second subscription, got %q", got)
+	}
+	feed.Send(`{"p":42}`)
+	if u := next(t, updates); u.Price != 42 {
+		t.Errorf("expected 42 after reconnecting, got %v", u.Price)
+	}
+}
+
+// TestStreamFallback tests that polling covers an outage and stops once the feed is back.
+func TestStreamFallback(t *testing.T) {
+	feed := wstest.NewServer(t)
+	feed.Reject(true)
+
+	fallback := NewPoller(&fakeFetcher{prices: []float64{1}, errs: []error{nil}}, "coindesk", "BTC-USD", 10*time.Millisecond)
+	updates := runStream(t, StreamConfig{URL: feed.URL(), PricePath: "p", Fallback: fallback, FallbackAfter: 50 * time.Millisecond})
+
+	if u := next(t, updates); u.Price != 1 {
+		t.Fatalf("expected a fallback price during the outage, got %v", u.Price)
+	}
+
+	feed.Reject(false)
+	if !feed.WaitForClients(1) {
+		t.Fatal("stream did not reconnect")
+	}
+	// Fallback ticks emitted before the switch may still be queued.
+	feed.Send(`{"p":500}`)
+	for u := next(t, updates); u.Price != 500; u = next(t, updates) {
+	}
+	select {
+	case u := <-updates:
+		t.Errorf("expected the fallback to stop, got %+v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestStreamParse tests price lookup by dotted path and the optional provider fields.
+func TestStreamParse(t *testing.T) {
+	tests := []struct {
+		msg, path string
+		want      float64
+		ok        bool
+	}{
+		{`{"price":1.5}`, "price", 1.5, true},
+		{`{"data":{"p":"2.5"}}`, "data.p", 2.5, true},
+		{`{"ticks":[{"p":3}]}`, "ticks.0.p", 3, true},
+		{`{"ticks":[]}`, "ticks.0.p", 0, false},
+		{`{"price":"n/a"}`, "price", 0, false},
+		{`{"price":0}`, "price", 0, false},
+		{`not json`, "price", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := NewStream(StreamConfig{PricePath: tt.path}).parse([]byte(tt.msg))
+		if got.Price != tt.want || ok != tt.ok {
+			t.Errorf("parse(%s) with path %q = %v, %v; want %v, %v", tt.msg, tt.path, got.Price, ok, tt.want, tt.ok)
+		}
+	}
+
+	s := NewStream(StreamConfig{PricePath: "p", Provider: "exchange", TimePath: "t", BidPath: "b", AskPath: "a", VolumePath: "v"})
+	u, _ := s.parse([]byte(`{"p":100,"t":1716732900123,"b":"99.5","a":100.5,"v":0.25}`))
+	if u.Sources[0] != "exchange" || u.ProviderTimestamp.UnixMilli() != 1716732900123 || u.Bid != 99.5 || u.Ask != 100.5 || u.Volume != 0.25 {
+		t.Errorf("unexpected update %+v", u)
+	}
+	u, _ = s.parse([]byte(`{"p":100,"t":"2024-05-26T14:15:00Z"}`))
+	if u.ProviderTimestamp.Unix() != 1716732900 || u.Bid != 0 {
+		t.Errorf("expected RFC 3339 time and no quote, got %+v", u)
+	}
+}
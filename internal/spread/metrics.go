@@ -0,0 +1,79 @@
+package spread
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Tracker keeps the latest spread of each instrument and counts how often each provider was the
+// outlier, for exposing as metrics. It is safe for concurrent use.
+type Tracker struct {
+	mutex    sync.Mutex
+	latest   map[string]Snapshot
+	outliers map[[2]string]uint64 // By instrument and provider
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{latest: make(map[string]Snapshot), outliers: make(map[[2]string]uint64)}
+}
+
+// Observe measures an update's spread as Of does and remembers it. An update from a single provider
+// clears its instrument's spread, so metrics don't report a disagreement that has gone stale.
+func (t *Tracker) Observe(u models.PriceUpdate) (Snapshot, bool) {
+	s, ok := Of(u)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !ok {
+		delete(t.latest, u.Instrument)
+		return s, false
+	}
+	t.latest[u.Instrument] = s
+	if s.Outlier != "" {
+		t.outliers[[2]string{s.Instrument, s.Outlier}]++
+	}
+	return s, true
+}
+
+// WriteMetrics writes the tracked spreads in the Prometheus text format, limited to the instruments
+// allow accepts.
+func (t *Tracker) WriteMetrics(w io.Writer, allow func(instrument string) bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	instruments := slices.DeleteFunc(slices.Sorted(maps.Keys(t.latest)), func(i string) bool { return !allow(i) })
+	gauge := func(name, help string, each func(s Snapshot)) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, instrument := range instruments {
+			each(t.latest[instrument])
+		}
+	}
+
+	gauge("injective_spread_bps", "Spread between the highest and lowest provider price, in basis points of the consensus price.", func(s Snapshot) {
+		fmt.Fprintf(w, "injective_spread_bps{instrument=%q} %g\n", s.Instrument, s.SpreadBps)
+	})
+	gauge("injective_provider_price", "Latest price from each provider.", func(s Snapshot) {
+		for _, q := range s.Providers {
+			fmt.Fprintf(w, "injective_provider_price{instrument=%q,provider=%q} %g\n", s.Instrument, q.Provider, q.Price)
+		}
+	})
+	gauge("injective_provider_deviation_bps", "Deviation of each provider's price from the consensus, in basis points.", func(s Snapshot) {
+		for _, q := range s.Providers {
+			fmt.Fprintf(w, "injective_provider_deviation_bps{instrument=%q,provider=%q} %g\n", s.Instrument, q.Provider, q.DeviationBps)
+		}
+	})
+
+	fmt.Fprintf(w, "# HELP injective_spread_outliers_total Updates at which each provider was the outlier.\n# TYPE injective_spread_outliers_total counter\n")
+	keys := slices.SortedFunc(maps.Keys(t.outliers), func(a, b [2]string) int {
+		return slices.Compare(a[:], b[:])
+	})
+	for _, key := range keys {
+		if allow(key[0]) {
+			fmt.Fprintf(w, "injective_spread_outliers_total{instrument=%q,provider=%q} %d\n", key[0], key[1], t.outliers[key])
+		}
+	}
+}
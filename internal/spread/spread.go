@@ -0,0 +1,68 @@
+// Package spread measures how far price providers disagree, from the per-provider prices of merged updates.
+package spread
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// Quote is one provider's price and its deviation from the consensus price.
+type Quote struct {
+	Provider     string  `json:"provider"`
+	Price        float64 `json:"price"`
+	DeviationBps float64 `json:"deviation_bps"`
+}
+
+// Snapshot describes the disagreement between providers at one update.
+// Spread and deviations are in basis points of the consensus price.
+type Snapshot struct {
+	Instrument string    `json:"instrument"`
+	Seq        uint64    `json:"seq,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Price      float64   `json:"price"` // Consensus price
+	SpreadBps  float64   `json:"spread_bps"`
+	High       string    `json:"high"`              // Provider quoting the highest price
+	Low        string    `json:"low"`               // Provider quoting the lowest price
+	Outlier    string    `json:"outlier,omitempty"` // Provider furthest from the consensus, with three or more providers
+	Providers  []Quote   `json:"providers"`         // Highest price first
+}
+
+// Of measures the spread of an update. It reports false for updates with fewer than two providers.
+func Of(u models.PriceUpdate) (Snapshot, bool) {
+	if len(u.Providers) < 2 || u.Price <= 0 {
+		return Snapshot{}, false
+	}
+	s := Snapshot{Instrument: u.Instrument, Seq: u.Seq, Timestamp: u.Timestamp, Price: u.Price}
+	for name, price := range u.Providers {
+		s.Providers = append(s.Providers, Quote{Provider: name, Price: price, DeviationBps: (price - u.Price) / u.Price * 1e4})
+	}
+	slices.SortFunc(s.Providers, func(a, b Quote) int {
+		return cmp.Or(cmp.Compare(b.Price, a.Price), cmp.Compare(a.Provider, b.Provider))
+	})
+
+	high, low := s.Providers[0], s.Providers[len(s.Providers)-1]
+	s.High, s.Low = high.Provider, low.Provider
+	s.SpreadBps = (high.Price - low.Price) / u.Price * 1e4
+
+	// With two providers both are equally far from their midpoint, so neither stands out.
+	// With more, the outlier must be strictly further out than every other provider.
+	if len(s.Providers) >= 3 {
+		var furthest, runnerUp float64
+		for _, q := range s.Providers {
+			switch d := math.Abs(q.DeviationBps); {
+			case d > furthest:
+				furthest, runnerUp, s.Outlier = d, furthest, q.Provider
+			case d > runnerUp:
+				runnerUp = d
+			}
+		}
+		if furthest == runnerUp {
+			s.Outlier = ""
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,63 @@
+package spread
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+// TestOf tests the spread, extremes and outlier of merged updates.
+func TestOf(t *testing.T) {
+	if _, ok := Of(models.PriceUpdate{Price: 100, Providers: map[string]float64{"a": 100}}); ok {
+		t.Error("expected no spread with a single provider")
+	}
+
+	two, ok := Of(models.PriceUpdate{Instrument: "BTC-USD", Price: 100, Providers: map[string]float64{"a": 99.5, "b": 100.5}})
+	if !ok || two.SpreadBps != 100 || two.High != "b" || two.Low != "a" || two.Outlier != "" {
+		t.Errorf("unexpected two-provider spread %+v", two)
+	}
+
+	three, _ := Of(models.PriceUpdate{Price: 100, Providers: map[string]float64{"a": 100, "b": 100.1, "c": 98}})
+	if math.Abs(three.SpreadBps-210) > 1e-9 || three.Outlier != "c" || three.Providers[0].Provider != "b" || three.Providers[2].DeviationBps != -200 {
+		t.Errorf("expected c as the outlier 200 bps below, got %+v", three)
+	}
+
+	tied, _ := Of(models.PriceUpdate{Price: 100, Providers: map[string]float64{"a": 99, "b": 100, "c": 101}})
+	if tied.Outlier != "" {
+		t.Errorf("expected no outlier when two are equally far out, got %q", tied.Outlier)
+	}
+}
+
+// TestTrackerMetrics tests the exported gauges and outlier counts, and that a lone provider clears them.
+func TestTrackerMetrics(t *testing.T) {
+	tr := NewTracker()
+	merged := models.PriceUpdate{Instrument: "BTC-USD", Price: 100, Providers: map[string]float64{"a": 100, "b": 100, "c": 99}}
+	tr.Observe(merged)
+	tr.Observe(merged)
+	tr.Observe(models.PriceUpdate{Instrument: "ETH-USD", Price: 10, Providers: map[string]float64{"a": 10, "b": 10.1}})
+
+	var out strings.Builder
+	tr.WriteMetrics(&out, func(instrument string) bool { return instrument == "BTC-USD" })
+	for _, want := range []string{
+		"# TYPE injective_spread_bps gauge\ninjective_spread_bps{instrument=\"BTC-USD\"} 100\n",
+		"injective_provider_price{instrument=\"BTC-USD\",provider=\"c\"} 99\n",
+		"injective_provider_deviation_bps{instrument=\"BTC-USD\",provider=\"c\"} -100\n",
+		"injective_spread_outliers_total{instrument=\"BTC-USD\",provider=\"c\"} 2\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "ETH-USD") {
+		t.Errorf("expected instruments not allowed to be left out:\n%s", out.String())
+	}
+
+	tr.Observe(models.PriceUpdate{Instrument: "BTC-USD", Price: 100})
+	out.Reset()
+	tr.WriteMetrics(&out, func(string) bool { return true })
+	if strings.Contains(out.String(), "injective_spread_bps{instrument=\"BTC-USD\"}") || !strings.Contains(out.String(), "ETH-USD") {
+		t.Errorf("expected only ETH-USD's spread once BTC-USD has a single provider:\n%s", out.String())
+	}
+}
@@ -0,0 +1,162 @@
+// Package stats maintains rolling price statistics per instrument over several windows,
+// updated incrementally as prices arrive.
+package stats
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+const year = 365 * 24 * time.Hour // Crypto trades around the clock, so volatility annualises over calendar time
+
+// Stats summarises an instrument's prices over a window. Percentages are plain numbers, 3.2 meaning 3.2%.
+type Stats struct {
+	Window      string    `json:"window"`
+	Ticks       int       `json:"ticks"`
+	From        time.Time `json:"from,omitzero"` // First and last price in the window
+	To          time.Time `json:"to,omitzero"`
+	Min         float64   `json:"min,omitempty"`
+	Max         float64   `json:"max,omitempty"`
+	Mean        float64   `json:"mean,omitempty"`
+	StdDev      float64   `json:"stddev"`
+	Volatility  float64   `json:"volatility"`   // Annualised standard deviation of log returns, in percent
+	MaxDrawdown float64   `json:"max_drawdown"` // Largest fall from a peak to a later trough, in percent of the peak
+}
+
+// Snapshot is an instrument's statistics over every window, shortest first.
+type Snapshot struct {
+	Instrument string    `json:"instrument"`
+	Seq        uint64    `json:"seq,omitempty"`
+	Timestamp  time.Time `json:"timestamp,omitzero"` // Of the latest update included
+	Windows    []Stats   `json:"windows"`
+}
+
+// Tracker keeps rolling windows per instrument. It is safe for concurrent use.
+type Tracker struct {
+	spans       []time.Duration
+	instruments map[string]*series
+	mutex       sync.Mutex
+}
+
+type series struct {
+	windows []*window
+	last    models.PriceUpdate
+}
+
+// New returns a tracker for the given window lengths.
+func New(spans []time.Duration) *Tracker {
+	return &Tracker{spans: slices.Sorted(slices.Values(spans)), instruments: make(map[string]*series)}
+}
+
+// Add feeds a price. Updates not newer than the instrument's latest, such as ones already
+// seen while backfilling, are ignored.
+func (t *Tracker) Add(u models.PriceUpdate) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	s := t.instruments[u.Instrument]
+	if s == nil {
+		s = &series{}
+		for _, span := range t.spans {
+			s.windows = append(s.windows, &window{span: span})
+		}
+		t.instruments[u.Instrument] = s
+	} else if !u.Timestamp.After(s.last.Timestamp) {
+		return
+	}
+
+	smp := sample{at: u.Timestamp, price: u.Price}
+	if s.last.Price > 0 && u.Price > 0 {
+		smp.ret, smp.hasRet = math.Log(u.Price/s.last.Price), true
+	}
+	s.last = u
+	for _, w := range s.windows {
+		w.push(smp)
+		w.expire(u.Timestamp)
+	}
+}
+
+// Snapshot returns the instrument's statistics as of now. Windows without prices report zero ticks.
+func (t *Tracker) Snapshot(instrument string, now time.Time) Snapshot {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	snap := Snapshot{Instrument: instrument}
+	s := t.instruments[instrument]
+	if s != nil {
+		snap.Seq, snap.Timestamp = s.last.Seq, s.last.Timestamp
+	}
+	for i, span := range t.spans {
+		st := Stats{Window: FormatWindow(span)}
+		if s != nil {
+			w := s.windows[i]
+			w.expire(now)
+			st.fill(w.total())
+		}
+		snap.Windows = append(snap.Windows, st)
+	}
+	return snap
+}
+
+func (st *Stats) fill(a agg) {
+	if a.count == 0 {
+		return
+	}
+	n := float64(a.count)
+	st.Ticks, st.From, st.To = a.count, a.first, a.last
+	st.Min, st.Max, st.Mean = a.min, a.max, a.sum/n
+	st.StdDev = sampleStdDev(a.sum, a.sumSq, a.count)
+	st.MaxDrawdown = a.drawdown * 100
+
+	// Scale the per-tick deviation by the number of ticks a year would have at the observed rate.
+	if a.returns >= 2 && a.last.After(a.first) {
+		perTick := a.last.Sub(a.first) / time.Duration(a.count-1)
+		st.Volatility = sampleStdDev(a.retSum, a.retSq, a.returns) * math.Sqrt(float64(year)/float64(perTick)) * 100
+	}
+}
+
+// sampleStdDev computes the standard deviation from running sums, with Bessel's correction.
+func sampleStdDev(sum, sumSq float64, count int) float64 {
+	if count < 2 {
+		return 0
+	}
+	n := float64(count)
+	return math.Sqrt(max(sumSq-sum*sum/n, 0) / (n - 1))
+}
+
+// FormatWindow writes a window length compactly: 5m, 1h, 24h, 90s.
+func FormatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// ParseWindows parses a comma-separated list of window lengths, e.g. "5m,1h,24h".
+func ParseWindows(s string) ([]time.Duration, error) {
+	var spans []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, errors.New("windows must be positive")
+		}
+		spans = append(spans, d)
+	}
+	return spans, nil
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/models"
+)
+
+var start = time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)
+
+func near(a, b, tolerance float64) bool { return math.Abs(a-b) <= tolerance*math.Max(1, math.Abs(b)) }
+
+// naive computes the statistics of prices directly, for comparison.
+func naive(prices []float64, prev float64) (mean, sd, drawdown, retSD float64) {
+	n := float64(len(prices))
+	for _, p := range prices {
+		mean += p
+	}
+	mean /= n
+	for _, p := range prices {
+		sd += (p - mean) * (p - mean)
+	}
+	sd = math.Sqrt(sd / (n - 1))
+
+	peak := prices[0]
+	for _, p := range prices {
+		peak = math.Max(peak, p)
+		drawdown = math.Max(drawdown, (peak-p)/peak)
+	}
+
+	var rets []float64
+	for _, p := range prices {
+		rets = append(rets, math.Log(p/prev))
+		prev = p
+	}
+	var rm float64
+	for _, r := range rets {
+		rm += r
+	}
+	rm /= float64(len(rets))
+	for _, r := range rets {
+		retSD += (r - rm) * (r - rm)
+	}
+	retSD = math.Sqrt(retSD / float64(len(rets)-1))
+	return mean, sd, drawdown * 100, retSD
+}
+
+// TestRollingWindows tests the sliding statistics against direct computation over a random walk.
+func TestRollingWindows(t *testing.T) {
+	tr := New([]time.Duration{time.Hour, 5 * time.Minute})
+	rng := rand.New(rand.NewPCG(1, 2))
+	var prices []float64
+	price := 60000.0
+	for i := range 3 * 3600 / 5 { // Three hours of 5s ticks
+		price *= math.Exp(rng.NormFloat64() * 0.001)
+		prices = append(prices, price)
+		tr.Add(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: start.Add(time.Duration(i) * 5 * time.Second), Price: price, Instrument: "BTC-USD"})
+
+		if i%97 != 0 || i < 720 {
+			continue
+		}
+		snap := tr.Snapshot("BTC-USD", start.Add(time.Duration(i)*5*time.Second))
+		for j, n := range []int{60, 720} { // Ticks in 5m and 1h, both inclusive of the cutoff
+			got := snap.Windows[j]
+			window := prices[len(prices)-n-1:]
+			mean, sd, dd, retSD := naive(window, prices[len(prices)-n-2])
+			if got.Ticks != len(window) || !near(got.Mean, mean, 1e-9) || !near(got.StdDev, sd, 1e-6) || !near(got.MaxDrawdown, dd, 1e-9) {
+				t.Fatalf("tick %d, %s: got %+v, want %d ticks, mean %v, stddev %v, drawdown %v", i, got.Window, got, len(window), mean, sd, dd)
+			}
+			if want := retSD * math.Sqrt(float64(year)/float64(5*time.Second)) * 100; !near(got.Volatility, want, 1e-6) {
+				t.Fatalf("tick %d, %s: volatility %v, want %v", i, got.Window, got.Volatility, want)
+			}
+		}
+	}
+}
+
+// TestSnapshot tests window order, names, drawdown and expiry at read time.
+func TestSnapshot(t *testing.T) {
+	tr := New([]time.Duration{24 * time.Hour, 5 * time.Minute, time.Hour})
+	for i, p := range []float64{100, 120, 90, 110, 60, 130} {
+		tr.Add(models.PriceUpdate{Seq: uint64(i + 1), Timestamp: start.Add(time.Duration(i) * time.Minute), Price: p, Instrument: "BTC-USD"})
+	}
+	tr.Add(models.PriceUpdate{Seq: 3, Timestamp: start.Add(2 * time.Minute), Price: 1, Instrument: "BTC-USD"}) // Already seen
+
+	snap := tr.Snapshot("BTC-USD", start.Add(5*time.Minute))
+	if snap.Seq != 6 || len(snap.Windows) != 3 || snap.Windows[0].Window != "5m" || snap.Windows[1].Window != "1h" || snap.Windows[2].Window != "24h" {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if h := snap.Windows[1]; h.Ticks != 6 || h.Min != 60 || h.Max != 130 || h.MaxDrawdown != 50 {
+		t.Errorf("expected the 1h window to hold every price with a 50%% drawdown, got %+v", h)
+	}
+	if m := snap.Windows[0]; m.Ticks != 6 {
+		t.Errorf("expected the 5m window to include the first price at its edge, got %d ticks", m.Ticks)
+	}
+
+	later := tr.Snapshot("BTC-USD", start.Add(2*time.Hour))
+	if later.Windows[0].Ticks != 0 || later.Windows[1].Ticks != 0 || later.Windows[2].Ticks != 6 {
+		t.Errorf("expected short windows to empty out, got %+v", later.Windows)
+	}
+	if unknown := tr.Snapshot("ETH-USD", start); len(unknown.Windows) != 3 || unknown.Windows[0].Ticks != 0 {
+		t.Errorf("expected empty windows for an unknown instrument, got %+v", unknown)
+	}
+}
+
+// TestParseWindows tests window lists and their names.
+func TestParseWindows(t *testing.T) {
+	spans, err := ParseWindows("5m, 1h,24h,90s")
+	if err != nil || len(spans) != 4 {
+		t.Fatalf("unexpected %v, %v", spans, err)
+	}
+	var names []string
+	for _, s := range spans {
+		names = append(names, FormatWindow(s))
+	}
+	if names[0] != "5m" || names[1] != "1h" || names[2] != "24h" || names[3] != "1m30s" {
+		t.Errorf("unexpected names %v", names)
+	}
+	for _, bad := range []string{"5", "0s", "-1h"} {
+		if _, err := ParseWindows(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
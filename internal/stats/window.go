@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// sample is one price in a window, with the log return from the instrument's previous price.
+type sample struct {
+	at     time.Time
+	price  float64
+	ret    float64
+	hasRet bool
+}
+
+// agg summarises a run of consecutive samples. Aggregates of adjacent runs combine in O(1),
+// which is what lets windows slide without rescanning their contents.
+type agg struct {
+	count         int
+	first, last   time.Time
+	min, max      float64
+	sum, sumSq    float64
+	drawdown      float64 // Largest fall from a peak to a later trough, as a fraction of the peak
+	returns       int
+	retSum, retSq float64
+}
+
+func single(s sample) agg {
+	a := agg{count: 1, first: s.at, last: s.at, min: s.price, max: s.price, sum: s.price, sumSq: s.price * s.price}
+	if s.hasRet {
+		a.returns, a.retSum, a.retSq = 1, s.ret, s.ret*s.ret
+	}
+	return a
+}
+
+// combine merges the aggregate of a run with that of the run straight after it.
+func combine(a, b agg) agg {
+	switch {
+	case a.count == 0:
+		return b
+	case b.count == 0:
+		return a
+	}
+	c := agg{
+		count:    a.count + b.count,
+		first:    a.first,
+		last:     b.last,
+		min:      math.Min(a.min, b.min),
+		max:      math.Max(a.max, b.max),
+		sum:      a.sum + b.sum,
+		sumSq:    a.sumSq + b.sumSq,
+		drawdown: math.Max(a.drawdown, b.drawdown),
+		returns:  a.returns + b.returns,
+		retSum:   a.retSum + b.retSum,
+		retSq:    a.retSq + b.retSq,
+	}
+	// Or the fall from the first run's peak to the second run's trough.
+	if a.max > 0 {
+		c.drawdown = math.Max(c.drawdown, (a.max-b.min)/a.max)
+	}
+	return c
+}
+
+// window holds the samples of the last span as a two-stack queue: new samples are pushed onto back,
+// whose aggregate is kept as they arrive, and old ones popped from front, where each entry holds the
+// aggregate of itself and everything newer in front. When front runs out, back is moved over.
+// Adding and expiring samples and reading the total are all amortised O(1).
+type window struct {
+	span    time.Duration
+	front   []entry // Oldest last
+	back    []sample
+	backAgg agg
+}
+
+type entry struct {
+	sample
+	agg agg
+}
+
+func (w *window) push(s sample) {
+	w.back = append(w.back, s)
+	w.backAgg = combine(w.backAgg, single(s))
+}
+
+// expire drops samples older than the span before now.
+func (w *window) expire(now time.Time) {
+	cutoff := now.Add(-w.span)
+	for {
+		if len(w.front) == 0 {
+			if len(w.back) == 0 || !w.back[0].at.Before(cutoff) {
+				return
+			}
+			w.flip()
+		}
+		if !w.front[len(w.front)-1].at.Before(cutoff) {
+			return
+		}
+		w.front = w.front[:len(w.front)-1]
+	}
+}
+
+// flip moves back onto front, newest first, so the oldest ends up on top.
+func (w *window) flip() {
+	var acc agg
+	for i := len(w.back) - 1; i >= 0; i-- {
+		acc = combine(single(w.back[i]), acc)
+		w.front = append(w.front, entry{w.back[i], acc})
+	}
+	w.back, w.backAgg = w.back[:0], agg{}
+}
+
+func (w *window) total() agg {
+	if len(w.front) == 0 {
+		return w.backAgg
+	}
+	return combine(w.front[len(w.front)-1].agg, w.backAgg)
+}
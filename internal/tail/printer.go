@@ -0,0 +1,129 @@
+package tail
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/pkg/injectiveclient"
+)
+
+// Output formats supported by Printer.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+const (
+	colorGreen = "\033[32m"
+	colorRed   = "\033[31m"
+	colorReset = "\033[0m"
+)
+
+// Printer renders price updates with the change versus the previous update of the same instrument.
+type Printer struct {
+	w      io.Writer
+	format string
+	color  bool
+
+	prev   map[string]float64
+	csv    *csv.Writer
+	header bool
+}
+
+func NewPrinter(w io.Writer, format string, color bool) (*Printer, error) {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV:
+	default:
+		return nil, fmt.Errorf("unknown format %q (expected table, json or csv)", format)
+	}
+	p := &Printer{w: w, format: format, color: color, prev: make(map[string]float64)}
+	if format == FormatCSV {
+		p.csv = csv.NewWriter(w)
+	}
+	return p, nil
+}
+
+// row is an update annotated with its change, as printed in every format.
+type row struct {
+	Seq        uint64    `json:"seq,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Instrument string    `json:"instrument"`
+	Price      float64   `json:"price"`
+	ChangePct  *float64  `json:"change_pct"` // nil for the first update of an instrument
+}
+
+func (p *Printer) Print(u injectiveclient.PriceUpdate) error {
+	r := row{Seq: u.Seq, Timestamp: u.Timestamp, Instrument: u.Instrument, Price: u.Price}
+	if prev, ok := p.prev[u.Instrument]; ok && prev != 0 {
+		pct := (u.Price - prev) / prev * 100
+		r.ChangePct = &pct
+	}
+	p.prev[u.Instrument] = u.Price
+
+	switch p.format {
+	case FormatJSON:
+		return json.NewEncoder(p.w).Encode(r)
+	case FormatCSV:
+		return p.printCSV(r)
+	default:
+		return p.printTable(r)
+	}
+}
+
+func (p *Printer) printTable(r row) error {
+	if !p.header {
+		p.header = true
+		if _, err := fmt.Fprintf(p.w, "%-8s  %-10s  %14s  %9s\n", "TIME", "INSTRUMENT", "PRICE", "CHANGE"); err != nil {
+			return err
+		}
+	}
+
+	marker, change, color := "•", "", ""
+	if r.ChangePct != nil {
+		change = fmt.Sprintf("%+.3f%%", *r.ChangePct)
+		switch {
+		case *r.ChangePct > 0:
+			marker, color = "▲", colorGreen
+		case *r.ChangePct < 0:
+			marker, color = "▼", colorRed
+		}
+	}
+
+	line := fmt.Sprintf("%-8s  %-10s  %14.2f  %9s %s", r.Timestamp.Local().Format("15:04:05"), r.Instrument, r.Price, change, marker)
+	if p.color && color != "" {
+		line = color + line + colorReset
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+func (p *Printer) printCSV(r row) error {
+	if !p.header {
+		p.header = true
+		if err := p.csv.Write([]string{"seq", "timestamp", "instrument", "price", "change_pct"}); err != nil {
+			return err
+		}
+	}
+
+	change := ""
+	if r.ChangePct != nil {
+		change = strconv.FormatFloat(*r.ChangePct, 'f', 6, 64)
+	}
+	err := p.csv.Write([]string{
+		strconv.FormatUint(r.Seq, 10),
+		r.Timestamp.UTC().Format(time.RFC3339Nano),
+		r.Instrument,
+		strconv.FormatFloat(r.Price, 'f', -1, 64),
+		change,
+	})
+	if err != nil {
+		return err
+	}
+	p.csv.Flush()
+	return p.csv.Error()
+}
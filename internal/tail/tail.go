@@ -0,0 +1,79 @@
+package tail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/pkg/injectiveclient"
+)
+
+// Config describes what `injective tail` streams and how it prints it.
+type Config struct {
+	URL         string
+	Token       string
+	Instruments []string
+	Since       time.Duration // Replay this much history first (0 for live only)
+	Format      string
+	Color       bool
+	Count       int // Stop after this many updates (0 for no limit)
+	MaxFailures int // Give up after this many connection attempts in a row fail (0 to retry forever)
+}
+
+// Run streams updates to out until ctx is cancelled or Count updates were printed.
+// Connection status goes to status (typically stderr) so it never mixes with machine-readable output.
+// It returns an error when the stream can't continue, e.g. the server rejected the token or stayed
+// unreachable for MaxFailures attempts.
+func Run(ctx context.Context, cfg Config, out, status io.Writer) error {
+	printer, err := NewPrinter(out, cfg.Format, cfg.Color)
+	if err != nil {
+		return err
+	}
+
+	clientCfg := injectiveclient.Config{URL: cfg.URL, Token: cfg.Token, Instruments: cfg.Instruments, MaxFailures: cfg.MaxFailures}
+	if cfg.Since > 0 {
+		clientCfg.Since = time.Now().Add(-cfg.Since)
+	}
+	c, err := injectiveclient.New(clientCfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- c.Run(ctx) }()
+
+	printed := 0
+	statuses := c.Status()
+	for {
+		select {
+		case ev, ok := <-statuses:
+			if !ok {
+				statuses = nil
+				continue
+			}
+			switch ev.Kind {
+			case injectiveclient.StatusDisconnected:
+				fmt.Fprintf(status, "disconnected: %v (retrying in %v)\n", ev.Err, ev.RetryIn.Round(time.Millisecond))
+			case injectiveclient.StatusServerShutdown:
+				fmt.Fprintln(status, "server is shutting down, will reconnect")
+			case injectiveclient.StatusAuthExpired:
+				fmt.Fprintln(status, "token expired")
+			}
+		case update, ok := <-c.Updates():
+			if !ok {
+				return <-runErr
+			}
+			if err := printer.Print(update); err != nil {
+				return err
+			}
+			printed++
+			if cfg.Count > 0 && printed >= cfg.Count {
+				cancel()
+				return nil
+			}
+		}
+	}
+}
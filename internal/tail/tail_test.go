@@ -0,0 +1,125 @@
+package tail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/pkg/injectiveclient"
+)
+
+var updates = []injectiveclient.PriceUpdate{
+	{Seq: 1, Timestamp: time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC), Instrument: "BTC-USD", Price: 100},
+	{Seq: 2, Timestamp: time.Date(2025, 5, 26, 12, 0, 5, 0, time.UTC), Instrument: "BTC-USD", Price: 102},
+	{Seq: 3, Timestamp: time.Date(2025, 5, 26, 12, 0, 10, 0, time.UTC), Instrument: "BTC-USD", Price: 101.49},
+}
+
+// TestPrinterFormats tests the table, JSON and CSV renderings including percent change.
+func TestPrinterFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		color  bool
+		want   []string
+	}{
+		{FormatTable, false, []string{"INSTRUMENT", "100.00", "+2.000% ▲", "-0.500% ▼"}},
+		{FormatTable, true, []string{colorGreen, colorRed, colorReset}},
+		{FormatJSON, false, []string{`"change_pct":null`, `"change_pct":2`, `"instrument":"BTC-USD"`}},
+		{FormatCSV, false, []string{"seq,timestamp,instrument,price,change_pct\n", "1,2025-05-26T12:00:00Z,BTC-USD,100,\n", "2,2025-05-26T12:00:05Z,BTC-USD,102,2.000000\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s color=%v", tt.format, tt.color), func(t *testing.T) {
+			var buf bytes.Buffer
+			p, err := NewPrinter(&buf, tt.format, tt.color)
+			if err != nil {
+				t.Fatalf("new printer: %v", err)
+			}
+			for _, u := range updates {
+				if err := p.Print(u); err != nil {
+					t.Fatalf("print: %v", err)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+				}
+			}
+			if !tt.color && strings.Contains(buf.String(), "\033[") {
+				t.Error("expected no color codes")
+			}
+		})
+	}
+
+	if _, err := NewPrinter(io.Discard, "xml", false); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// TestRunStopsAfterCount tests that --count ends the stream after N updates.
+func TestRunStopsAfterCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Errorf("expected since parameter, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 5; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: {\"seq\":%d,\"instrument\":\"BTC-USD\",\"price\":%d}\n\n", i, i, 100+i)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := Run(ctx, Config{URL: srv.URL, Format: FormatJSON, Count: 3, Since: 10 * time.Minute}, &out, io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 3 {
+		t.Errorf("expected 3 lines, got %d:\n%s", lines, out.String())
+	}
+}
+
+// TestRunUnreachable tests that an unreachable server is reported as an error after MaxFailures attempts.
+func TestRunUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var status bytes.Buffer
+	err := Run(ctx, Config{URL: srv.URL, Format: FormatTable, MaxFailures: 2}, io.Discard, &status)
+	if err == nil || ctx.Err() != nil {
+		t.Fatalf("expected to give up before the deadline, got %v", err)
+	}
+	if n := strings.Count(status.String(), "disconnected"); n != 1 {
+		t.Errorf("expected one retry reported before giving up, got:\n%s", status.String())
+	}
+}
+
+// TestRunUnauthorized tests that a rejected token is reported as an error.
+func TestRunUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := Run(ctx, Config{URL: srv.URL, Format: FormatTable}, io.Discard, io.Discard)
+	if !errors.Is(err, injectiveclient.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Config describes how the server terminates TLS.
+type Config struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile enables mTLS: client certificates are verified against this CA bundle.
+	ClientCAFile string
+	// RequireClientCert rejects handshakes without a client certificate.
+	// When false, certificates are verified if presented, so browsers and internal consumers can share a listener.
+	RequireClientCert bool
+}
+
+// NewServerConfig builds a tls.Config that reloads its certificate from disk and advertises HTTP/2.
+func NewServerConfig(cfg Config) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tlsutil: both certificate and key files are required")
+	}
+
+	reloader, err := NewCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if cfg.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else if cfg.RequireClientCert {
+		return nil, errors.New("tlsutil: requiring client certificates needs a client CA file")
+	}
+
+	return tlsConfig, nil
+}
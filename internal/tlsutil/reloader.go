@@ -0,0 +1,103 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate from disk and picks up renewed files without a restart.
+//
+// It plugs into tls.Config.GetCertificate. On a handshake it checks (at most once per checkInterval)
+// whether either file's modification time changed and, if so, reloads the pair.
+// A failed reload keeps serving the previous certificate, since a renewal tool may write the
+// cert and key in two steps and we might observe the files in between.
+type CertReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+	mutex     sync.Mutex
+}
+
+// NewCertReloader loads the initial certificate, failing fast if the files are missing or invalid.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	cr := &CertReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		checkInterval: 5 * time.Second,
+	}
+	if err := cr.reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// GetCertificate implements the tls.Config callback.
+func (cr *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	if time.Since(cr.lastCheck) >= cr.checkInterval {
+		cr.lastCheck = time.Now()
+		if cr.changed() {
+			if err := cr.reload(); err != nil {
+				log.Printf("[!] certificate reload failed, keeping previous certificate: %v", err)
+			} else {
+				log.Printf("reloaded TLS certificate from %s", cr.certFile)
+			}
+		}
+	}
+
+	return cr.cert, nil
+}
+
+// changed reports whether either file has a different modification time than the loaded pair.
+func (cr *CertReloader) changed() bool {
+	certMod, err := modTime(cr.certFile)
+	if err != nil {
+		return false
+	}
+	keyMod, err := modTime(cr.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certMod.Equal(cr.certMod) || !keyMod.Equal(cr.keyMod)
+}
+
+// reload reads the key pair from disk. Callers other than the constructor must hold the mutex.
+func (cr *CertReloader) reload() error {
+	certMod, err := modTime(cr.certFile)
+	if err != nil {
+		return err
+	}
+	keyMod, err := modTime(cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading key pair: %w", err)
+	}
+
+	cr.cert = &cert
+	cr.certMod = certMod
+	cr.keyMod = keyMod
+	return nil
+}
+
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
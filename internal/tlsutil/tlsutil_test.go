@@ -0,0 +1,206 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// keyPair is a generated certificate with its key, written to disk as PEM files.
+type keyPair struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// generate creates a certificate signed by parent, or a self-signed CA when parent is nil.
+func generate(t *testing.T, dir, name string, parent *keyPair, usage x509.ExtKeyUsage) *keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	kp := &keyPair{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	writePEM(t, kp.certFile, "CERTIFICATE", der)
+	writePEM(t, kp.keyFile, "EC PRIVATE KEY", keyDER)
+	return kp
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// TestCertReloaderPicksUpNewCertificate tests that rewritten files are served on the next handshake.
+func TestCertReloaderPicksUpNewCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first := generate(t, dir, "server", nil, x509.ExtKeyUsageServerAuth)
+
+	cr, err := NewCertReloader(first.certFile, first.keyFile)
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	cr.checkInterval = 0
+
+	got, _ := cr.GetCertificate(nil)
+	if got.Leaf.SerialNumber.Cmp(first.cert.SerialNumber) != 0 {
+		t.Fatal("expected initial certificate")
+	}
+
+	// Overwrite in place, as a renewal tool would, and bump mtimes so the change is visible on coarse filesystems.
+	second := generate(t, dir, "server", nil, x509.ExtKeyUsageServerAuth)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(second.certFile, future, future)
+	os.Chtimes(second.keyFile, future, future)
+
+	got, _ = cr.GetCertificate(nil)
+	if got.Leaf.SerialNumber.Cmp(second.cert.SerialNumber) != 0 {
+		t.Error("expected renewed certificate after files changed")
+	}
+
+	// A half-written renewal must not take the listener down.
+	os.WriteFile(second.keyFile, []byte("garbage"), 0o600)
+	later := future.Add(time.Minute)
+	os.Chtimes(second.keyFile, later, later)
+
+	got, err = cr.GetCertificate(nil)
+	if err != nil || got.Leaf.SerialNumber.Cmp(second.cert.SerialNumber) != 0 {
+		t.Errorf("expected previous certificate to be kept on reload failure, got err=%v", err)
+	}
+}
+
+// TestServerConfigHTTP2AndMTLS tests that the server negotiates HTTP/2 and enforces client certificates.
+func TestServerConfigHTTP2AndMTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := generate(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+	server := generate(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+	client := generate(t, dir, "client", ca, x509.ExtKeyUsageClientAuth)
+
+	tlsConfig, err := NewServerConfig(Config{
+		CertFile:          server.certFile,
+		KeyFile:           server.keyFile,
+		ClientCAFile:      ca.certFile,
+		RequireClientCert: true,
+	})
+	if err != nil {
+		t.Fatalf("new server config: %v", err)
+	}
+
+	// httptest.Server installs its own certificate, so serve through a plain http.Server as main does.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: tlsConfig,
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+	url := "https://" + ln.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: true,
+		}}
+	}
+
+	// Without a client certificate the handshake must fail.
+	if resp, err := newClient().Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected handshake failure without client certificate")
+	}
+
+	clientCert, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	if err != nil {
+		t.Fatalf("load client cert: %v", err)
+	}
+	resp, err := newClient(clientCert).Get(url)
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+}
+
+// TestServerConfigValidation tests configuration errors.
+func TestServerConfigValidation(t *testing.T) {
+	dir := t.TempDir()
+	server := generate(t, dir, "server", nil, x509.ExtKeyUsageServerAuth)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing key", Config{CertFile: server.certFile}},
+		{"missing files", Config{CertFile: filepath.Join(dir, "nope.crt"), KeyFile: filepath.Join(dir, "nope.key")}},
+		{"require client cert without CA", Config{CertFile: server.certFile, KeyFile: server.keyFile, RequireClientCert: true}},
+		{"CA file without certificates", Config{CertFile: server.certFile, KeyFile: server.keyFile, ClientCAFile: server.keyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewServerConfig(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package websocket_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/websocket"
+	"github.com/matheusdutrademoura/injective/internal/websocket/wstest"
+)
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// echoServer upgrades requests and echoes every message back, pinging the client first.
+func echoServer(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Ping([]byte("are you there"))
+		for {
+			op, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(op, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// TestEcho tests that messages of every length encoding survive masking and framing both ways.
+func TestEcho(t *testing.T) {
+	conn := dial(t, echoServer(t))
+
+	for _, size := range []int{0, 5, 125, 126, 1000, 70000} {
+		msg := bytes.Repeat([]byte("x"), size)
+		op := websocket.BinaryMessage
+		if size < 200 {
+			op = websocket.TextMessage
+		}
+		if err := conn.WriteMessage(op, msg); err != nil {
+			t.Fatalf("write %d bytes: %v", size, err)
+		}
+		gotOp, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d bytes: %v", size, err)
+		}
+		if gotOp != op || !bytes.Equal(got, msg) {
+			t.Errorf("size %d: got type %d and %d bytes", size, gotOp, len(got))
+		}
+	}
+}
+
+// TestServerClose tests that a close frame from the server ends reading with ErrClosed.
+func TestServerClose(t *testing.T) {
+	feed := wstest.NewServer(t)
+	conn := dial(t, feed.URL())
+	if !feed.WaitForClients(1) {
+		t.Fatal("client did not connect")
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"subscribe":"BTC-USD"}`))
+	feed.Send("tick")
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "tick" {
+		t.Fatalf("expected tick, got %q (%v)", msg, err)
+	}
+	if got := feed.WaitForReceived(1); len(got) != 1 || got[0] != `{"subscribe":"BTC-USD"}` {
+		t.Errorf("expected subscription to be received, got %q", got)
+	}
+
+	feed.DropConnections()
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, websocket.ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+// TestHandshakeRejected tests that non-upgrade responses fail the dial.
+func TestHandshakeRejected(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected handshake failure, got %v", err)
+	}
+	if _, err := websocket.Dial(context.Background(), srv.URL, nil); err == nil {
+		t.Error("expected http:// URL to be rejected")
+	}
+}
@@ -0,0 +1,145 @@
+// Package wstest provides an in-process stand-in for a price provider's WebSocket feed, for tests.
+//
+// The server records every message clients send (e.g. subscriptions) and broadcasts whatever
+// the test passes to Send, so tests script the feed tick by tick.
+package wstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/websocket"
+)
+
+// Server is a fake WebSocket feed listening on a loopback port.
+type Server struct {
+	srv *httptest.Server
+
+	conns    map[*websocket.Conn]struct{}
+	received []string
+	reject   bool
+	mutex    sync.Mutex // Guards everything above
+}
+
+// NewServer starts a fake feed. It is closed automatically when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{conns: make(map[*websocket.Conn]struct{})}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// URL returns the ws:// address of the feed.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	reject := s.reject
+	s.mutex.Unlock()
+	if reject {
+		http.Error(w, "feed unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	s.conns[conn] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.received = append(s.received, string(msg))
+		s.mutex.Unlock()
+	}
+}
+
+// Send broadcasts a text message to every connected client.
+func (s *Server) Send(msg string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.conns {
+		conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	}
+}
+
+// Received returns the messages clients have sent so far, in order.
+func (s *Server) Received() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// WaitForReceived waits up to a few seconds until clients have sent n messages and returns them.
+func (s *Server) WaitForReceived(n int) []string {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := s.Received(); len(got) >= n {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return s.Received()
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.conns)
+}
+
+// WaitForClients waits up to a few seconds until n clients are connected and reports whether they were.
+func (s *Server) WaitForClients(n int) bool {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if s.Clients() == n {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+// Reject makes the server refuse (true) or accept (false) new connections, simulating a provider outage.
+func (s *Server) Reject(reject bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reject = reject
+}
+
+// DropConnections closes every client connection, forcing clients to reconnect.
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// Close stops the server and disconnects all clients.
+func (s *Server) Close() {
+	s.DropConnections()
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+}
@@ -0,0 +1,369 @@
+// Package injectiveclient consumes an injective server's /stream endpoint.
+//
+// It parses the SSE stream into typed updates, reconnects with exponential backoff
+// (honouring the server's retry hints), resumes from the last event ID so no update in the
+// server's history window is missed, and reports connection state changes as status events.
+//
+//	c, err := injectiveclient.New(injectiveclient.Config{URL: "http://localhost:8080/stream"})
+//	if err != nil { ... }
+//	go c.Run(ctx)
+//	for update := range c.Updates() {
+//		fmt.Println(update.Instrument, update.Price)
+//	}
+package injectiveclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PriceUpdate is a price published by the server. Fields after Instrument are optional
+// and left zero when the server or its provider doesn't report them.
+type PriceUpdate struct {
+	Seq        uint64    `json:"seq,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Price      float64   `json:"price"`
+	Instrument string    `json:"instrument,omitempty"`
+
+	Sources           []string           `json:"sources,omitempty"`
+	ProviderTimestamp time.Time          `json:"provider_timestamp,omitzero"`
+	FetchLatencyMs    float64            `json:"fetch_latency_ms,omitempty"`
+	DerivedFrom       string             `json:"derived_from,omitempty"` // Native instrument of a converted, synthetic one
+	FXRate            float64            `json:"fx_rate,omitempty"`
+	Providers         map[string]float64 `json:"providers,omitempty"` // Price per provider when several are merged
+	Bid               float64            `json:"bid,omitempty"`
+	Ask               float64            `json:"ask,omitempty"`
+	Volume            float64            `json:"volume,omitempty"`
+
+	// Set when the server's anomaly detection flagged the price as suspicious
+	Anomaly *Anomaly `json:"anomaly,omitempty"`
+
+	Change    *Change `json:"change,omitempty"`     // Versus the previous update
+	Change1h  *Change `json:"change_1h,omitempty"`  // Versus an hour ago
+	Change24h *Change `json:"change_24h,omitempty"` // Versus a day ago
+}
+
+// Anomaly explains why the server flagged a price as suspicious.
+type Anomaly struct {
+	Reasons   []string `json:"reasons"` // "jump", "zscore" or "invalid"
+	Reference float64  `json:"reference"`
+	JumpPct   float64  `json:"jump_pct"`
+	ZScore    float64  `json:"zscore,omitempty"`
+}
+
+// Change compares a price with an earlier reference price.
+type Change struct {
+	From float64 `json:"from"`
+	Abs  float64 `json:"abs"`
+	Pct  float64 `json:"pct"`
+}
+
+// Config configures a Client. Only URL is required.
+type Config struct {
+	URL         string    // Stream endpoint, e.g. http://localhost:8080/stream
+	Token       string    // Bearer token, when the server requires one
+	Instruments []string  // Only receive these instruments (all when empty)
+	Since       time.Time // Replay history from this time on the first connection
+
+	// LastEventID resumes a previous session; it takes precedence over Since.
+	LastEventID string
+
+	HTTPClient *http.Client // Defaults to a client without a timeout, as streams are long-lived
+
+	MinBackoff time.Duration // First reconnect delay (default 500ms)
+	MaxBackoff time.Duration // Reconnect delay cap (default 30s)
+
+	// MaxFailures makes Run give up with ErrUnreachable once this many connection attempts in a row
+	// have failed. Zero retries forever.
+	MaxFailures int
+
+	// HeartbeatTimeout is how long the stream may be silent before it's considered dead.
+	// The server sends a heartbeat every 15s by default, so the default is 45s.
+	HeartbeatTimeout time.Duration
+
+	Buffer int // Capacity of the Updates channel (default 64)
+}
+
+// Client streams price updates from an injective server. Create one with New and start it with Run.
+type Client struct {
+	cfg     Config
+	updates chan PriceUpdate
+	status  chan StatusEvent
+
+	lastEventID string
+	retryHint   time.Duration // Last retry interval sent by the server, used instead of backoff
+}
+
+// ErrUnauthorized is returned by Run when the server rejects the credentials; retrying wouldn't help.
+var ErrUnauthorized = errors.New("injectiveclient: unauthorized")
+
+// ErrUnreachable is returned by Run when MaxFailures connection attempts in a row have failed.
+var ErrUnreachable = errors.New("injectiveclient: server unreachable")
+
+func New(cfg Config) (*Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("injectiveclient: URL must be http or https, got %q", cfg.URL)
+	}
+
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{}
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.HeartbeatTimeout <= 0 {
+		cfg.HeartbeatTimeout = 45 * time.Second
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 64
+	}
+
+	return &Client{
+		cfg:         cfg,
+		updates:     make(chan PriceUpdate, cfg.Buffer),
+		status:      make(chan StatusEvent, 16),
+		lastEventID: cfg.LastEventID,
+	}, nil
+}
+
+// Updates returns the channel of price updates. It is closed when Run returns.
+func (c *Client) Updates() <-chan PriceUpdate {
+	return c.updates
+}
+
+// Status returns the channel of connection status events. It is closed when Run returns.
+// Events are dropped rather than blocking the stream if nobody reads them.
+func (c *Client) Status() <-chan StatusEvent {
+	return c.status
+}
+
+// LastEventID returns the ID of the last update received, for resuming in a later session.
+func (c *Client) LastEventID() string {
+	return c.lastEventID
+}
+
+// Run connects and keeps the stream going until ctx is cancelled, returning nil,
+// the server rejects the credentials, returning ErrUnauthorized, or MaxFailures attempts in a row fail,
+// returning ErrUnreachable.
+func (c *Client) Run(ctx context.Context) error {
+	defer close(c.updates)
+	defer close(c.status)
+
+	backoff := c.cfg.MinBackoff
+	for attempt := 1; ; attempt++ {
+		c.emit(StatusEvent{Kind: StatusConnecting, Attempt: attempt})
+
+		connected, err := c.stream(ctx)
+		if ctx.Err() != nil {
+			c.emit(StatusEvent{Kind: StatusClosed})
+			return nil
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			c.emit(StatusEvent{Kind: StatusClosed, Err: err})
+			return err
+		}
+		if connected {
+			attempt, backoff = 0, c.cfg.MinBackoff
+		} else if c.cfg.MaxFailures > 0 && attempt >= c.cfg.MaxFailures {
+			err = fmt.Errorf("%w: %d connection attempts failed, the last with: %w", ErrUnreachable, attempt, err)
+			c.emit(StatusEvent{Kind: StatusClosed, Err: err})
+			return err
+		}
+
+		wait := backoff
+		var rejected *rejectedError
+		switch {
+		case errors.As(err, &rejected) && rejected.retryAfter > 0:
+			wait = rejected.retryAfter
+		case c.retryHint > 0:
+			wait = c.retryHint
+			c.retryHint = 0
+		default:
+			backoff = min(backoff*2, c.cfg.MaxBackoff)
+		}
+		c.emit(StatusEvent{Kind: StatusDisconnected, Err: err, RetryIn: wait, LastEventID: c.lastEventID})
+
+		select {
+		case <-ctx.Done():
+			c.emit(StatusEvent{Kind: StatusClosed})
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// rejectedError is a non-200 response worth retrying, possibly after a server-specified delay.
+type rejectedError struct {
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *rejectedError) Error() string {
+	return "injectiveclient: server responded " + e.status
+}
+
+// stream runs a single connection. It reports whether the connection was established.
+func (c *Client) stream(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.requestURL(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+	if c.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", c.lastEventID)
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return false, fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return false, &rejectedError{status: resp.Status, retryAfter: time.Duration(retryAfter) * time.Second}
+	}
+	c.emit(StatusEvent{Kind: StatusConnected, LastEventID: c.lastEventID})
+
+	// The watchdog cancels the request if nothing, not even a heartbeat, arrives in time.
+	watchdog := time.AfterFunc(c.cfg.HeartbeatTimeout, cancel)
+	defer watchdog.Stop()
+	body := &activityReader{r: resp.Body, onRead: func() { watchdog.Reset(c.cfg.HeartbeatTimeout) }}
+
+	err = c.read(ctx, body)
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		err = errors.New("injectiveclient: heartbeat timeout")
+	}
+	return true, err
+}
+
+// requestURL adds the instrument filter and, on a fresh session, the since parameter.
+func (c *Client) requestURL() string {
+	u, _ := url.Parse(c.cfg.URL)
+	q := u.Query()
+	if len(c.cfg.Instruments) > 0 {
+		q.Set("instruments", strings.Join(c.cfg.Instruments, ","))
+	}
+	if c.lastEventID == "" && !c.cfg.Since.IsZero() {
+		q.Set("since", strconv.FormatInt(c.cfg.Since.Unix(), 10))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// read parses SSE events until the stream ends.
+func (c *Client) read(ctx context.Context, body io.Reader) error {
+	var event, id, data string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if strings.HasPrefix(line, ":") {
+				continue // comment, e.g. heartbeat
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "id":
+				id = value
+			case "data":
+				if data != "" {
+					data += "\n"
+				}
+				data += value
+			case "retry":
+				if ms, err := strconv.Atoi(value); err == nil {
+					c.retryHint = time.Duration(ms) * time.Millisecond
+				}
+			}
+			continue
+		}
+
+		// A blank line dispatches the event.
+		if id != "" {
+			c.lastEventID = id
+		}
+		if err := c.dispatch(ctx, event, data); err != nil {
+			return err
+		}
+		event, id, data = "", "", ""
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (c *Client) dispatch(ctx context.Context, event, data string) error {
+	switch event {
+	case "", "message":
+		if data == "" {
+			return nil
+		}
+		var update PriceUpdate
+		if err := json.Unmarshal([]byte(data), &update); err != nil {
+			return fmt.Errorf("injectiveclient: decoding update: %w", err)
+		}
+		select {
+		case c.updates <- update:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case "shutdown":
+		c.emit(StatusEvent{Kind: StatusServerShutdown, LastEventID: c.lastEventID, Data: data})
+	case "auth_expired":
+		c.emit(StatusEvent{Kind: StatusAuthExpired, Data: data})
+		return fmt.Errorf("%w: token expired", ErrUnauthorized)
+	default:
+		c.emit(StatusEvent{Kind: StatusEventReceived, Event: event, Data: data})
+	}
+	return nil
+}
+
+func (c *Client) emit(ev StatusEvent) {
+	select {
+	case c.status <- ev:
+	default:
+	}
+}
+
+// activityReader calls onRead whenever data arrives.
+type activityReader struct {
+	r      io.Reader
+	onRead func()
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.onRead()
+	}
+	return n, err
+}
@@ -0,0 +1,207 @@
+package injectiveclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matheusdutrademoura/injective/internal/auth"
+	"github.com/matheusdutrademoura/injective/internal/server"
+	"github.com/matheusdutrademoura/injective/pkg/injectiveclient"
+)
+
+// startServer runs a real injective server fed by a mock CoinDesk API that ticks every 50ms.
+func startServer(t *testing.T) (*server.Server, *httptest.Server) {
+	t.Helper()
+
+	var price atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Data": {"BTC-USD": {"VALUE": %d}}}`, 60000+price.Add(1))
+	}))
+	t.Cleanup(api.Close)
+
+	t.Setenv("COINDESK_API_KEY", "dummy")
+	t.Setenv("COINDESK_API_URL", api.URL+"?api_key=%s")
+	t.Setenv("UPDATE_INTERVAL", "50ms")
+	t.Setenv("STREAM_HEARTBEAT", "100ms")
+	t.Setenv("RATE_LIMIT_RPS", "0")
+
+	srv := server.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Run(ctx)
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func newClient(t *testing.T, cfg injectiveclient.Config) (*injectiveclient.Client, <-chan error) {
+	t.Helper()
+	cfg.MinBackoff = 20 * time.Millisecond
+	c, err := injectiveclient.New(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	return c, done
+}
+
+func nextUpdate(t *testing.T, c *injectiveclient.Client) injectiveclient.PriceUpdate {
+	t.Helper()
+	select {
+	case u := <-c.Updates():
+		return u
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for update")
+		return injectiveclient.PriceUpdate{}
+	}
+}
+
+func waitStatus(t *testing.T, c *injectiveclient.Client, kind injectiveclient.StatusKind) injectiveclient.StatusEvent {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-c.Status():
+			if ev.Kind == kind {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for status %s", kind)
+			return injectiveclient.StatusEvent{}
+		}
+	}
+}
+
+// TestResumeAfterDisconnect tests that a dropped connection is resumed via Last-Event-ID without gaps or duplicates.
+func TestResumeAfterDisconnect(t *testing.T) {
+	_, ts := startServer(t)
+	c, _ := newClient(t, injectiveclient.Config{URL: ts.URL + "/stream", Instruments: []string{"BTC-USD"}})
+
+	first := nextUpdate(t, c)
+	if first.Instrument != "BTC-USD" || first.Price <= 60000 {
+		t.Errorf("unexpected update %+v", first)
+	}
+	prev := first.Seq
+	for i := 0; i < 2; i++ {
+		u := nextUpdate(t, c)
+		if u.Seq != prev+1 {
+			t.Fatalf("expected seq %d, got %d", prev+1, u.Seq)
+		}
+		if u.Change == nil || u.Change.Abs != 1 || len(u.Sources) == 0 || u.Sources[0] != "coindesk" {
+			t.Errorf("expected enriched update, got %+v", u)
+		}
+		prev = u.Seq
+	}
+
+	ts.CloseClientConnections()
+	waitStatus(t, c, injectiveclient.StatusDisconnected)
+	reconnected := waitStatus(t, c, injectiveclient.StatusConnected)
+	if reconnected.LastEventID != fmt.Sprint(prev) {
+		t.Errorf("expected resume from %d, got %q", prev, reconnected.LastEventID)
+	}
+
+	// Updates published while we were away are replayed from the server's history.
+	for i := 0; i < 3; i++ {
+		u := nextUpdate(t, c)
+		if u.Seq != prev+1 {
+			t.Fatalf("expected seq %d after resume, got %d", prev+1, u.Seq)
+		}
+		prev = u.Seq
+	}
+}
+
+// TestSinceReplaysHistory tests that Since replays buffered updates on the first connection.
+func TestSinceReplaysHistory(t *testing.T) {
+	_, ts := startServer(t)
+	time.Sleep(300 * time.Millisecond) // let a few updates accumulate
+
+	c, _ := newClient(t, injectiveclient.Config{URL: ts.URL + "/stream", Since: time.Now().Add(-time.Minute)})
+	if u := nextUpdate(t, c); u.Seq != 1 {
+		t.Errorf("expected history to start at seq 1, got %d", u.Seq)
+	}
+}
+
+// TestServerShutdownStatus tests that the server's goodbye event is surfaced as a status.
+func TestServerShutdownStatus(t *testing.T) {
+	srv, ts := startServer(t)
+	c, _ := newClient(t, injectiveclient.Config{URL: ts.URL + "/stream"})
+	nextUpdate(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+
+	ev := waitStatus(t, c, injectiveclient.StatusServerShutdown)
+	if !strings.Contains(ev.Data, "last_event_id") {
+		t.Errorf("expected shutdown payload with last event ID, got %q", ev.Data)
+	}
+}
+
+// TestUnauthorized tests that rejected credentials end Run instead of retrying forever.
+func TestUnauthorized(t *testing.T) {
+	t.Setenv("JWT_SECRET", "sdk-secret")
+	_, ts := startServer(t)
+
+	_, done := newClient(t, injectiveclient.Config{URL: ts.URL + "/stream", Token: "bogus"})
+	select {
+	case err := <-done:
+		if !errors.Is(err, injectiveclient.ErrUnauthorized) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return")
+	}
+
+	token, _ := auth.Sign(auth.Claims{ExpiresAt: time.Now().Add(time.Minute).Unix()}, []byte("sdk-secret"))
+	c, _ := newClient(t, injectiveclient.Config{URL: ts.URL + "/stream", Token: token})
+	nextUpdate(t, c)
+}
+
+// TestMaxFailures tests that Run gives up once MaxFailures connection attempts in a row have failed.
+func TestMaxFailures(t *testing.T) {
+	var attempts atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	_, done := newClient(t, injectiveclient.Config{URL: down.URL, MaxFailures: 3})
+	select {
+	case err := <-done:
+		if !errors.Is(err, injectiveclient.ErrUnreachable) || attempts.Load() != 3 {
+			t.Errorf("expected ErrUnreachable after 3 attempts, got %v after %d", err, attempts.Load())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return")
+	}
+}
+
+// TestHeartbeatTimeout tests that a silent connection is abandoned and retried.
+func TestHeartbeatTimeout(t *testing.T) {
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer silent.Close()
+
+	c, _ := newClient(t, injectiveclient.Config{URL: silent.URL, HeartbeatTimeout: 100 * time.Millisecond})
+	waitStatus(t, c, injectiveclient.StatusConnected)
+	ev := waitStatus(t, c, injectiveclient.StatusDisconnected)
+	if ev.Err == nil || !strings.Contains(ev.Err.Error(), "heartbeat") {
+		t.Errorf("expected heartbeat timeout, got %v", ev.Err)
+	}
+}
@@ -0,0 +1,48 @@
+package injectiveclient
+
+import "time"
+
+// StatusKind describes what happened to the connection.
+type StatusKind int
+
+const (
+	StatusConnecting     StatusKind = iota // A connection attempt is starting
+	StatusConnected                        // The server accepted the stream
+	StatusDisconnected                     // The stream ended; a reconnect follows after RetryIn
+	StatusServerShutdown                   // The server announced it is shutting down
+	StatusAuthExpired                      // The token expired; Run returns ErrUnauthorized
+	StatusEventReceived                    // A named event the client doesn't interpret (see Event and Data)
+	StatusClosed                           // Run is returning
+)
+
+func (k StatusKind) String() string {
+	switch k {
+	case StatusConnecting:
+		return "connecting"
+	case StatusConnected:
+		return "connected"
+	case StatusDisconnected:
+		return "disconnected"
+	case StatusServerShutdown:
+		return "server_shutdown"
+	case StatusAuthExpired:
+		return "auth_expired"
+	case StatusEventReceived:
+		return "event"
+	case StatusClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StatusEvent reports a change in the connection's state.
+type StatusEvent struct {
+	Kind        StatusKind
+	Attempt     int           // Connection attempt number since the last successful connection (StatusConnecting)
+	Err         error         // Why the stream ended (StatusDisconnected, StatusClosed)
+	RetryIn     time.Duration // Delay before the next attempt (StatusDisconnected)
+	LastEventID string        // Resume point
+	Event       string        // SSE event name (StatusEventReceived)
+	Data        string        // Raw event payload, when the status came from an event
+}